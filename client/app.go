@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	"client/internal/client"
 	"client/internal/config"
@@ -19,11 +21,14 @@ import (
 // App struct
 type App struct {
 	ctx           context.Context
-	apiClient     *client.APIClient
-	configuration *config.ResolvedConfiguration
+	apiClient     *client.APIClient // replaced when the server URL changes; guarded by clientMu
+	serverURL     string            // guarded by clientMu
+	clientMu      sync.RWMutex
+	configuration *config.ResolvedConfiguration // replaced whole, never edited; guarded by configMu
+	configMu      sync.RWMutex
 	logger        *logrus.Logger
-	serverURL     string
 	configDir     string
+	events        *client.EventListener
 }
 
 // getConfigDir returns the OS-appropriate config directory
@@ -89,30 +94,52 @@ func NewApp() *App {
 	return &App{
 		logger:    logger,
 		configDir: configDir,
+		events:    client.NewEventListener(logger),
 	}
 }
 
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
-	a.serverURL = loadServerURL(a.configDir)
+	serverURL := loadServerURL(a.configDir)
 
 	a.logger.Infof("Robo-Stream Client starting...")
 	a.logger.Infof("Platform: %s/%s", runtime.GOOS, runtime.GOARCH)
-	a.logger.Infof("Server URL: %s", a.serverURL)
+	a.logger.Infof("Server URL: %s", serverURL)
 
-	a.apiClient = client.NewAPIClient(a.serverURL, a.logger, a.configDir)
+	a.clientMu.Lock()
+	a.serverURL = serverURL
+	a.apiClient = client.NewAPIClient(serverURL, a.logger, a.configDir)
+	a.clientMu.Unlock()
 	go a.connectAndLoad()
 }
 
 // shutdown is called when the app shuts down
 func (a *App) shutdown(ctx context.Context) {
 	a.logger.Info("Shutting down...")
+	a.events.Stop()
 }
 
-// connectAndLoad connects to server and loads configuration
+// connection returns the API client and the server URL it talks to
+func (a *App) connection() (*client.APIClient, string) {
+	a.clientMu.RLock()
+	defer a.clientMu.RUnlock()
+	return a.apiClient, a.serverURL
+}
+
+// currentClient returns the API client, which SetServerURL may replace at
+// any time
+func (a *App) currentClient() *client.APIClient {
+	apiClient, _ := a.connection()
+	return apiClient
+}
+
+// connectAndLoad connects to server and loads configuration. Startup,
+// Reconnect, Pair and SetServerURL may run it concurrently.
 func (a *App) connectAndLoad() {
-	info, err := a.apiClient.GetServerInfo()
+	apiClient, serverURL := a.connection()
+
+	info, err := apiClient.GetServerInfo()
 	if err != nil {
 		a.logger.Errorf("Failed to connect to server: %v", err)
 		wailsruntime.EventsEmit(a.ctx, "connection_error", err.Error())
@@ -123,29 +150,98 @@ func (a *App) connectAndLoad() {
 	wailsruntime.EventsEmit(a.ctx, "connected", info)
 
 	// Register with server and get default configuration
-	resolved, err := a.apiClient.Register()
+	resolved, err := apiClient.Register()
+	if errors.Is(err, client.ErrPairingRequired) {
+		a.logger.Warnf("Server requires pairing")
+		wailsruntime.EventsEmit(a.ctx, "pairing_required", serverURL)
+		return
+	}
 	if err != nil {
 		a.logger.Errorf("Failed to register with server: %v", err)
 		wailsruntime.EventsEmit(a.ctx, "config_error", err.Error())
 		return
 	}
 
-	a.configuration = resolved
+	// Holding clientMu keeps SetServerURL from swapping the client until the
+	// stream is replaced; a client it already swapped out is left alone
+	a.clientMu.RLock()
+	defer a.clientMu.RUnlock()
+	if a.apiClient != apiClient {
+		a.logger.Infof("Server URL changed while connecting to %s", serverURL)
+		return
+	}
+
+	a.setConfiguration(resolved)
 
 	a.logger.Infof("Loaded configuration: %s (%dx%d grid, %d buttons)",
 		resolved.Name, resolved.Grid.Rows, resolved.Grid.Cols, len(resolved.Buttons))
 
 	wailsruntime.EventsEmit(a.ctx, "configuration_loaded", resolved)
+
+	// Replace any previous event stream (e.g. after a server URL change)
+	a.events.Listen(a.ctx, apiClient, a.handleEvent, func() {
+		wailsruntime.EventsEmit(a.ctx, "pairing_required", serverURL)
+	})
+}
+
+// handleEvent forwards a pushed OBS event to the frontend
+func (a *App) handleEvent(event config.OBSEvent) {
+	a.logger.Debugf("OBS event: %s %v", event.Type, event.Data)
+	if event.Type == "config_changed" {
+		a.handleConfigChanged(event)
+		return
+	}
+	if event.Status != nil {
+		a.emitStatusUpdate(event.Status)
+	}
+}
+
+// handleConfigChanged refetches the configuration after the server
+// reassigned or edited it
+func (a *App) handleConfigChanged(event config.OBSEvent) {
+	configID, _ := event.Data["config_id"].(string)
+	reason, _ := event.Data["reason"].(string)
+
+	// Our own LoadConfiguration already has the new configuration
+	if current := a.currentConfiguration(); reason == "reassigned" && current != nil && current.ID == configID {
+		return
+	}
+
+	resolved, err := a.currentClient().GetClientConfig()
+	if err != nil {
+		a.logger.Errorf("Failed to reload configuration: %v", err)
+		wailsruntime.EventsEmit(a.ctx, "config_error", err.Error())
+		return
+	}
+
+	a.setConfiguration(resolved)
+	a.logger.Infof("Configuration %s by server: %s", reason, resolved.Name)
+	wailsruntime.EventsEmit(a.ctx, "configuration_loaded", resolved)
+}
+
+// currentConfiguration returns the loaded configuration, which the event
+// goroutine may replace at any time
+func (a *App) currentConfiguration() *config.ResolvedConfiguration {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.configuration
+}
+
+// setConfiguration replaces the loaded configuration
+func (a *App) setConfiguration(resolved *config.ResolvedConfiguration) {
+	a.configMu.Lock()
+	defer a.configMu.Unlock()
+	a.configuration = resolved
 }
 
 // GetConfiguration returns the current configuration
 func (a *App) GetConfiguration() *config.ResolvedConfiguration {
-	return a.configuration
+	return a.currentConfiguration()
 }
 
 // GetConfigurations returns all available configurations
 func (a *App) GetConfigurations() ([]config.Configuration, error) {
-	configs, err := a.apiClient.GetConfigurations()
+	configs, err := a.currentClient().GetConfigurations()
 	if err != nil {
 		a.logger.Errorf("Failed to get configurations: %v", err)
 		return nil, err
@@ -155,13 +251,13 @@ func (a *App) GetConfigurations() ([]config.Configuration, error) {
 
 // LoadConfiguration loads a specific configuration
 func (a *App) LoadConfiguration(configID string) error {
-	resolved, err := a.apiClient.GetConfiguration(configID)
+	resolved, err := a.currentClient().GetConfiguration(configID)
 	if err != nil {
 		a.logger.Errorf("Failed to load configuration: %v", err)
 		return err
 	}
 
-	a.configuration = resolved
+	a.setConfiguration(resolved)
 
 	a.logger.Infof("Loaded configuration: %s", resolved.Name)
 	wailsruntime.EventsEmit(a.ctx, "configuration_loaded", resolved)
@@ -170,64 +266,74 @@ func (a *App) LoadConfiguration(configID string) error {
 }
 
 // PressButton executes a button action
-// Position is in format "btn-0-0" (btn-row-col)
+// Position is in format "btn-0-0-0" (btn-page-row-col); "btn-0-0" is page 0
 func (a *App) PressButton(position string) error {
-	if a.configuration == nil {
+	configuration := a.currentConfiguration()
+	if configuration == nil {
 		return fmt.Errorf("no configuration loaded")
 	}
 
-	// Parse position string "btn-0-0" to row and col
+	// Parse position string to page, row and col
 	parts := strings.Split(position, "-")
-	if len(parts) != 3 {
+	if len(parts) == 3 {
+		parts = []string{parts[0], "0", parts[1], parts[2]}
+	}
+	if len(parts) != 4 {
 		return fmt.Errorf("invalid position format: %s", position)
 	}
 
-	row, err := strconv.Atoi(parts[1])
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid page in position: %s", position)
+	}
+
+	row, err := strconv.Atoi(parts[2])
 	if err != nil {
 		return fmt.Errorf("invalid row in position: %s", position)
 	}
 
-	col, err := strconv.Atoi(parts[2])
+	col, err := strconv.Atoi(parts[3])
 	if err != nil {
 		return fmt.Errorf("invalid col in position: %s", position)
 	}
 
 	// Find button at this position
-	button := a.configuration.GetButtonAt(row, col)
+	button := configuration.GetButtonAt(page, row, col)
 	if button == nil {
 		return fmt.Errorf("no button at position: %s", position)
 	}
 
 	a.logger.Infof("Button pressed: %s (action: %s)", button.Text, button.Action.Type)
 
-	err = a.apiClient.ExecuteAction(button.Action)
+	err = a.currentClient().ExecuteAction(button.Action, button.ID)
 	if err != nil {
 		a.logger.Errorf("Failed to execute action: %v", err)
 		return err
 	}
 
-	go a.emitStatusUpdate()
+	// Status changes arrive over the event stream
 	return nil
 }
 
 // GetStatus returns current OBS status
 func (a *App) GetStatus() (map[string]interface{}, error) {
-	return a.apiClient.GetOBSStatus()
+	return a.currentClient().GetOBSStatus()
 }
 
 // GetServerURL returns the configured server URL
 func (a *App) GetServerURL() string {
-	return a.serverURL
+	_, serverURL := a.connection()
+	return serverURL
 }
 
 // GetCurrentConfiguration returns the currently loaded configuration
 func (a *App) GetCurrentConfiguration() *config.ResolvedConfiguration {
-	return a.configuration
+	return a.currentConfiguration()
 }
 
 // GetOBSStatus returns current OBS status (recording, streaming, etc)
 func (a *App) GetOBSStatus() (map[string]interface{}, error) {
-	status, err := a.apiClient.GetOBSStatus()
+	status, err := a.currentClient().GetOBSStatus()
 	if err != nil {
 		return map[string]interface{}{
 			"connected": false,
@@ -238,10 +344,18 @@ func (a *App) GetOBSStatus() (map[string]interface{}, error) {
 	return status, nil
 }
 
-// SetServerURL sets a new server URL and reconnects
+// SetServerURL sets a new server URL and reconnects. A token is only valid
+// for the server that issued it, so a new server needs pairing again.
 func (a *App) SetServerURL(url string) error {
+	a.clientMu.Lock()
+	if url != a.serverURL {
+		if err := client.ClearToken(a.configDir); err != nil {
+			a.logger.Warnf("Failed to clear token: %v", err)
+		}
+	}
 	a.serverURL = url
 	a.apiClient = client.NewAPIClient(url, a.logger, a.configDir)
+	a.clientMu.Unlock()
 
 	if err := saveServerURL(a.configDir, url); err != nil {
 		a.logger.Warnf("Failed to save server URL: %v", err)
@@ -252,6 +366,16 @@ func (a *App) SetServerURL(url string) error {
 	return nil
 }
 
+// Pair pairs with the server using the code it shows, then connects
+func (a *App) Pair(code string) error {
+	if err := a.currentClient().Pair(code); err != nil {
+		a.logger.Errorf("Failed to pair: %v", err)
+		return err
+	}
+	go a.connectAndLoad()
+	return nil
+}
+
 // Reconnect attempts to reconnect to the server
 func (a *App) Reconnect() error {
 	go a.connectAndLoad()
@@ -263,12 +387,7 @@ func (a *App) ToggleFullscreen() {
 	wailsruntime.WindowToggleMaximise(a.ctx)
 }
 
-// emitStatusUpdate fetches status and emits to frontend
-func (a *App) emitStatusUpdate() {
-	status, err := a.apiClient.GetOBSStatus()
-	if err != nil {
-		a.logger.Errorf("Failed to get OBS status: %v", err)
-		return
-	}
+// emitStatusUpdate emits a pushed status snapshot to the frontend
+func (a *App) emitStatusUpdate(status map[string]interface{}) {
 	wailsruntime.EventsEmit(a.ctx, "status_update", status)
 }
@@ -1,6 +1,8 @@
 // Robo-Stream Client - Touchscreen Optimized
 
 let currentConfiguration = null;
+let currentPage = 0;
+let pageHistory = []; // pages opened folders came from, for page_back
 let obsStatus = {
     streaming: false,
     recording: false,
@@ -36,8 +38,8 @@ async function initializeApp() {
             handleConfigurationLoaded(config);
         }
 
-        // Start status polling
-        startStatusPolling();
+        // Load initial status; updates are pushed by the backend afterwards
+        updateStatusFromBackend();
     } catch (err) {
         console.error('Initialization error:', err);
         showConnectionBanner('Failed to initialize: ' + err, 'error');
@@ -51,6 +53,13 @@ function setupEventListeners() {
     document.getElementById('btn-close-settings-modal').addEventListener('click', closeSettings);
     document.getElementById('btn-update-server').addEventListener('click', updateServerURL);
 
+    // Pairing
+    document.getElementById('btn-close-pairing-modal').addEventListener('click', closePairing);
+    document.getElementById('btn-pair').addEventListener('click', pairWithServer);
+    document.getElementById('input-pairing-code').addEventListener('keydown', (e) => {
+        if (e.key === 'Enter') pairWithServer();
+    });
+
     // Config selector
     document.getElementById('btn-select-config').addEventListener('click', openConfigSelector);
     document.getElementById('btn-close-config-modal').addEventListener('click', closeConfigSelector);
@@ -65,6 +74,8 @@ function setupEventListeners() {
     window.runtime.EventsOn('connection_error', handleConnectionError);
     window.runtime.EventsOn('configuration_loaded', handleConfigurationLoaded);
     window.runtime.EventsOn('config_error', handleConfigError);
+    window.runtime.EventsOn('pairing_required', openPairing);
+    window.runtime.EventsOn('status_update', applyStatus);
 }
 
 // Handle connected event
@@ -86,7 +97,13 @@ function handleConfigurationLoaded(config) {
     console.log('Button count:', config.buttons.length);
     console.log('Buttons:', config.buttons.map(b => `${b.text} at (${b.row},${b.col})`));
     
+    // Stay on the same page when the configuration is reloaded, if it still exists
+    const sameConfig = currentConfiguration && currentConfiguration.id === config.id;
     currentConfiguration = config;
+    if (!sameConfig || currentPage >= getPageCount()) {
+        currentPage = 0;
+        pageHistory = [];
+    }
     renderButtonGrid();
     document.getElementById('config-name').textContent = config.name;
     showConnectionBanner('Configuration loaded: ' + config.name, 'connected');
@@ -120,13 +137,19 @@ function renderButtonGrid() {
     grid.style.gridTemplateColumns = `repeat(${cols}, 1fr)`;
     grid.style.gridTemplateRows = `repeat(${rows}, 1fr)`;
 
-    console.log(`Rendering ${rows}x${cols} grid with ${currentConfiguration.buttons.length} buttons`);
+    const buttons = getPageButtons(currentPage);
+    console.log(`Rendering ${rows}x${cols} grid, page ${currentPage + 1}/${getPageCount()} with ${buttons.length} buttons`);
+
+    // Show the page name when there is more than one page
+    const pages = currentConfiguration.pages || [];
+    document.getElementById('page-name').textContent =
+        pages.length > 1 ? (pages[currentPage].name || `Page ${currentPage + 1}`) : '';
 
     // Create all cells in grid order
     for (let row = 0; row < rows; row++) {
         for (let col = 0; col < cols; col++) {
             // Find button at this position
-            const button = currentConfiguration.buttons.find(b => b.row === row && b.col === col);
+            const button = buttons.find(b => b.row === row && b.col === col);
             
             if (button) {
                 renderButton(button);
@@ -140,15 +163,61 @@ function renderButtonGrid() {
     setTimeout(() => lucide.createIcons(), 50);
 }
 
+// Number of pages in the current configuration (older servers send none)
+function getPageCount() {
+    const pages = currentConfiguration?.pages || [];
+    return Math.max(pages.length, 1);
+}
+
+// Buttons on a page of the current configuration
+function getPageButtons(page) {
+    const pages = currentConfiguration.pages || [];
+    if (pages.length === 0) {
+        return page === 0 ? currentConfiguration.buttons : [];
+    }
+    return pages[page]?.buttons || [];
+}
+
+// Switch to another page
+function goToPage(page) {
+    const count = getPageCount();
+    currentPage = Math.max(0, Math.min(page, count - 1));
+    renderButtonGrid();
+}
+
+// Handle folder/page actions locally. Returns true if the action was one.
+function handleNavigation(action) {
+    const count = getPageCount();
+    switch (action.type) {
+        case 'folder':
+            pageHistory.push(currentPage);
+            goToPage((action.params?.page || 1) - 1);
+            return true;
+        case 'page_next':
+            goToPage((currentPage + 1) % count);
+            return true;
+        case 'page_prev':
+            goToPage((currentPage - 1 + count) % count);
+            return true;
+        case 'page_back':
+            if (pageHistory.length > 0) {
+                goToPage(pageHistory.pop());
+            }
+            return true;
+    }
+    return false;
+}
+
 // Render a button
 function renderButton(button) {
     const grid = document.getElementById('button-grid');
     const buttonEl = document.createElement('button');
     buttonEl.className = 'deck-button';
     buttonEl.style.backgroundColor = button.color;
-    buttonEl.dataset.position = `btn-${button.row}-${button.col}`;
+    buttonEl.dataset.position = button.id;
     buttonEl.dataset.buttonId = button.id;
     buttonEl.dataset.actionType = button.action.type; // Store action type
+    buttonEl.dataset.icon = button.icon || 'square';
     
     // For scene buttons, store the scene name (it's in action.params.scene_name)
     if (button.action.type === 'switch_scene' && button.action.params?.scene_name) {
@@ -161,11 +230,27 @@ function renderButton(button) {
         <span class="button-text">${button.text}</span>
     `;
 
-    // Check if this button should show indicator based on current state
-    updateButtonIndicator(buttonEl);
+    // Toggle buttons get their look from the server; others use the indicator
+    if (button.toggle) {
+        buttonEl.dataset.toggle = 'true';
+        buttonEl.classList.toggle('toggle-on', !!button.active);
+    } else {
+        updateButtonIndicator(buttonEl);
+    }
+
+    // Buttons naming scenes or inputs OBS doesn't have would fail when pressed
+    if (button.broken) {
+        buttonEl.classList.add('broken');
+        buttonEl.title = (button.issues || []).map(issue => issue.message).join('\n');
+    }
 
-    // Press by position
-    buttonEl.addEventListener('click', () => pressButton(`btn-${button.row}-${button.col}`, button.action.type));
+    // Navigation is handled here; everything else is pressed by position
+    buttonEl.addEventListener('click', () => {
+        if (handleNavigation(button.action)) {
+            return;
+        }
+        pressButton(button.id, button.action.type);
+    });
 
     grid.appendChild(buttonEl);
 }
@@ -228,6 +313,9 @@ function isToggleActive(actionType) {
 
 // Update indicator for a specific button
 function updateButtonIndicator(buttonEl) {
+    if (buttonEl.dataset.toggle) {
+        return; // Server-driven, see applyButtonStates
+    }
     if (shouldShowIndicator(buttonEl)) {
         buttonEl.classList.add('recording');
     } else {
@@ -278,65 +366,90 @@ async function pressButton(position, actionType) {
     }
 
     try {
+        // Indicators update when the resulting OBS event is pushed
         await window.go.main.App.PressButton(position);
-        
-        // Update indicators immediately after pressing toggle or scene buttons
-        if (isToggleAction(actionType) || actionType === 'switch_scene') {
-            setTimeout(() => updateStatusFromBackend(), 100);
-        }
     } catch (err) {
         console.error('Failed to press button:', err);
         alert('Error: ' + err);
     }
 }
 
-// Start status polling
-function startStatusPolling() {
-    // Poll every 2 seconds
-    setInterval(async () => {
-        await updateStatusFromBackend();
-    }, 2000);
-}
-
 // Update status from backend
 async function updateStatusFromBackend() {
     try {
         const status = await window.go.main.App.GetOBSStatus();
-        
-        // Track what changed
-        const streamingChanged = obsStatus.streaming !== (status.streaming || false);
-        const recordingChanged = obsStatus.recording !== (status.recording || false);
-        const sceneChanged = obsStatus.currentScene !== (status.current_scene || '');
-        
-        // Update state
-        obsStatus.streaming = status.streaming || false;
-        obsStatus.recording = status.recording || false;
-        obsStatus.currentScene = status.current_scene || '';
-        
-        // Debug log ALL status updates to see what we're getting
-        console.log('Status update:', {
-            streaming: obsStatus.streaming,
-            recording: obsStatus.recording,
-            currentScene: obsStatus.currentScene
-        });
-        
-        // Debug log significant changes
-        if (streamingChanged) {
-            console.log('Streaming state changed:', obsStatus.streaming);
-        }
-        if (recordingChanged) {
-            console.log('Recording state changed:', obsStatus.recording);
+        applyStatus(status);
+    } catch (err) {
+        console.error('Failed to get status:', err);
+    }
+}
+
+// Apply a status snapshot (fetched or pushed via status_update)
+function applyStatus(status) {
+    // Track what changed
+    const streamingChanged = obsStatus.streaming !== (status.streaming || false);
+    const recordingChanged = obsStatus.recording !== (status.recording || false);
+    const sceneChanged = obsStatus.currentScene !== (status.current_scene || '');
+    
+    // Update state
+    obsStatus.streaming = status.streaming || false;
+    obsStatus.recording = status.recording || false;
+    obsStatus.currentScene = status.current_scene || '';
+    
+    // Debug log significant changes
+    if (streamingChanged) {
+        console.log('Streaming state changed:', obsStatus.streaming);
+    }
+    if (recordingChanged) {
+        console.log('Recording state changed:', obsStatus.recording);
+    }
+    if (sceneChanged) {
+        console.log('Scene changed:', obsStatus.currentScene);
+    }
+    
+    // Update all indicators if anything changed
+    if (streamingChanged || recordingChanged || sceneChanged) {
+        updateAllIndicators();
+    }
+
+    if (Array.isArray(status.buttons)) {
+        applyButtonStates(status.buttons);
+    }
+}
+
+// Apply the live appearance of toggle buttons sent by the server
+function applyButtonStates(states) {
+    let iconsChanged = false;
+
+    states.forEach(state => {
+        const buttonEl = document.querySelector(`[data-position="${state.id}"]`);
+        if (!buttonEl) {
+            return;
         }
-        if (sceneChanged) {
-            console.log('Scene changed:', obsStatus.currentScene);
+
+        buttonEl.style.backgroundColor = state.color;
+        buttonEl.classList.toggle('toggle-on', state.active);
+
+        const textEl = buttonEl.querySelector('.button-text');
+        if (textEl && textEl.textContent !== state.text) {
+            textEl.textContent = state.text;
         }
-        
-        // Update all indicators if anything changed
-        if (streamingChanged || recordingChanged || sceneChanged) {
-            updateAllIndicators();
+
+        const icon = state.icon || 'square';
+        if (buttonEl.dataset.icon !== icon) {
+            buttonEl.dataset.icon = icon;
+            const iconEl = buttonEl.querySelector('i, svg');
+            if (iconEl) {
+                const newIcon = document.createElement('i');
+                newIcon.setAttribute('data-lucide', icon);
+                iconEl.replaceWith(newIcon);
+                iconsChanged = true;
+            }
         }
-    } catch (err) {
-        console.error('Failed to get status:', err);
+    });
+
+    if (iconsChanged) {
+        lucide.createIcons();
     }
 }
 
@@ -373,6 +486,39 @@ async function updateServerURL() {
     }
 }
 
+// Open pairing modal when the server needs this client to pair
+function openPairing(serverURL) {
+    document.getElementById('pairing-server').textContent =
+        'Show a pairing code on ' + serverURL + ' (Clients → Pair New Client) and enter it here.';
+    document.getElementById('pairing-error').textContent = '';
+    document.getElementById('input-pairing-code').value = '';
+    document.getElementById('pairing-modal').classList.add('open');
+    showConnectionBanner('Pairing required', 'error');
+    document.getElementById('input-pairing-code').focus();
+}
+
+// Close pairing modal
+function closePairing() {
+    document.getElementById('pairing-modal').classList.remove('open');
+}
+
+// Send the pairing code to the server
+async function pairWithServer() {
+    const code = document.getElementById('input-pairing-code').value.trim();
+    if (!code) {
+        return;
+    }
+
+    try {
+        await window.go.main.App.Pair(code);
+        closePairing();
+        showConnectionBanner('Paired, connecting...', 'connecting');
+    } catch (err) {
+        console.error('Pairing failed:', err);
+        document.getElementById('pairing-error').textContent = err;
+    }
+}
+
 // Open configuration selector
 async function openConfigSelector() {
     try {
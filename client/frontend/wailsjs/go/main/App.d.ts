@@ -16,6 +16,8 @@ export function GetStatus():Promise<Record<string, any>>;
 
 export function LoadConfiguration(arg1:string):Promise<void>;
 
+export function Pair(arg1:string):Promise<void>;
+
 export function PressButton(arg1:string):Promise<void>;
 
 export function Reconnect():Promise<void>;
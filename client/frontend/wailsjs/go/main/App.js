@@ -30,6 +30,10 @@ export function LoadConfiguration(arg1) {
   return window['go']['main']['App']['LoadConfiguration'](arg1);
 }
 
+export function Pair(arg1) {
+  return window['go']['main']['App']['Pair'](arg1);
+}
+
 export function PressButton(arg1) {
   return window['go']['main']['App']['PressButton'](arg1);
 }
@@ -3,24 +3,39 @@ package client
 import (
 	"bytes"
 	"client/internal/config"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrPairingRequired is returned when the server rejects the client token,
+// because the client has never paired or has been revoked
+var ErrPairingRequired = errors.New("pairing required")
+
 // APIClient handles communication with YOUR actual robo-stream server
 type APIClient struct {
 	serverURL string
-	sessionID string
 	clientID  string
+	configDir string
 	httpClient *http.Client
 	logger     *logrus.Logger
+
+	// Pair and Register replace these while an event stream may be reading them
+	mu        sync.RWMutex
+	sessionID string
+	token     string
 }
 
 // loadClientID loads or creates a persistent client ID
@@ -46,11 +61,31 @@ func loadClientID(configDir string) string {
 	return clientID
 }
 
+// loadToken loads the token issued when the client paired
+func loadToken(configDir string) string {
+	data, err := os.ReadFile(filepath.Join(configDir, "token.txt"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ClearToken forgets the saved token, e.g. when switching servers
+func ClearToken(configDir string) error {
+	err := os.Remove(filepath.Join(configDir, "token.txt"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
 // NewAPIClient creates a new API client
 func NewAPIClient(serverURL string, logger *logrus.Logger, configDir string) *APIClient {
 	return &APIClient{
 		serverURL: serverURL,
 		clientID:  loadClientID(configDir),
+		token:     loadToken(configDir),
+		configDir: configDir,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -65,6 +100,101 @@ type RegisterResponse struct {
 	Config    config.ResolvedConfiguration  `json:"config"`
 }
 
+// session returns the session ID from the last Register
+func (c *APIClient) session() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sessionID
+}
+
+// authToken returns the token issued when the client paired
+func (c *APIClient) authToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// send adds the client token to a request and sends it. A 401 response is
+// returned as ErrPairingRequired.
+func (c *APIClient) send(req *http.Request) (*http.Response, error) {
+	if token := c.authToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, ErrPairingRequired
+	}
+	return resp, nil
+}
+
+// get sends an authorized GET request
+func (c *APIClient) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+// Pair exchanges the code shown on the server for a token and saves it
+func (c *APIClient) Pair(code string) error {
+	hostname, _ := os.Hostname()
+	jsonData, err := json.Marshal(map[string]string{
+		"code":        strings.TrimSpace(code),
+		"client_id":   c.clientID,
+		"client_name": fmt.Sprintf("Wails Desktop Client (%s)", hostname),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.httpClient.Post(
+		fmt.Sprintf("%s/api/client/pair", c.serverURL),
+		"application/json",
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to pair: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("pairing failed: %s", errResp.Error)
+		}
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pairResp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &pairResp); err != nil || pairResp.Token == "" {
+		return fmt.Errorf("failed to parse pairing response")
+	}
+
+	c.mu.Lock()
+	c.token = pairResp.Token
+	c.mu.Unlock()
+	if err := os.WriteFile(filepath.Join(c.configDir, "token.txt"), []byte(pairResp.Token), 0600); err != nil {
+		c.logger.Warnf("Failed to save token: %v", err)
+	}
+
+	c.logger.Infof("Paired with server %s", c.serverURL)
+	return nil
+}
+
 // GetServerInfo gets server information
 func (c *APIClient) GetServerInfo() (map[string]interface{}, error) {
 	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/health", c.serverURL))
@@ -102,11 +232,15 @@ func (c *APIClient) Register() (*config.ResolvedConfiguration, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := c.httpClient.Post(
+	req, err := http.NewRequest("POST",
 		fmt.Sprintf("%s/api/client/register", c.serverURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to register: %w", err)
 	}
@@ -128,7 +262,9 @@ func (c *APIClient) Register() (*config.ResolvedConfiguration, error) {
 	}
 
 	// Store session ID for future requests
+	c.mu.Lock()
 	c.sessionID = regResp.SessionID
+	c.mu.Unlock()
 
 	c.logger.Infof("Registered with server - Session: %s, Config: %s", 
 		regResp.SessionID, regResp.ConfigID)
@@ -138,7 +274,7 @@ func (c *APIClient) Register() (*config.ResolvedConfiguration, error) {
 
 // GetConfigurations gets all available configurations
 func (c *APIClient) GetConfigurations() ([]config.Configuration, error) {
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/configurations", c.serverURL))
+	resp, err := c.get(fmt.Sprintf("%s/api/configurations", c.serverURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get configurations: %w", err)
 	}
@@ -164,16 +300,16 @@ func (c *APIClient) GetConfigurations() ([]config.Configuration, error) {
 // GetConfiguration gets a specific configuration
 func (c *APIClient) GetConfiguration(configID string) (*config.ResolvedConfiguration, error) {
 	// First switch to this config in our session
-	if c.sessionID != "" {
+	if sessionID := c.session(); sessionID != "" {
 		req, err := http.NewRequest("PUT",
 			fmt.Sprintf("%s/api/client/config/%s", c.serverURL, configID),
 			nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-		req.Header.Set("X-Session-ID", c.sessionID)
+		req.Header.Set("X-Session-ID", sessionID)
 
-		resp, err := c.httpClient.Do(req)
+		resp, err := c.send(req)
 		if err != nil {
 			return nil, fmt.Errorf("failed to switch config: %w", err)
 		}
@@ -198,7 +334,7 @@ func (c *APIClient) GetConfiguration(configID string) (*config.ResolvedConfigura
 	}
 
 	// No session yet, just fetch it
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/configurations/%s", c.serverURL, configID))
+	resp, err := c.get(fmt.Sprintf("%s/api/configurations/%s", c.serverURL, configID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get configuration: %w", err)
 	}
@@ -222,15 +358,53 @@ func (c *APIClient) GetConfiguration(configID string) (*config.ResolvedConfigura
 	return &resolved, nil
 }
 
+// GetClientConfig gets the configuration the server currently assigns to
+// this client's session
+func (c *APIClient) GetClientConfig() (*config.ResolvedConfiguration, error) {
+	sessionID := c.session()
+	if sessionID == "" {
+		return nil, fmt.Errorf("not registered - no session ID")
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/client/config", c.serverURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Session-ID", sessionID)
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client configuration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resolved config.ResolvedConfiguration
+	if err := json.Unmarshal(body, &resolved); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	return &resolved, nil
+}
+
 // GetDefaultConfiguration gets the default configuration
 func (c *APIClient) GetDefaultConfiguration() (*config.ResolvedConfiguration, error) {
 	// If we don't have a session, register first
-	if c.sessionID == "" {
+	if c.session() == "" {
 		return c.Register()
 	}
 
 	// Otherwise fetch default
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/configurations/default", c.serverURL))
+	resp, err := c.get(fmt.Sprintf("%s/api/configurations/default", c.serverURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get default configuration: %w", err)
 	}
@@ -253,13 +427,19 @@ func (c *APIClient) GetDefaultConfiguration() (*config.ResolvedConfiguration, er
 	return &resolved, nil
 }
 
-// ExecuteAction sends an action request to the server with session header
-func (c *APIClient) ExecuteAction(action config.ButtonAction) error {
-	if c.sessionID == "" {
+// ExecuteAction sends an action request to the server with session header.
+// position is the pressed button's ID (its position key), so the server
+// can count the press.
+func (c *APIClient) ExecuteAction(action config.ButtonAction, position string) error {
+	sessionID := c.session()
+	if sessionID == "" {
 		return fmt.Errorf("not registered - no session ID")
 	}
 
-	jsonData, err := json.Marshal(action)
+	jsonData, err := json.Marshal(struct {
+		config.ButtonAction
+		Button string `json:"button,omitempty"`
+	}{action, position})
 	if err != nil {
 		return fmt.Errorf("failed to marshal action: %w", err)
 	}
@@ -274,9 +454,9 @@ func (c *APIClient) ExecuteAction(action config.ButtonAction) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Session-ID", c.sessionID)
+	req.Header.Set("X-Session-ID", sessionID)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -290,9 +470,18 @@ func (c *APIClient) ExecuteAction(action config.ButtonAction) error {
 	return nil
 }
 
-// GetOBSStatus gets the current OBS status
+// GetOBSStatus gets the current OBS status, including the live state of
+// toggle buttons once registered
 func (c *APIClient) GetOBSStatus() (map[string]interface{}, error) {
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/obs/status", c.serverURL))
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/obs/status", c.serverURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if sessionID := c.session(); sessionID != "" {
+		req.Header.Set("X-Session-ID", sessionID)
+	}
+
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get OBS status: %w", err)
 	}
@@ -314,3 +503,56 @@ func (c *APIClient) GetOBSStatus() (map[string]interface{}, error) {
 
 	return status, nil
 }
+
+// StreamEvents connects to the server's event stream and calls handler for
+// every event until the connection drops or ctx is cancelled
+func (c *APIClient) StreamEvents(ctx context.Context, handler func(config.OBSEvent)) error {
+	sessionID := c.session()
+	if sessionID == "" {
+		return fmt.Errorf("not registered - no session ID")
+	}
+
+	wsURL, err := url.Parse(c.serverURL)
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+	wsURL.Scheme = strings.Replace(wsURL.Scheme, "http", "ws", 1)
+	wsURL.Path = strings.TrimSuffix(wsURL.Path, "/") + "/api/events"
+
+	header := http.Header{}
+	header.Set("X-Session-ID", sessionID)
+	header.Set("Authorization", "Bearer "+c.authToken())
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL.String(), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return ErrPairingRequired
+		}
+		return fmt.Errorf("failed to open event stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadJSON when the caller cancels; stop waiting once we return
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c.logger.Infof("Event stream connected")
+
+	for {
+		var event config.OBSEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("event stream closed: %w", err)
+		}
+		handler(event)
+	}
+}
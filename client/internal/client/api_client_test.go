@@ -0,0 +1,306 @@
+package client
+
+import (
+	"client/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeServer speaks the part of the robo-stream server API the client uses:
+// pairing, registration, configurations, actions, status and /api/events.
+// Toggling the stream pushes stream_state_changed to event streams.
+type fakeServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	streaming bool
+	actions   []string // action types executed, in order
+	streams   map[*websocket.Conn]bool
+}
+
+const (
+	fakeCode    = "123456"
+	fakeToken   = "token-1"
+	fakeSession = "session-1"
+)
+
+var fakeConfig = config.ResolvedConfiguration{
+	ID:   "default",
+	Name: "Default",
+	Grid: config.GridConfig{Rows: 1, Cols: 1},
+	Buttons: []config.ResolvedButton{
+		{ID: "btn-0-0-0", Text: "Stream", Action: config.ButtonAction{Type: "toggle_stream"}, Toggle: true},
+	},
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{streams: make(map[*websocket.Conn]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/client/pair", s.pair)
+	mux.HandleFunc("POST /api/client/register", s.authorized(s.register))
+	mux.HandleFunc("GET /api/client/config", s.authorized(s.session(s.clientConfig)))
+	mux.HandleFunc("POST /api/action", s.authorized(s.session(s.action)))
+	mux.HandleFunc("GET /api/obs/status", s.authorized(s.status))
+	mux.HandleFunc("GET /api/events", s.authorized(s.session(s.events)))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// authorized rejects requests without the paired token
+func (s *fakeServer) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fakeToken {
+			http.Error(w, `{"error": "pairing required"}`, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// session rejects requests without the registered session
+func (s *fakeServer) session(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Session-ID") != fakeSession {
+			http.Error(w, `{"error": "session not found"}`, http.StatusNotFound)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *fakeServer) pair(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code     string `json:"code"`
+		ClientID string `json:"client_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code != fakeCode || req.ClientID == "" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid or expired pairing code"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"token": fakeToken})
+}
+
+func (s *fakeServer) register(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(RegisterResponse{SessionID: fakeSession, ConfigID: fakeConfig.ID, Config: fakeConfig})
+}
+
+func (s *fakeServer) clientConfig(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(fakeConfig)
+}
+
+func (s *fakeServer) action(w http.ResponseWriter, r *http.Request) {
+	var action config.ButtonAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if action.Type != "toggle_stream" {
+		http.Error(w, `{"error": "action not permitted by this configuration"}`, http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	s.actions = append(s.actions, action.Type)
+	s.streaming = !s.streaming
+	event := config.OBSEvent{
+		Type:   "stream_state_changed",
+		Data:   map[string]interface{}{"active": s.streaming},
+		Status: s.statusLocked(),
+	}
+	for conn := range s.streams {
+		conn.WriteJSON(event)
+	}
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+func (s *fakeServer) status(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	json.NewEncoder(w).Encode(s.statusLocked())
+}
+
+func (s *fakeServer) statusLocked() map[string]interface{} {
+	return map[string]interface{}{"connected": true, "streaming": s.streaming}
+}
+
+// events sends a status snapshot, then every event until the client leaves
+func (s *fakeServer) events(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	conn.WriteJSON(config.OBSEvent{Type: "status", Status: s.statusLocked()})
+	s.streams[conn] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, conn)
+		s.mu.Unlock()
+	}()
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// dropStreams closes every event stream, as the server does when it stops
+func (s *fakeServer) dropStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.streams {
+		conn.Close()
+	}
+}
+
+// streamCount returns the number of open event streams
+func (s *fakeServer) streamCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+func newTestClient(t *testing.T, serverURL string) *APIClient {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewAPIClient(serverURL, logger, t.TempDir())
+}
+
+// TestAPIClient pairs, registers, presses a button and follows the event
+// stream against the fake server
+func TestAPIClient(t *testing.T) {
+	srv := newFakeServer(t)
+	c := newTestClient(t, srv.URL)
+
+	if _, err := c.Register(); !errors.Is(err, ErrPairingRequired) {
+		t.Fatalf("register before pairing: %v, want ErrPairingRequired", err)
+	}
+	if err := c.Pair("000000"); err == nil {
+		t.Fatal("pairing with a wrong code succeeded")
+	}
+	if err := c.Pair(" " + fakeCode + " "); err != nil {
+		t.Fatalf("pair: %v", err)
+	}
+
+	// The token is saved, so a new client for the same directory is paired
+	if again := NewAPIClient(srv.URL, c.logger, c.configDir); again.token != fakeToken {
+		t.Errorf("saved token = %q", again.token)
+	}
+
+	resolved, err := c.Register()
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if resolved.ID != fakeConfig.ID || len(resolved.Buttons) != 1 {
+		t.Errorf("registered configuration = %+v", resolved)
+	}
+	if current, err := c.GetClientConfig(); err != nil || current.ID != fakeConfig.ID {
+		t.Errorf("client configuration = %+v (%v)", current, err)
+	}
+
+	events := make(chan config.OBSEvent, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- c.StreamEvents(ctx, func(event config.OBSEvent) { events <- event })
+	}()
+
+	next := func() config.OBSEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return config.OBSEvent{}
+		}
+	}
+	if event := next(); event.Type != "status" || event.Status["streaming"] != false {
+		t.Errorf("first event = %+v, want status", event)
+	}
+
+	if err := c.ExecuteAction(resolved.Buttons[0].Action, resolved.Buttons[0].ID); err != nil {
+		t.Fatalf("execute action: %v", err)
+	}
+	if event := next(); event.Type != "stream_state_changed" || event.Data["active"] != true {
+		t.Errorf("event = %+v, want stream started", event)
+	}
+	if status, err := c.GetOBSStatus(); err != nil || status["streaming"] != true {
+		t.Errorf("status = %v (%v)", status, err)
+	}
+
+	if err := c.ExecuteAction(config.ButtonAction{Type: "stop_stream"}, ""); err == nil {
+		t.Error("denied action returned no error")
+	}
+
+	cancel()
+	select {
+	case err := <-streamErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("stream ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not stop when cancelled")
+	}
+}
+
+// TestAPIClientStreamDropped checks that StreamEvents returns when the server
+// closes the stream
+func TestAPIClientStreamDropped(t *testing.T) {
+	srv := newFakeServer(t)
+	c := newTestClient(t, srv.URL)
+	if err := c.Pair(fakeCode); err != nil {
+		t.Fatalf("pair: %v", err)
+	}
+	if _, err := c.Register(); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	connected := make(chan struct{}, 1)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- c.StreamEvents(context.Background(), func(config.OBSEvent) {
+			select {
+			case connected <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not connect")
+	}
+	srv.dropStreams()
+
+	select {
+	case err := <-streamErr:
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Errorf("stream ended with %v, want a closed stream error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not end when the server closed it")
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"client/internal/config"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventListener keeps one server event stream open, reconnecting after
+// drops. Listen replaces the running stream and Stop ends it, so overlapping
+// reconnects never leave a second stream behind.
+type EventListener struct {
+	logger     *logrus.Logger
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	stopped bool
+}
+
+// NewEventListener creates a listener with no stream running
+func NewEventListener(logger *logrus.Logger) *EventListener {
+	return &EventListener{logger: logger, retryDelay: 2 * time.Second}
+}
+
+// Listen stops the running stream and streams events from apiClient to
+// handler until the next Listen or Stop. onRevoked is called if the server
+// rejects the token, since retrying won't help then.
+func (l *EventListener) Listen(ctx context.Context, apiClient *APIClient, handler func(config.OBSEvent), onRevoked func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stopped {
+		return
+	}
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	l.cancel = cancel
+	l.done = done
+
+	go func() {
+		defer close(done)
+		l.run(ctx, apiClient, handler, onRevoked)
+	}()
+}
+
+// Stop ends the running stream and waits for it to close. Later calls to
+// Listen do nothing.
+func (l *EventListener) Stop() {
+	l.mu.Lock()
+	l.stopped = true
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// run streams events, waiting retryDelay between reconnects
+func (l *EventListener) run(ctx context.Context, apiClient *APIClient, handler func(config.OBSEvent), onRevoked func()) {
+	for {
+		err := apiClient.StreamEvents(ctx, handler)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, ErrPairingRequired) {
+			l.logger.Warnf("Event stream rejected: client must pair again")
+			onRevoked()
+			return
+		}
+		l.logger.Warnf("Event stream disconnected: %v (retrying in %s)", err, l.retryDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
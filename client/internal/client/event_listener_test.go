@@ -0,0 +1,137 @@
+package client
+
+import (
+	"client/internal/config"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForStreams waits until the fake server has want event streams open
+func waitForStreams(t *testing.T, srv *fakeServer, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.streamCount() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("server has %d event streams, want %d", srv.streamCount(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func pairedClient(t *testing.T, srv *fakeServer) *APIClient {
+	t.Helper()
+	c := newTestClient(t, srv.URL)
+	if err := c.Pair(fakeCode); err != nil {
+		t.Fatalf("pair: %v", err)
+	}
+	if _, err := c.Register(); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return c
+}
+
+// TestEventListenerReplacesStream starts streams from overlapping reconnects,
+// as the app does, and checks only the last one stays open
+func TestEventListenerReplacesStream(t *testing.T) {
+	srv := newFakeServer(t)
+	c := pairedClient(t, srv)
+	l := NewEventListener(c.logger)
+
+	var stale atomic.Int32
+	handler := func(event config.OBSEvent) {
+		if event.Type == "stream_state_changed" {
+			stale.Add(1)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A reconnect registers again while older streams are running
+			if _, err := c.Register(); err != nil {
+				t.Errorf("register: %v", err)
+			}
+			l.Listen(context.Background(), c, handler, func() { t.Error("stream rejected") })
+		}()
+	}
+	wg.Wait()
+
+	events := make(chan config.OBSEvent, 16)
+	l.Listen(context.Background(), c, func(event config.OBSEvent) { events <- event }, func() { t.Error("stream rejected") })
+	next := func() config.OBSEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return config.OBSEvent{}
+		}
+	}
+	if event := next(); event.Type != "status" {
+		t.Fatalf("first event = %+v, want status", event)
+	}
+	waitForStreams(t, srv, 1)
+
+	if err := c.ExecuteAction(config.ButtonAction{Type: "toggle_stream"}, ""); err != nil {
+		t.Fatalf("execute action: %v", err)
+	}
+	if event := next(); event.Type != "stream_state_changed" {
+		t.Errorf("event = %+v, want stream_state_changed", event)
+	}
+	if n := stale.Load(); n != 0 {
+		t.Errorf("replaced streams received %d events", n)
+	}
+
+	l.Stop()
+	waitForStreams(t, srv, 0)
+
+	l.Listen(context.Background(), c, handler, func() {})
+	time.Sleep(50 * time.Millisecond)
+	if n := srv.streamCount(); n != 0 {
+		t.Errorf("Listen after Stop opened %d streams", n)
+	}
+}
+
+// TestEventListenerReconnects checks a dropped stream is opened again
+func TestEventListenerReconnects(t *testing.T) {
+	srv := newFakeServer(t)
+	c := pairedClient(t, srv)
+	l := NewEventListener(c.logger)
+	l.retryDelay = 10 * time.Millisecond
+	defer l.Stop()
+
+	l.Listen(context.Background(), c, func(config.OBSEvent) {}, func() { t.Error("stream rejected") })
+	waitForStreams(t, srv, 1)
+
+	srv.dropStreams()
+	waitForStreams(t, srv, 0)
+	waitForStreams(t, srv, 1)
+}
+
+// TestEventListenerRevoked checks a rejected token ends the stream instead
+// of retrying
+func TestEventListenerRevoked(t *testing.T) {
+	srv := newFakeServer(t)
+	c := pairedClient(t, srv)
+	c.mu.Lock()
+	c.token = "revoked"
+	c.mu.Unlock()
+
+	l := NewEventListener(c.logger)
+	l.retryDelay = 10 * time.Millisecond
+	revoked := make(chan struct{})
+	l.Listen(context.Background(), c, func(config.OBSEvent) {}, func() { close(revoked) })
+
+	select {
+	case <-revoked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("revoked token was not reported")
+	}
+	l.Stop()
+}
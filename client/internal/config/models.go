@@ -15,12 +15,27 @@ type ButtonAction struct {
 // ResolvedButton represents a button with position from server
 type ResolvedButton struct {
 	ID     string       `json:"id"`
+	Page   int          `json:"page"`
 	Row    int          `json:"row"`
 	Col    int          `json:"col"`
 	Text   string       `json:"text"`
 	Icon   string       `json:"icon"`
 	Color  string       `json:"color"`
 	Action ButtonAction `json:"action"`
+	Toggle bool         `json:"toggle,omitempty"` // appearance follows OBS state
+	Active bool         `json:"active,omitempty"`
+
+	// Broken is set when the button refers to scenes or inputs OBS doesn't
+	// have; Issues says which
+	Broken bool          `json:"broken,omitempty"`
+	Issues []ButtonIssue `json:"issues,omitempty"`
+}
+
+// ButtonIssue is a reference from a button to a missing OBS object
+type ButtonIssue struct {
+	Kind    string `json:"kind"` // scene, input or source
+	Name    string `json:"name"`
+	Message string `json:"message"`
 }
 
 // Configuration represents a button configuration (for listing)
@@ -40,14 +55,36 @@ type ResolvedConfiguration struct {
 	ID      string           `json:"id"`
 	Name    string           `json:"name"`
 	Grid    GridConfig       `json:"grid"`
-	Buttons []ResolvedButton `json:"buttons"` // array of buttons with positions
+	Buttons []ResolvedButton `json:"buttons"` // buttons on the first page
+	Pages   []ResolvedPage   `json:"pages"`   // every page; empty from older servers
+}
+
+// ResolvedPage is one page of buttons in a configuration
+type ResolvedPage struct {
+	Index   int              `json:"index"`
+	Name    string           `json:"name"`
+	Buttons []ResolvedButton `json:"buttons"`
+}
+
+// OBSEvent is a state change pushed by the server over the event stream
+type OBSEvent struct {
+	Type   string                 `json:"type"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Status map[string]interface{} `json:"status,omitempty"`
 }
 
 // GetButtonAt returns the button at the given position
-func (c *ResolvedConfiguration) GetButtonAt(row, col int) *ResolvedButton {
-	for i := range c.Buttons {
-		if c.Buttons[i].Row == row && c.Buttons[i].Col == col {
-			return &c.Buttons[i]
+func (c *ResolvedConfiguration) GetButtonAt(page, row, col int) *ResolvedButton {
+	buttons := c.Buttons
+	if page < len(c.Pages) {
+		buttons = c.Pages[page].Buttons
+	} else if page > 0 {
+		return nil
+	}
+
+	for i := range buttons {
+		if buttons[i].Row == row && buttons[i].Col == col {
+			return &buttons[i]
 		}
 	}
 	return nil
@@ -63,7 +63,7 @@ func main() {
 		Host:              *obsHost,
 		Port:              *obsPort,
 		Password:          *obsPassword,
-		AutoConnect:       true,
+		AutoConnect:       !*testMode, // test mode should fail fast
 		ReconnectInterval: 5 * time.Second,
 	}
 
@@ -95,8 +95,8 @@ func main() {
 		logger.Errorf("OBS error: %v", err)
 	})
 
-	// Connect to OBS
-	if err := obsManager.Connect(); err != nil {
+	// Connect to OBS (keeps retrying in the background when AutoConnect is set)
+	if err := obsManager.Start(); err != nil {
 		logger.Fatalf("Failed to connect to OBS: %v", err)
 	}
 
@@ -111,6 +111,7 @@ func main() {
 	apiHandler := api.NewHandler(obsManager, logger)
 
 	http.HandleFunc("/api/action", apiHandler.HandleAction)
+	http.HandleFunc("/api/actions", apiHandler.HandleListActions)
 	http.HandleFunc("/api/status", apiHandler.HandleGetStatus)
 	http.HandleFunc("/api/scenes", apiHandler.HandleGetScenes)
 	http.HandleFunc("/api/inputs", apiHandler.HandleGetInputs)
@@ -12,15 +12,17 @@ import (
 
 // Handler handles API requests
 type Handler struct {
-	manager *obs.Manager
-	logger  *logrus.Logger
+	manager  *obs.Manager
+	registry *actions.Registry
+	logger   *logrus.Logger
 }
 
 // NewHandler creates a new API handler
 func NewHandler(manager *obs.Manager, logger *logrus.Logger) *Handler {
 	return &Handler{
-		manager: manager,
-		logger:  logger,
+		manager:  manager,
+		registry: actions.DefaultRegistry(),
+		logger:   logger,
 	}
 }
 
@@ -47,87 +49,25 @@ func (h *Handler) HandleAction(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Infof("Executing action: %s with params: %v", req.Action, req.Params)
 
-	client := h.manager.Client()
-	if client == nil {
-		respondError(w, "Not connected to OBS", http.StatusServiceUnavailable)
+	handler, ok := h.registry.Get(req.Action)
+	if !ok {
+		respondError(w, fmt.Sprintf("Unknown action: %s", req.Action), http.StatusBadRequest)
 		return
 	}
 
-	var err error
-	var data interface{}
-
-	switch req.Action {
-	case "switch_scene":
-		sceneName := getStringParam(req.Params, "scene_name")
-		err = actions.SetCurrentScene(client, sceneName)
-
-	case "toggle_stream":
-		err = actions.ToggleStreaming(client)
-
-	case "start_stream":
-		err = actions.StartStreaming(client)
-
-	case "stop_stream":
-		err = actions.StopStreaming(client)
-
-	case "toggle_record":
-		err = actions.ToggleRecording(client)
-
-	case "start_record":
-		err = actions.StartRecording(client)
-
-	case "stop_record":
-		err = actions.StopRecording(client)
-
-	case "pause_record":
-		err = actions.PauseRecording(client)
-
-	case "resume_record":
-		err = actions.ResumeRecording(client)
-
-	case "toggle_input_mute":
-		inputName := getStringParam(req.Params, "input_name")
-		err = actions.ToggleInputMute(client, inputName)
-
-	case "mute_input":
-		inputName := getStringParam(req.Params, "input_name")
-		err = actions.SetInputMute(client, inputName, true)
-
-	case "unmute_input":
-		inputName := getStringParam(req.Params, "input_name")
-		err = actions.SetInputMute(client, inputName, false)
-
-	case "set_source_visibility":
-		sourceName := getStringParam(req.Params, "source_name")
-		visible := getBoolParam(req.Params, "visible")
-		err = actions.SetSourceVisibility(client, sourceName, visible)
-
-	// Volume control
-	case "set_input_volume":
-		inputName := getStringParam(req.Params, "input_name")
-		volumeDb := getFloatParam(req.Params, "volume_db")
-		err = actions.SetInputVolume(client, inputName, volumeDb)
-
-	case "get_input_volume":
-		inputName := getStringParam(req.Params, "input_name")
-		volume, volumeErr := actions.GetInputVolume(client, inputName)
-		if volumeErr != nil {
-			err = volumeErr
-		} else {
-			data = map[string]interface{}{"volume_db": volume}
-		}
-
-	// Screenshot
-	case "take_screenshot":
-		sourceName := getStringParam(req.Params, "source_name")
-		filePath := getStringParam(req.Params, "file_path")
-		err = actions.TakeSourceScreenshot(client, sourceName, filePath)
+	params := actions.Params(req.Params)
+	if err := handler.Spec().Validate(params); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	default:
-		respondError(w, fmt.Sprintf("Unknown action: %s", req.Action), http.StatusBadRequest)
+	client := h.manager.Client()
+	if client == nil {
+		respondError(w, "Not connected to OBS", http.StatusServiceUnavailable)
 		return
 	}
 
+	data, err := handler.Execute(client, params)
 	if err != nil {
 		h.logger.Errorf("Action failed: %v", err)
 		respondError(w, err.Error(), http.StatusInternalServerError)
@@ -137,6 +77,12 @@ func (h *Handler) HandleAction(w http.ResponseWriter, r *http.Request) {
 	respondSuccess(w, "Action executed successfully", data)
 }
 
+// HandleListActions returns the catalog of supported actions
+func (h *Handler) HandleListActions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.registry.Catalog())
+}
+
 // HandleGetStatus returns current OBS status
 func (h *Handler) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
 	client := h.manager.Client()
@@ -165,11 +111,27 @@ func (h *Handler) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
 		currentScene = "Unknown"
 	}
 
+	// The virtual camera and replay buffer may not be set up in OBS, so
+	// report them as off when their status can't be read
+	virtualcam, err := actions.GetVirtualCamStatus(client)
+	if err != nil {
+		h.logger.Debugf("Failed to get virtual camera status: %v", err)
+	}
+	replayBuffer, err := actions.GetReplayBufferStatus(client)
+	if err != nil {
+		h.logger.Debugf("Failed to get replay buffer status: %v", err)
+	}
+	// OBS errors until a replay has been saved
+	lastReplay, _ := actions.GetLastReplay(client)
+
 	status := map[string]interface{}{
-		"streaming":     streamStatus.Active,
-		"recording":     recordStatus.Active,
-		"paused":        recordStatus.Paused,
-		"current_scene": currentScene,
+		"streaming":        streamStatus.Active,
+		"recording":        recordStatus.Active,
+		"paused":           recordStatus.Paused,
+		"virtualcam":       virtualcam,
+		"replay_buffer":    replayBuffer,
+		"last_replay_path": lastReplay,
+		"current_scene":    currentScene,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -220,40 +182,6 @@ func (h *Handler) HandleGetInputs(w http.ResponseWriter, r *http.Request) {
 
 // Helper functions
 
-func getStringParam(params map[string]interface{}, key string) string {
-	if val, ok := params[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
-func getBoolParam(params map[string]interface{}, key string) bool {
-	if val, ok := params[key]; ok {
-		if b, ok := val.(bool); ok {
-			return b
-		}
-	}
-	return false
-}
-
-func getFloatParam(params map[string]interface{}, key string) float64 {
-	if val, ok := params[key]; ok {
-		switch v := val.(type) {
-		case float64:
-			return v
-		case float32:
-			return float64(v)
-		case int:
-			return float64(v)
-		case int64:
-			return float64(v)
-		}
-	}
-	return 0.0
-}
-
 func respondSuccess(w http.ResponseWriter, message string, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ActionResponse{
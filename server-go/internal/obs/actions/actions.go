@@ -1,8 +1,11 @@
 package actions
 
 import (
+	"strings"
+
 	"github.com/andreykaipov/goobs"
 	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/mediainputs"
 	"github.com/andreykaipov/goobs/api/requests/sceneitems"
 	"github.com/andreykaipov/goobs/api/requests/scenes"
 	"github.com/andreykaipov/goobs/api/requests/sources"
@@ -108,6 +111,129 @@ func GetRecordStatus(client *goobs.Client) (*RecordStatus, error) {
 	}, nil
 }
 
+// Virtual camera actions
+func ToggleVirtualCam(client *goobs.Client) error {
+	_, err := client.Outputs.ToggleVirtualCam(nil)
+	return err
+}
+
+func StartVirtualCam(client *goobs.Client) error {
+	_, err := client.Outputs.StartVirtualCam(nil)
+	return err
+}
+
+func StopVirtualCam(client *goobs.Client) error {
+	_, err := client.Outputs.StopVirtualCam(nil)
+	return err
+}
+
+func GetVirtualCamStatus(client *goobs.Client) (bool, error) {
+	resp, err := client.Outputs.GetVirtualCamStatus()
+	if err != nil {
+		return false, err
+	}
+	return resp.OutputActive, nil
+}
+
+// Replay buffer actions
+func ToggleReplayBuffer(client *goobs.Client) error {
+	_, err := client.Outputs.ToggleReplayBuffer(nil)
+	return err
+}
+
+func StartReplayBuffer(client *goobs.Client) error {
+	_, err := client.Outputs.StartReplayBuffer(nil)
+	return err
+}
+
+func StopReplayBuffer(client *goobs.Client) error {
+	_, err := client.Outputs.StopReplayBuffer(nil)
+	return err
+}
+
+func SaveReplayBuffer(client *goobs.Client) error {
+	_, err := client.Outputs.SaveReplayBuffer(nil)
+	return err
+}
+
+func GetReplayBufferStatus(client *goobs.Client) (bool, error) {
+	resp, err := client.Outputs.GetReplayBufferStatus()
+	if err != nil {
+		return false, err
+	}
+	return resp.OutputActive, nil
+}
+
+// GetLastReplay returns the path of the last replay saved from the buffer
+func GetLastReplay(client *goobs.Client) (string, error) {
+	resp, err := client.Outputs.GetLastReplayBufferReplay()
+	if err != nil {
+		return "", err
+	}
+	return resp.SavedReplayPath, nil
+}
+
+// Media input actions, named as OBS names them
+const (
+	MediaActionPlay     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PLAY"
+	MediaActionPause    = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PAUSE"
+	MediaActionStop     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_STOP"
+	MediaActionRestart  = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_RESTART"
+	MediaActionNext     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NEXT"
+	MediaActionPrevious = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PREVIOUS"
+)
+
+// TriggerMediaAction sends a media action, such as MediaActionPlay, to an input
+func TriggerMediaAction(client *goobs.Client, inputName, mediaAction string) error {
+	params := &mediainputs.TriggerMediaInputActionParams{
+		InputName:   &inputName,
+		MediaAction: &mediaAction,
+	}
+	_, err := client.MediaInputs.TriggerMediaInputAction(params)
+	return err
+}
+
+// SetMediaCursor moves a media input to a time in milliseconds
+func SetMediaCursor(client *goobs.Client, inputName string, cursorMs float64) error {
+	params := &mediainputs.SetMediaInputCursorParams{
+		InputName:   &inputName,
+		MediaCursor: &cursorMs,
+	}
+	_, err := client.MediaInputs.SetMediaInputCursor(params)
+	return err
+}
+
+// OffsetMediaCursor moves a media input forward, or back for a negative offset
+func OffsetMediaCursor(client *goobs.Client, inputName string, offsetMs float64) error {
+	params := &mediainputs.OffsetMediaInputCursorParams{
+		InputName:         &inputName,
+		MediaCursorOffset: &offsetMs,
+	}
+	_, err := client.MediaInputs.OffsetMediaInputCursor(params)
+	return err
+}
+
+type MediaStatus struct {
+	State      string  `json:"state"` // playing, paused, stopped, ended...
+	CursorMs   float64 `json:"cursor_ms"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+func GetMediaStatus(client *goobs.Client, inputName string) (*MediaStatus, error) {
+	params := &mediainputs.GetMediaInputStatusParams{
+		InputName: &inputName,
+	}
+	resp, err := client.MediaInputs.GetMediaInputStatus(params)
+	if err != nil {
+		return nil, err
+	}
+	return &MediaStatus{
+		State:      strings.ToLower(strings.TrimPrefix(resp.MediaState, "OBS_MEDIA_STATE_")),
+		CursorMs:   resp.MediaCursor,
+		DurationMs: resp.MediaDuration,
+	}, nil
+}
+
 // Audio input actions
 func ToggleInputMute(client *goobs.Client, inputName string) error {
 	params := &inputs.ToggleInputMuteParams{
@@ -0,0 +1,329 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/andreykaipov/goobs"
+)
+
+// ParamType is the kind of value an action parameter holds
+type ParamType string
+
+const (
+	ParamString ParamType = "string"
+	ParamNumber ParamType = "number"
+	ParamBool   ParamType = "boolean"
+	ParamScene  ParamType = "scene"
+	ParamInput  ParamType = "input"
+	ParamSource ParamType = "source"
+)
+
+// ParamSpec describes one parameter of an action
+type ParamSpec struct {
+	Name        string    `json:"name"`
+	Type        ParamType `json:"type"`
+	Required    bool      `json:"required"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Spec describes an action type for the /api/actions catalog
+type Spec struct {
+	Name        string      `json:"name"`
+	Category    string      `json:"category"`
+	Description string      `json:"description"`
+	Params      []ParamSpec `json:"params"`
+}
+
+// Params holds the parameters of an action request
+type Params map[string]interface{}
+
+// String returns a string parameter, or "" if missing
+func (p Params) String(key string) string {
+	if str, ok := p[key].(string); ok {
+		return str
+	}
+	return ""
+}
+
+// Bool returns a boolean parameter, or false if missing
+func (p Params) Bool(key string) bool {
+	if b, ok := p[key].(bool); ok {
+		return b
+	}
+	return false
+}
+
+// Float returns a numeric parameter and whether it was set
+func (p Params) Float(key string) (float64, bool) {
+	switch v := p[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Handler executes one action type, optionally returning data for the response
+type Handler interface {
+	Spec() Spec
+	Execute(client *goobs.Client, params Params) (interface{}, error)
+}
+
+// Validate checks params against the spec's parameter schema
+func (s Spec) Validate(params Params) error {
+	for _, param := range s.Params {
+		value, present := params[param.Name]
+		if !present || value == nil {
+			if param.Required {
+				return fmt.Errorf("missing %s parameter", param.Name)
+			}
+			continue
+		}
+
+		switch param.Type {
+		case ParamNumber:
+			if _, ok := params.Float(param.Name); !ok {
+				return fmt.Errorf("parameter %s must be a number", param.Name)
+			}
+		case ParamBool:
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("parameter %s must be a boolean", param.Name)
+			}
+		default:
+			if str, ok := value.(string); !ok || (param.Required && str == "") {
+				return fmt.Errorf("parameter %s must be a non-empty string", param.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Registry maps action names to handlers
+type Registry struct {
+	handlers map[string]Handler
+	mu       sync.RWMutex
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register adds a handler under its spec name
+func (r *Registry) Register(handler Handler) error {
+	name := handler.Spec().Name
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[name]; exists {
+		return fmt.Errorf("action already registered: %s", name)
+	}
+	r.handlers[name] = handler
+	return nil
+}
+
+// Get returns the handler for an action name
+func (r *Registry) Get(name string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
+
+// Catalog returns all action specs sorted by category and name
+func (r *Registry) Catalog() []Spec {
+	r.mu.RLock()
+	specs := make([]Spec, 0, len(r.handlers))
+	for _, handler := range r.handlers {
+		specs = append(specs, handler.Spec())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].Category != specs[j].Category {
+			return specs[i].Category < specs[j].Category
+		}
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// handlerFunc adapts a function to Handler
+type handlerFunc struct {
+	spec Spec
+	run  func(client *goobs.Client, params Params) (interface{}, error)
+}
+
+func (h *handlerFunc) Spec() Spec { return h.spec }
+
+func (h *handlerFunc) Execute(client *goobs.Client, params Params) (interface{}, error) {
+	return h.run(client, params)
+}
+
+// define builds a handler that returns no data
+func define(spec Spec, run func(client *goobs.Client, params Params) error) Handler {
+	if spec.Params == nil {
+		spec.Params = []ParamSpec{}
+	}
+	return &handlerFunc{
+		spec: spec,
+		run: func(client *goobs.Client, params Params) (interface{}, error) {
+			return nil, run(client, params)
+		},
+	}
+}
+
+// mediaAction builds a handler sending one media action to input_name
+func mediaAction(name, description, action string) Handler {
+	return define(Spec{Name: name, Category: "media", Description: description, Params: []ParamSpec{inputParam}},
+		func(c *goobs.Client, p Params) error { return TriggerMediaAction(c, p.String("input_name"), action) })
+}
+
+var (
+	sceneParam  = ParamSpec{Name: "scene_name", Type: ParamScene, Required: true}
+	inputParam  = ParamSpec{Name: "input_name", Type: ParamInput, Required: true}
+	sourceParam = ParamSpec{Name: "source_name", Type: ParamSource, Required: true}
+)
+
+// DefaultRegistry returns a registry with every built-in action
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, handler := range []Handler{
+		define(Spec{Name: "switch_scene", Category: "scenes", Description: "Switch the program scene", Params: []ParamSpec{sceneParam}},
+			func(c *goobs.Client, p Params) error { return SetCurrentScene(c, p.String("scene_name")) }),
+
+		define(Spec{Name: "toggle_stream", Category: "streaming", Description: "Start or stop streaming"},
+			func(c *goobs.Client, p Params) error { return ToggleStreaming(c) }),
+		define(Spec{Name: "start_stream", Category: "streaming", Description: "Start streaming"},
+			func(c *goobs.Client, p Params) error { return StartStreaming(c) }),
+		define(Spec{Name: "stop_stream", Category: "streaming", Description: "Stop streaming"},
+			func(c *goobs.Client, p Params) error { return StopStreaming(c) }),
+
+		define(Spec{Name: "toggle_record", Category: "recording", Description: "Start or stop recording"},
+			func(c *goobs.Client, p Params) error { return ToggleRecording(c) }),
+		define(Spec{Name: "start_record", Category: "recording", Description: "Start recording"},
+			func(c *goobs.Client, p Params) error { return StartRecording(c) }),
+		define(Spec{Name: "stop_record", Category: "recording", Description: "Stop recording"},
+			func(c *goobs.Client, p Params) error { return StopRecording(c) }),
+		define(Spec{Name: "pause_record", Category: "recording", Description: "Pause recording"},
+			func(c *goobs.Client, p Params) error { return PauseRecording(c) }),
+		define(Spec{Name: "resume_record", Category: "recording", Description: "Resume recording"},
+			func(c *goobs.Client, p Params) error { return ResumeRecording(c) }),
+
+		define(Spec{Name: "toggle_virtualcam", Category: "virtualcam", Description: "Start or stop the virtual camera"},
+			func(c *goobs.Client, p Params) error { return ToggleVirtualCam(c) }),
+		define(Spec{Name: "start_virtualcam", Category: "virtualcam", Description: "Start the virtual camera"},
+			func(c *goobs.Client, p Params) error { return StartVirtualCam(c) }),
+		define(Spec{Name: "stop_virtualcam", Category: "virtualcam", Description: "Stop the virtual camera"},
+			func(c *goobs.Client, p Params) error { return StopVirtualCam(c) }),
+
+		define(Spec{Name: "toggle_replay_buffer", Category: "replay_buffer", Description: "Start or stop the replay buffer"},
+			func(c *goobs.Client, p Params) error { return ToggleReplayBuffer(c) }),
+		define(Spec{Name: "start_replay_buffer", Category: "replay_buffer", Description: "Start the replay buffer"},
+			func(c *goobs.Client, p Params) error { return StartReplayBuffer(c) }),
+		define(Spec{Name: "stop_replay_buffer", Category: "replay_buffer", Description: "Stop the replay buffer"},
+			func(c *goobs.Client, p Params) error { return StopReplayBuffer(c) }),
+		define(Spec{Name: "save_replay_buffer", Category: "replay_buffer", Description: "Save the replay buffer to a file"},
+			func(c *goobs.Client, p Params) error { return SaveReplayBuffer(c) }),
+		&handlerFunc{
+			spec: Spec{Name: "get_last_replay", Category: "replay_buffer", Description: "Read the path of the last saved replay", Params: []ParamSpec{}},
+			run: func(c *goobs.Client, p Params) (interface{}, error) {
+				path, err := GetLastReplay(c)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"path": path}, nil
+			},
+		},
+
+		mediaAction("media_play", "Play or resume a media source", MediaActionPlay),
+		mediaAction("media_pause", "Pause a media source", MediaActionPause),
+		mediaAction("media_restart", "Play a media source from the start", MediaActionRestart),
+		mediaAction("media_stop", "Stop a media source", MediaActionStop),
+		mediaAction("media_next", "Skip to the next item of a playlist source", MediaActionNext),
+		mediaAction("media_previous", "Go back to the previous item of a playlist source", MediaActionPrevious),
+		define(Spec{Name: "media_toggle", Category: "media", Description: "Pause a playing media source, or play it", Params: []ParamSpec{inputParam}},
+			func(c *goobs.Client, p Params) error {
+				status, err := GetMediaStatus(c, p.String("input_name"))
+				if err != nil {
+					return err
+				}
+				switch status.State {
+				case "playing":
+					return TriggerMediaAction(c, p.String("input_name"), MediaActionPause)
+				case "paused":
+					return TriggerMediaAction(c, p.String("input_name"), MediaActionPlay)
+				default:
+					return TriggerMediaAction(c, p.String("input_name"), MediaActionRestart)
+				}
+			}),
+		define(Spec{Name: "media_seek", Category: "media", Description: "Move a media source to a time",
+			Params: []ParamSpec{inputParam, {Name: "position_ms", Type: ParamNumber, Required: true}}},
+			func(c *goobs.Client, p Params) error {
+				positionMs, _ := p.Float("position_ms")
+				if positionMs < 0 {
+					return fmt.Errorf("position_ms can't be negative")
+				}
+				return SetMediaCursor(c, p.String("input_name"), positionMs)
+			}),
+		define(Spec{Name: "media_offset", Category: "media", Description: "Jump a media source forward or back",
+			Params: []ParamSpec{inputParam, {Name: "offset_ms", Type: ParamNumber, Required: true, Description: "Negative to go back"}}},
+			func(c *goobs.Client, p Params) error {
+				offsetMs, _ := p.Float("offset_ms")
+				return OffsetMediaCursor(c, p.String("input_name"), offsetMs)
+			}),
+		&handlerFunc{
+			spec: Spec{Name: "media_status", Category: "media", Description: "Read the state, position and length of a media source", Params: []ParamSpec{inputParam}},
+			run: func(c *goobs.Client, p Params) (interface{}, error) {
+				return GetMediaStatus(c, p.String("input_name"))
+			},
+		},
+
+		define(Spec{Name: "toggle_input_mute", Category: "audio", Description: "Mute or unmute an input", Params: []ParamSpec{inputParam}},
+			func(c *goobs.Client, p Params) error { return ToggleInputMute(c, p.String("input_name")) }),
+		define(Spec{Name: "mute_input", Category: "audio", Description: "Mute an input", Params: []ParamSpec{inputParam}},
+			func(c *goobs.Client, p Params) error { return SetInputMute(c, p.String("input_name"), true) }),
+		define(Spec{Name: "unmute_input", Category: "audio", Description: "Unmute an input", Params: []ParamSpec{inputParam}},
+			func(c *goobs.Client, p Params) error { return SetInputMute(c, p.String("input_name"), false) }),
+		define(Spec{Name: "set_input_volume", Category: "audio", Description: "Set an input's volume in dB",
+			Params: []ParamSpec{inputParam, {Name: "volume_db", Type: ParamNumber, Required: true}}},
+			func(c *goobs.Client, p Params) error {
+				volumeDb, _ := p.Float("volume_db")
+				return SetInputVolume(c, p.String("input_name"), volumeDb)
+			}),
+		&handlerFunc{
+			spec: Spec{Name: "get_input_volume", Category: "audio", Description: "Read an input's volume in dB", Params: []ParamSpec{inputParam}},
+			run: func(c *goobs.Client, p Params) (interface{}, error) {
+				volume, err := GetInputVolume(c, p.String("input_name"))
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"volume_db": volume}, nil
+			},
+		},
+
+		define(Spec{Name: "set_source_visibility", Category: "sources", Description: "Show or hide a source in the current scene",
+			Params: []ParamSpec{sourceParam, {Name: "visible", Type: ParamBool, Description: "Defaults to false (hide)"}}},
+			func(c *goobs.Client, p Params) error {
+				return SetSourceVisibility(c, p.String("source_name"), p.Bool("visible"))
+			}),
+
+		define(Spec{Name: "take_screenshot", Category: "sources", Description: "Save a screenshot of a source",
+			Params: []ParamSpec{sourceParam, {Name: "file_path", Type: ParamString, Required: true}}},
+			func(c *goobs.Client, p Params) error {
+				return TakeSourceScreenshot(c, p.String("source_name"), p.String("file_path"))
+			}),
+	} {
+		if err := r.Register(handler); err != nil {
+			panic(err) // programming error in the built-in table
+		}
+	}
+	return r
+}
@@ -9,6 +9,14 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// Used when Config.ReconnectInterval is unset
+	defaultReconnectInterval = 5 * time.Second
+
+	// Retry delays double from ReconnectInterval up to this
+	maxReconnectDelay = 30 * time.Second
+)
+
 // Manager manages the connection to OBS Studio via WebSocket 5.x
 type Manager struct {
 	client          *goobs.Client
@@ -18,6 +26,7 @@ type Manager struct {
 	logger          *logrus.Logger
 	connectCallback func()
 	errorCallback   func(error)
+	stop            chan struct{}
 }
 
 // Config contains OBS connection configuration
@@ -35,8 +44,8 @@ func NewManager(config *Config, logger *logrus.Logger) *Manager {
 		logger = logrus.New()
 	}
 
-	if config.ReconnectInterval == 0 {
-		config.ReconnectInterval = 5 * time.Second
+	if config.ReconnectInterval <= 0 {
+		config.ReconnectInterval = defaultReconnectInterval
 	}
 
 	return &Manager{
@@ -83,11 +92,98 @@ func (m *Manager) Connect() error {
 	return nil
 }
 
-// Disconnect closes the connection to OBS
+// Start connects to OBS. With AutoConnect enabled it returns immediately and
+// keeps the connection alive in the background, probing it every
+// ReconnectInterval and reconnecting with exponential backoff when OBS goes
+// away. Without AutoConnect it is a single Connect call.
+func (m *Manager) Start() error {
+	if !m.config.AutoConnect {
+		return m.Connect()
+	}
+
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("OBS connection supervisor already running")
+	}
+	stop := make(chan struct{})
+	m.stop = stop
+	m.mu.Unlock()
+
+	go m.supervise(stop)
+	return nil
+}
+
+// supervise reconnects to OBS until stop is closed
+func (m *Manager) supervise(stop <-chan struct{}) {
+	interval := m.config.ReconnectInterval
+	if interval <= 0 {
+		interval = defaultReconnectInterval
+	}
+	delay := interval
+
+	for {
+		if m.IsConnected() {
+			// Probe the connection; goobs doesn't always notice dropped sockets
+			if _, err := m.GetVersion(); err != nil {
+				m.logger.Warnf("Lost connection to OBS: %v", err)
+				m.markDisconnected()
+				m.reportError(fmt.Errorf("lost connection to OBS: %w", err))
+			}
+		}
+
+		wait := interval
+		if !m.IsConnected() {
+			if err := m.Connect(); err != nil {
+				m.logger.Warnf("OBS not available, retrying in %s", delay)
+				m.reportError(err)
+				wait = delay
+				delay *= 2
+				if delay > maxReconnectDelay {
+					delay = maxReconnectDelay
+				}
+			} else {
+				delay = interval
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// markDisconnected drops a dead client so the supervisor reconnects
+func (m *Manager) markDisconnected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client != nil {
+		m.client.Disconnect()
+	}
+	m.client = nil
+	m.connected = false
+}
+
+// reportError passes err to the error callback, if set
+func (m *Manager) reportError(err error) {
+	if m.errorCallback != nil {
+		m.errorCallback(err)
+	}
+}
+
+// Disconnect closes the connection to OBS and stops reconnecting
 func (m *Manager) Disconnect() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+
 	if !m.connected {
 		return nil // Not an error if already disconnected
 	}
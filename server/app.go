@@ -15,15 +15,18 @@ import (
 	"github.com/robomon1/robo-stream/server/internal/manager"
 	"github.com/robomon1/robo-stream/server/internal/models"
 	"github.com/robomon1/robo-stream/server/internal/storage"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
 	ctx                  context.Context
 	storage              *storage.Storage
+	repo                 storage.Repository
 	buttonManager        *manager.ButtonManager
 	configManager        *manager.ConfigManager
 	sessionManager       *manager.SessionManager
+	authManager          *manager.AuthManager
 	obsManager           *manager.OBSManager
 	apiServer            *api.Server
 	lastOBSConnected     bool
@@ -51,34 +54,71 @@ func (a *App) startup(ctx context.Context) {
 		log.Fatal("Failed to initialize storage:", err)
 	}
 
+	// Open the backend manager state is kept in. ROBO_STREAM_STORAGE=sqlite
+	// selects the SQLite database, which imports the JSON files on first use.
+	backend := os.Getenv("ROBO_STREAM_STORAGE")
+	a.repo, err = storage.OpenRepository(backend, a.storage)
+	if err != nil {
+		log.Fatal("Failed to open storage backend:", err)
+	}
+	if backend == "" {
+		backend = storage.BackendJSON
+	}
+	log.Printf("📦 Using %s storage in %s", backend, dataDir)
+
 	// Initialize managers
-	a.buttonManager = manager.NewButtonManager(a.storage)
-	a.configManager = manager.NewConfigManager(a.storage, a.buttonManager)
-	a.sessionManager = manager.NewSessionManager(a.storage)
+	store := manager.NewStore(a.repo)
+	a.buttonManager = manager.NewButtonManager(store)
+	a.sessionManager = manager.NewSessionManager(store)
+	a.configManager = manager.NewConfigManager(store, a.buttonManager, a.sessionManager)
+	a.authManager = manager.NewAuthManager(store, a.sessionManager)
 	a.obsManager = manager.NewOBSManager()
 
+	// Toggle buttons follow live OBS state, and buttons naming scenes or
+	// inputs OBS doesn't have are flagged as broken
+	a.configManager.SetStateProvider(a.obsManager)
+	a.configManager.SetObjectLister(a.obsManager)
+
+	// Rewrite button references when scenes and inputs are renamed in OBS,
+	// unless ROBO_STREAM_FOLLOW_RENAMES=false
+	if os.Getenv("ROBO_STREAM_FOLLOW_RENAMES") != "false" {
+		a.buttonManager.FollowRenames(a.obsManager)
+	}
+
 	// Initialize with some default data if needed
 	a.initializeDefaults()
 
 	// Start session cleanup routine
 	go a.sessionCleanupLoop()
 
+	// Tell the frontend whenever the OBS connection changes state
+	a.obsManager.OnStateChange(func(state manager.ConnectionState, err error) {
+		payload := map[string]interface{}{"state": state}
+		if err != nil {
+			payload["error"] = err.Error()
+		}
+		runtime.EventsEmit(a.ctx, "obs_state_changed", payload)
+	})
+
 	// Auto-connect to OBS on startup
 	go func() {
 		log.Println("🔌 Attempting to auto-connect to OBS...")
 		savedConfig := a.GetSavedOBSConfig()
 
-		// Try with saved config first
+		// Try with saved config first; the OBS manager keeps retrying in the
+		// background, so OBS can be started after the server
 		err := a.obsManager.Connect(savedConfig.URL, savedConfig.Password)
 		if err != nil {
-			log.Printf("⚠️  Auto-connect to OBS failed: %v (this is normal if OBS isn't running)", err)
+			log.Printf("⚠️  Auto-connect to OBS failed: %v (will keep retrying until OBS is available)", err)
 		} else {
 			log.Println("✅ Auto-connected to OBS successfully!")
 		}
 	}()
 
-	// Start API server for clients
-	a.apiServer = api.NewServer(a.configManager, a.sessionManager, a.obsManager)
+	// Start API server for clients. Browser origins allowed to call it are
+	// listed in ROBO_STREAM_CORS_ORIGINS, comma separated.
+	origins := api.ParseOrigins(os.Getenv("ROBO_STREAM_CORS_ORIGINS"))
+	a.apiServer = api.NewServer(a.configManager, a.buttonManager, a.sessionManager, a.obsManager, a.authManager, origins)
 	go func() {
 		log.Println("Starting API server on :8080")
 		if err := a.apiServer.Start(":8080"); err != nil {
@@ -119,6 +159,11 @@ func (a *App) shutdown(ctx context.Context) {
 	if a.obsManager != nil {
 		a.obsManager.Disconnect()
 	}
+	if a.repo != nil {
+		if err := a.repo.Close(); err != nil {
+			log.Printf("⚠️  Failed to close storage: %v", err)
+		}
+	}
 	log.Println("Robo-Stream Server shutdown complete")
 }
 
@@ -199,7 +244,7 @@ func (a *App) initializeDefaults() {
 	}
 
 	// Assign buttons to positions
-	positions := []string{"btn-0-0", "btn-0-1", "btn-1-0", "btn-1-1", "btn-2-0", "btn-2-1"}
+	positions := []string{"btn-0-0-0", "btn-0-0-1", "btn-0-1-0", "btn-0-1-1", "btn-0-2-0", "btn-0-2-1"}
 	for i, btnID := range buttonIDs {
 		if i < len(positions) {
 			defaultConfig.Buttons[positions[i]] = btnID
@@ -220,10 +265,30 @@ func (a *App) GetButtons() []*models.Button {
 	return a.buttonManager.List()
 }
 
+// SearchButtons returns the page of library buttons matching a query
+func (a *App) SearchButtons(query models.ButtonQuery) (*models.ButtonSearchResult, error) {
+	return a.buttonManager.Search(query)
+}
+
 func (a *App) GetButton(id string) (*models.Button, error) {
 	return a.buttonManager.Get(id)
 }
 
+// GetButtonUsage reports where a button is placed and how often it is pressed
+func (a *App) GetButtonUsage(id string) (*models.ButtonUsage, error) {
+	return a.configManager.ButtonUsage(id)
+}
+
+// GetButtonsUsage reports the usage of every button, or of the unused ones
+func (a *App) GetButtonsUsage(unusedOnly bool) []*models.ButtonUsage {
+	return a.configManager.Usage(unusedOnly)
+}
+
+// ValidateButtons checks every button's scene and input references against OBS
+func (a *App) ValidateButtons() (*models.ValidationReport, error) {
+	return a.configManager.ValidateButtons()
+}
+
 func (a *App) CreateButton(button *models.Button) error {
 	return a.buttonManager.Create(button)
 }
@@ -232,8 +297,12 @@ func (a *App) UpdateButton(button *models.Button) error {
 	return a.buttonManager.Update(button)
 }
 
-func (a *App) DeleteButton(id string) error {
-	return a.buttonManager.Delete(id)
+func (a *App) DeleteButton(id string, options models.DeleteOptions) (*models.DeleteReport, error) {
+	return a.configManager.DeleteButton(id, options)
+}
+
+func (a *App) GetButtonDependents(id string) (*models.DeleteReport, error) {
+	return a.configManager.ButtonDependents(id)
 }
 
 // Configuration operations
@@ -253,8 +322,12 @@ func (a *App) UpdateConfiguration(config *models.Configuration) error {
 	return a.configManager.Update(config)
 }
 
-func (a *App) DeleteConfiguration(id string) error {
-	return a.configManager.Delete(id)
+func (a *App) DeleteConfiguration(id string, options models.DeleteOptions) (*models.DeleteReport, error) {
+	return a.configManager.Delete(id, options)
+}
+
+func (a *App) GetConfigurationDependents(id string) (*models.DeleteReport, error) {
+	return a.configManager.ConfigurationDependents(id)
 }
 
 func (a *App) SetDefaultConfiguration(id string) error {
@@ -270,6 +343,107 @@ func (a *App) ResolveConfiguration(id string) (*models.ResolvedConfiguration, er
 	return a.configManager.Resolve(id)
 }
 
+// Bundle operations
+
+// ExportBundle asks where to save and writes a bundle of the configurations
+// (all of them when none are given) and their buttons. It returns the saved
+// path, or "" if the dialog was cancelled.
+func (a *App) ExportBundle(configIDs []string) (string, error) {
+	bundle, err := a.configManager.ExportBundle(configIDs)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Bundle",
+		DefaultFilename: fmt.Sprintf("robo-stream-%s.zip", bundle.ExportedAt.Format("20060102-150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Robo-Stream Bundle (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := storage.WriteBundle(f, bundle); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	log.Printf("📦 Exported %d configurations and %d buttons to %s", len(bundle.Configurations), len(bundle.Buttons), path)
+	return path, nil
+}
+
+// SelectBundleFile asks for a bundle to import. It returns "" if the dialog
+// was cancelled.
+func (a *App) SelectBundleFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import Bundle",
+		Filters: []runtime.FileFilter{{DisplayName: "Robo-Stream Bundle (*.zip)", Pattern: "*.zip"}},
+	})
+}
+
+// ImportBundle imports a bundle file. Run it with options.DryRun first to
+// see what would change.
+func (a *App) ImportBundle(path string, options models.BundleImportOptions) (*models.BundleImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := storage.ReadBundle(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := a.configManager.ImportBundle(bundle, options)
+	if err != nil {
+		return nil, err
+	}
+	if !report.DryRun {
+		log.Printf("📦 Imported %s (%d configurations, %d buttons)", filepath.Base(path), len(report.Configurations), len(report.Buttons))
+	}
+	return report, nil
+}
+
+// SelectProfileFile asks for a Stream-Pi or Stream Deck profile to import.
+// It returns "" if the dialog was cancelled.
+func (a *App) SelectProfileFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Profile",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Deck Profiles (*.streamDeckProfile, *.xml, *.json)", Pattern: "*.streamDeckProfile;*.xml;*.json"},
+		},
+	})
+}
+
+// ImportProfile imports a Stream-Pi or Stream Deck profile file as a new
+// configuration. Run it with options.DryRun first to see what would change
+// and which actions could not be mapped.
+func (a *App) ImportProfile(path string, options models.BundleImportOptions) (*models.ProfileImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := storage.ReadProfile(filepath.Base(path), data)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := a.configManager.ImportProfile(profile, options)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Import.DryRun {
+		log.Printf("📦 Imported %s profile %s (%d buttons, %d unmapped)", profile.Format, profile.Name, len(report.Import.Buttons), len(report.Unmapped))
+	}
+	return report, nil
+}
+
 // Session operations
 func (a *App) GetSessions() []*models.ClientSession {
 	return a.sessionManager.List()
@@ -280,7 +454,20 @@ func (a *App) GetSession(sessionID string) (*models.ClientSession, error) {
 }
 
 func (a *App) UpdateClientConfig(sessionID, configID string) error {
-	return a.sessionManager.UpdateConfig(sessionID, configID)
+	return a.configManager.AssignSession(sessionID, configID)
+}
+
+// Pairing operations
+func (a *App) NewPairingCode() (*models.PairingCode, error) {
+	return a.authManager.NewPairingCode()
+}
+
+func (a *App) GetPairedClients() []*models.PairedClient {
+	return a.authManager.List()
+}
+
+func (a *App) RevokeClient(id string) error {
+	return a.authManager.Revoke(id)
 }
 
 // OBS operations
@@ -377,6 +564,13 @@ func (a *App) GetOBSStatus() map[string]interface{} {
 	return status
 }
 
+// GetOBSState returns the OBS connection state
+// (disconnected, connecting, connected or auth_failed)
+func (a *App) GetOBSState() string {
+	state, _ := a.obsManager.State()
+	return string(state)
+}
+
 // GetSavedOBSConfig returns the saved OBS credentials
 func (a *App) GetSavedOBSConfig() *models.OBSConfig {
 	// Check environment variables first
@@ -414,23 +608,40 @@ func (a *App) GetInputs() ([]string, error) {
 	return a.obsManager.GetInputs()
 }
 
-func (a *App) ExecuteAction(action models.ButtonAction) error {
+// GetMediaStatus returns the playback state, position and length of a
+// media input
+func (a *App) GetMediaStatus(inputName string) (*models.MediaStatus, error) {
+	return a.obsManager.GetMediaStatus(inputName)
+}
+
+// ExecuteAction runs an action and returns its result (per-step results for macros)
+func (a *App) ExecuteAction(action models.ButtonAction) (interface{}, error) {
 	return a.obsManager.ExecuteAction(action)
 }
 
-// Test configuration by executing all actions in preview mode
-func (a *App) TestConfiguration(configID string) error {
+// GetActionCatalog returns every action type with its parameter schema
+func (a *App) GetActionCatalog() []manager.ActionSpec {
+	return a.obsManager.ActionCatalog()
+}
+
+// TestConfiguration dry-runs every button of a configuration against OBS
+// without changing anything, so a deck can be checked before going live
+func (a *App) TestConfiguration(configID string) (*models.ConfigurationTest, error) {
 	config, err := a.configManager.Resolve(configID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !a.obsManager.IsConnected() {
-		return fmt.Errorf("not connected to OBS")
+		return nil, fmt.Errorf("not connected to OBS")
 	}
 
-	log.Printf("Testing configuration: %s (%d buttons)", config.Name, len(config.Buttons))
-	return nil
+	report, err := a.obsManager.DryRunConfiguration(config)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Tested configuration: %s (%d buttons, %d failed)", config.Name, len(report.Buttons), report.Failed)
+	return report, nil
 }
 
 // getLocalIPs returns all non-loopback IPv4 addresses
@@ -494,6 +705,7 @@ func (a *App) GetServerInfo() map[string]interface{} {
 		"ip_addresses":    ips,
 		"client_urls":     clientURLs,
 		"obs_connected":   a.obsManager.IsConnected(),
+		"obs_state":       a.GetOBSState(),
 		"active_sessions": len(a.sessionManager.List()),
 		"configurations":  len(a.configManager.List()),
 		"buttons":         len(a.buttonManager.List()),
@@ -1,5 +1,6 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {manager} from '../models';
 import {models} from '../models';
 
 export function ConnectOBS(arg1:string,arg2:string):Promise<void>;
@@ -8,28 +9,46 @@ export function CreateButton(arg1:models.Button):Promise<void>;
 
 export function CreateConfiguration(arg1:models.Configuration):Promise<void>;
 
-export function DeleteButton(arg1:string):Promise<void>;
+export function DeleteButton(arg1:string,arg2:models.DeleteOptions):Promise<models.DeleteReport>;
 
-export function DeleteConfiguration(arg1:string):Promise<void>;
+export function DeleteConfiguration(arg1:string,arg2:models.DeleteOptions):Promise<models.DeleteReport>;
 
 export function DisconnectOBS():Promise<void>;
 
-export function ExecuteAction(arg1:models.ButtonAction):Promise<void>;
+export function ExecuteAction(arg1:models.ButtonAction):Promise<any>;
+
+export function ExportBundle(arg1:Array<string>):Promise<string>;
+
+export function GetActionCatalog():Promise<Array<manager.ActionSpec>>;
 
 export function GetButton(arg1:string):Promise<models.Button>;
 
+export function GetButtonDependents(arg1:string):Promise<models.DeleteReport>;
+
+export function GetButtonUsage(arg1:string):Promise<models.ButtonUsage>;
+
 export function GetButtons():Promise<Array<models.Button>>;
 
+export function GetButtonsUsage(arg1:boolean):Promise<Array<models.ButtonUsage>>;
+
 export function GetConfiguration(arg1:string):Promise<models.Configuration>;
 
+export function GetConfigurationDependents(arg1:string):Promise<models.DeleteReport>;
+
 export function GetConfigurations():Promise<Array<models.Configuration>>;
 
 export function GetDefaultConfiguration():Promise<models.Configuration>;
 
 export function GetInputs():Promise<Array<string>>;
 
+export function GetMediaStatus(arg1:string):Promise<models.MediaStatus>;
+
+export function GetOBSState():Promise<string>;
+
 export function GetOBSStatus():Promise<Record<string, any>>;
 
+export function GetPairedClients():Promise<Array<models.PairedClient>>;
+
 export function GetSavedOBSConfig():Promise<models.OBSConfig>;
 
 export function GetScenes():Promise<Array<string>>;
@@ -40,16 +59,32 @@ export function GetSession(arg1:string):Promise<models.ClientSession>;
 
 export function GetSessions():Promise<Array<models.ClientSession>>;
 
+export function ImportBundle(arg1:string,arg2:models.BundleImportOptions):Promise<models.BundleImportReport>;
+
+export function ImportProfile(arg1:string,arg2:models.BundleImportOptions):Promise<models.ProfileImportReport>;
+
+export function NewPairingCode():Promise<models.PairingCode>;
+
 export function ResolveConfiguration(arg1:string):Promise<models.ResolvedConfiguration>;
 
+export function RevokeClient(arg1:string):Promise<void>;
+
+export function SearchButtons(arg1:models.ButtonQuery):Promise<models.ButtonSearchResult>;
+
+export function SelectBundleFile():Promise<string>;
+
+export function SelectProfileFile():Promise<string>;
+
 export function SetDefaultConfiguration(arg1:string):Promise<void>;
 
 export function TestBinding(arg1:string):Promise<string>;
 
-export function TestConfiguration(arg1:string):Promise<void>;
+export function TestConfiguration(arg1:string):Promise<models.ConfigurationTest>;
 
 export function UpdateButton(arg1:models.Button):Promise<void>;
 
 export function UpdateClientConfig(arg1:string,arg2:string):Promise<void>;
 
 export function UpdateConfiguration(arg1:models.Configuration):Promise<void>;
+
+export function ValidateButtons():Promise<models.ValidationReport>;
@@ -14,12 +14,12 @@ export function CreateConfiguration(arg1) {
   return window['go']['main']['App']['CreateConfiguration'](arg1);
 }
 
-export function DeleteButton(arg1) {
-  return window['go']['main']['App']['DeleteButton'](arg1);
+export function DeleteButton(arg1, arg2) {
+  return window['go']['main']['App']['DeleteButton'](arg1, arg2);
 }
 
-export function DeleteConfiguration(arg1) {
-  return window['go']['main']['App']['DeleteConfiguration'](arg1);
+export function DeleteConfiguration(arg1, arg2) {
+  return window['go']['main']['App']['DeleteConfiguration'](arg1, arg2);
 }
 
 export function DisconnectOBS() {
@@ -30,18 +30,42 @@ export function ExecuteAction(arg1) {
   return window['go']['main']['App']['ExecuteAction'](arg1);
 }
 
+export function ExportBundle(arg1) {
+  return window['go']['main']['App']['ExportBundle'](arg1);
+}
+
+export function GetActionCatalog() {
+  return window['go']['main']['App']['GetActionCatalog']();
+}
+
 export function GetButton(arg1) {
   return window['go']['main']['App']['GetButton'](arg1);
 }
 
+export function GetButtonDependents(arg1) {
+  return window['go']['main']['App']['GetButtonDependents'](arg1);
+}
+
+export function GetButtonUsage(arg1) {
+  return window['go']['main']['App']['GetButtonUsage'](arg1);
+}
+
 export function GetButtons() {
   return window['go']['main']['App']['GetButtons']();
 }
 
+export function GetButtonsUsage(arg1) {
+  return window['go']['main']['App']['GetButtonsUsage'](arg1);
+}
+
 export function GetConfiguration(arg1) {
   return window['go']['main']['App']['GetConfiguration'](arg1);
 }
 
+export function GetConfigurationDependents(arg1) {
+  return window['go']['main']['App']['GetConfigurationDependents'](arg1);
+}
+
 export function GetConfigurations() {
   return window['go']['main']['App']['GetConfigurations']();
 }
@@ -54,10 +78,22 @@ export function GetInputs() {
   return window['go']['main']['App']['GetInputs']();
 }
 
+export function GetMediaStatus(arg1) {
+  return window['go']['main']['App']['GetMediaStatus'](arg1);
+}
+
+export function GetOBSState() {
+  return window['go']['main']['App']['GetOBSState']();
+}
+
 export function GetOBSStatus() {
   return window['go']['main']['App']['GetOBSStatus']();
 }
 
+export function GetPairedClients() {
+  return window['go']['main']['App']['GetPairedClients']();
+}
+
 export function GetSavedOBSConfig() {
   return window['go']['main']['App']['GetSavedOBSConfig']();
 }
@@ -78,10 +114,38 @@ export function GetSessions() {
   return window['go']['main']['App']['GetSessions']();
 }
 
+export function ImportBundle(arg1, arg2) {
+  return window['go']['main']['App']['ImportBundle'](arg1, arg2);
+}
+
+export function ImportProfile(arg1, arg2) {
+  return window['go']['main']['App']['ImportProfile'](arg1, arg2);
+}
+
+export function NewPairingCode() {
+  return window['go']['main']['App']['NewPairingCode']();
+}
+
 export function ResolveConfiguration(arg1) {
   return window['go']['main']['App']['ResolveConfiguration'](arg1);
 }
 
+export function RevokeClient(arg1) {
+  return window['go']['main']['App']['RevokeClient'](arg1);
+}
+
+export function SearchButtons(arg1) {
+  return window['go']['main']['App']['SearchButtons'](arg1);
+}
+
+export function SelectBundleFile() {
+  return window['go']['main']['App']['SelectBundleFile']();
+}
+
+export function SelectProfileFile() {
+  return window['go']['main']['App']['SelectProfileFile']();
+}
+
 export function SetDefaultConfiguration(arg1) {
   return window['go']['main']['App']['SetDefaultConfiguration'](arg1);
 }
@@ -105,3 +169,7 @@ export function UpdateClientConfig(arg1, arg2) {
 export function UpdateConfiguration(arg1) {
   return window['go']['main']['App']['UpdateConfiguration'](arg1);
 }
+
+export function ValidateButtons() {
+  return window['go']['main']['App']['ValidateButtons']();
+}
@@ -1,3 +1,68 @@
+export namespace manager {
+	
+	export class ParamSpec {
+	    name: string;
+	    type: string;
+	    required: boolean;
+	    description?: string;
+	    options?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ParamSpec(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.required = source["required"];
+	        this.description = source["description"];
+	        this.options = source["options"];
+	    }
+	}
+	export class ActionSpec {
+	    name: string;
+	    label: string;
+	    category: string;
+	    description: string;
+	    params: ParamSpec[];
+	    client_side?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionSpec(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.label = source["label"];
+	        this.category = source["category"];
+	        this.description = source["description"];
+	        this.params = this.convertValues(source["params"], ParamSpec);
+	        this.client_side = source["client_side"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace models {
 	
 	export class ButtonAction {
@@ -14,6 +79,26 @@ export namespace models {
 	        this.params = source["params"];
 	    }
 	}
+	export class ButtonState {
+	    source: string;
+	    params?: Record<string, any>;
+	    on_text?: string;
+	    on_icon?: string;
+	    on_color?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.params = source["params"];
+	        this.on_text = source["on_text"];
+	        this.on_icon = source["on_icon"];
+	        this.on_color = source["on_color"];
+	    }
+	}
 	export class Button {
 	    id: string;
 	    name: string;
@@ -21,10 +106,13 @@ export namespace models {
 	    icon: string;
 	    color: string;
 	    action: ButtonAction;
+	    state?: ButtonState;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
 	    updated_at: any;
+	    category?: string;
+	    tags?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new Button(source);
@@ -38,8 +126,11 @@ export namespace models {
 	        this.icon = source["icon"];
 	        this.color = source["color"];
 	        this.action = this.convertValues(source["action"], ButtonAction);
+	        this.state = this.convertValues(source["state"], ButtonState);
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.category = source["category"];
+	        this.tags = source["tags"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -60,7 +151,171 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class ButtonCheck {
+	    id: string;
+	    page: number;
+	    row: number;
+	    col: number;
+	    text: string;
+	    action: string;
+	    status: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.page = source["page"];
+	        this.row = source["row"];
+	        this.col = source["col"];
+	        this.text = source["text"];
+	        this.action = source["action"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ButtonQuery {
+	    text?: string;
+	    action_type?: string;
+	    category?: string;
+	    tags?: string[];
+	    scene?: string;
+	    input?: string;
+	    sort?: string;
+	    desc?: boolean;
+	    offset?: number;
+	    limit?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.action_type = source["action_type"];
+	        this.category = source["category"];
+	        this.tags = source["tags"];
+	        this.scene = source["scene"];
+	        this.input = source["input"];
+	        this.sort = source["sort"];
+	        this.desc = source["desc"];
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class ButtonSearchResult {
+	    buttons: Button[];
+	    total: number;
+	    offset: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonSearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.buttons = this.convertValues(source["buttons"], Button);
+	        this.total = source["total"];
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class BundleImportItem {
+	    source_id: string;
+	    id: string;
+	    name: string;
+	    result: string;
+	    conflict_id?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BundleImportItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source_id = source["source_id"];
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.result = source["result"];
+	        this.conflict_id = source["conflict_id"];
+	    }
+	}
+	export class BundleImportOptions {
+	    conflict?: string;
+	    dry_run?: boolean;
 	
+	    static createFrom(source: any = {}) {
+	        return new BundleImportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.conflict = source["conflict"];
+	        this.dry_run = source["dry_run"];
+	    }
+	}
+	export class BundleImportReport {
+	    dry_run: boolean;
+	    conflict: string;
+	    configurations: BundleImportItem[];
+	    buttons: BundleImportItem[];
+	    warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BundleImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dry_run = source["dry_run"];
+	        this.conflict = source["conflict"];
+	        this.configurations = this.convertValues(source["configurations"], BundleImportItem);
+	        this.buttons = this.convertValues(source["buttons"], BundleImportItem);
+	        this.warnings = source["warnings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ClientSession {
 	    session_id: string;
 	    client_id: string;
@@ -119,17 +374,32 @@ export namespace models {
 	        this.cols = source["cols"];
 	    }
 	}
+	export class Page {
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Page(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	    }
+	}
 	export class Configuration {
 	    id: string;
 	    name: string;
 	    description: string;
 	    grid: GridConfig;
 	    buttons: Record<string, string>;
+	    pages?: Page[];
 	    is_default: boolean;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
 	    updated_at: any;
+	    allowed_actions?: string[];
+	    allow_switching?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Configuration(source);
@@ -142,9 +412,12 @@ export namespace models {
 	        this.description = source["description"];
 	        this.grid = this.convertValues(source["grid"], GridConfig);
 	        this.buttons = source["buttons"];
+	        this.pages = this.convertValues(source["pages"], Page);
 	        this.is_default = source["is_default"];
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.allowed_actions = source["allowed_actions"];
+	        this.allow_switching = source["allow_switching"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -166,6 +439,178 @@ export namespace models {
 		}
 	}
 	
+	export class ConfigurationTest {
+	    config_id: string;
+	    name: string;
+	    ok: boolean;
+	    failed: number;
+	    buttons: ButtonCheck[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ConfigurationTest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.config_id = source["config_id"];
+	        this.name = source["name"];
+	        this.ok = source["ok"];
+	        this.failed = source["failed"];
+	        this.buttons = this.convertValues(source["buttons"], ButtonCheck);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeleteOptions {
+	    mode?: string;
+	    replace_with?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.replace_with = source["replace_with"];
+	    }
+	}
+	export class Dependent {
+	    id: string;
+	    name: string;
+	    positions?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Dependent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.positions = source["positions"];
+	    }
+	}
+	export class DeleteReport {
+	    id: string;
+	    name: string;
+	    is_default?: boolean;
+	    configurations: Dependent[];
+	    sessions: Dependent[];
+	    deleted: boolean;
+	    replaced_with?: string;
+	    new_default?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.is_default = source["is_default"];
+	        this.configurations = this.convertValues(source["configurations"], Dependent);
+	        this.sessions = this.convertValues(source["sessions"], Dependent);
+	        this.deleted = source["deleted"];
+	        this.replaced_with = source["replaced_with"];
+	        this.new_default = source["new_default"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ButtonUsage {
+	    id: string;
+	    name: string;
+	    configurations: Dependent[];
+	    press_count: number;
+	    // Go type: time
+	    last_pressed_at?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.configurations = this.convertValues(source["configurations"], Dependent);
+	        this.press_count = source["press_count"];
+	        this.last_pressed_at = this.convertValues(source["last_pressed_at"], null);
+	    }
+	
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class MediaStatus {
+	    input_name: string;
+	    state: string;
+	    cursor_ms: number;
+	    duration_ms: number;
+	    time: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.input_name = source["input_name"];
+	        this.state = source["state"];
+	        this.cursor_ms = source["cursor_ms"];
+	        this.duration_ms = source["duration_ms"];
+	        this.time = source["time"];
+	    }
+	}
 	export class OBSConfig {
 	    url: string;
 	    password: string;
@@ -180,14 +625,167 @@ export namespace models {
 	        this.password = source["password"];
 	    }
 	}
+	export class PairedClient {
+	    id: string;
+	    client_id: string;
+	    client_name: string;
+	    ip_address: string;
+	    token_hash?: string;
+	    // Go type: time
+	    paired_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PairedClient(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.client_id = source["client_id"];
+	        this.client_name = source["client_name"];
+	        this.ip_address = source["ip_address"];
+	        this.token_hash = source["token_hash"];
+	        this.paired_at = this.convertValues(source["paired_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PairingCode {
+	    code: string;
+	    // Go type: time
+	    expires_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PairingCode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.expires_at = this.convertValues(source["expires_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UnmappedAction {
+	    page: number;
+	    row: number;
+	    col: number;
+	    title: string;
+	    action_id: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UnmappedAction(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = source["page"];
+	        this.row = source["row"];
+	        this.col = source["col"];
+	        this.title = source["title"];
+	        this.action_id = source["action_id"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class ProfileImportReport {
+	    format: string;
+	    name: string;
+	    unmapped: UnmappedAction[];
+	    import?: BundleImportReport;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.name = source["name"];
+	        this.unmapped = this.convertValues(source["unmapped"], UnmappedAction);
+	        this.import = this.convertValues(source["import"], BundleImportReport);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ButtonIssue {
+	    kind: string;
+	    name: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.message = source["message"];
+	    }
+	}
 	export class ResolvedButton {
 	    id: string;
+	    page: number;
 	    row: number;
 	    col: number;
 	    text: string;
 	    icon: string;
 	    color: string;
 	    action: ButtonAction;
+	    toggle?: boolean;
+	    active?: boolean;
+	    broken?: boolean;
+	    issues?: ButtonIssue[];
 	
 	    static createFrom(source: any = {}) {
 	        return new ResolvedButton(source);
@@ -196,12 +794,51 @@ export namespace models {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
+	        this.page = source["page"];
 	        this.row = source["row"];
 	        this.col = source["col"];
 	        this.text = source["text"];
 	        this.icon = source["icon"];
 	        this.color = source["color"];
 	        this.action = this.convertValues(source["action"], ButtonAction);
+	        this.toggle = source["toggle"];
+	        this.active = source["active"];
+	        this.broken = source["broken"];
+	        this.issues = this.convertValues(source["issues"], ButtonIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ResolvedPage {
+	    index: number;
+	    name: string;
+	    buttons: ResolvedButton[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ResolvedPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.name = source["name"];
+	        this.buttons = this.convertValues(source["buttons"], ResolvedButton);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -227,6 +864,7 @@ export namespace models {
 	    name: string;
 	    grid: GridConfig;
 	    buttons: ResolvedButton[];
+	    pages: ResolvedPage[];
 	
 	    static createFrom(source: any = {}) {
 	        return new ResolvedConfiguration(source);
@@ -238,6 +876,73 @@ export namespace models {
 	        this.name = source["name"];
 	        this.grid = this.convertValues(source["grid"], GridConfig);
 	        this.buttons = this.convertValues(source["buttons"], ResolvedButton);
+	        this.pages = this.convertValues(source["pages"], ResolvedPage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ButtonValidation {
+	    id: string;
+	    name: string;
+	    issues: ButtonIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ButtonValidation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.issues = this.convertValues(source["issues"], ButtonIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ValidationReport {
+	    checked: number;
+	    broken: ButtonValidation[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ValidationReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checked = source["checked"];
+	        this.broken = this.convertValues(source["broken"], ButtonValidation);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -260,4 +965,3 @@ export namespace models {
 	}
 
 }
-
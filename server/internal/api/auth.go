@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// contextKey keys values the middleware adds to a request context
+type contextKey string
+
+// clientContextKey holds the paired client a request's token belongs to
+const clientContextKey contextKey = "client"
+
+// publicPaths can be called without a token
+var publicPaths = map[string]bool{
+	"/api/client/pair": true,
+	"/api/health":      true,
+}
+
+// ParseOrigins splits a comma-separated list of allowed CORS origins, as
+// given in ROBO_STREAM_CORS_ORIGINS. "*" allows any origin.
+func ParseOrigins(value string) []string {
+	origins := make([]string, 0)
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// originAllowed reports whether a browser at origin may call the API.
+// Requests without an Origin header don't come from a browser page and are
+// always allowed.
+func (s *Server) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware rejects requests without a valid client token. The token is
+// sent as "Authorization: Bearer <token>", or as the token query parameter
+// where headers can't be set, as on browser WebSockets.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" || publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+
+		client, err := s.authManager.Authenticate(token)
+		if err != nil {
+			s.respondError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientContextKey, client)))
+	})
+}
+
+// requestClient returns the paired client that made an authenticated request
+func requestClient(r *http.Request) *models.PairedClient {
+	client, _ := r.Context().Value(clientContextKey).(*models.PairedClient)
+	return client
+}
+
+// requireSession returns the request's session, writing an error response
+// and returning nil when it is missing or belongs to another client
+func (s *Server) requireSession(w http.ResponseWriter, r *http.Request, sessionID string) *models.ClientSession {
+	if sessionID == "" {
+		s.respondError(w, http.StatusBadRequest, "missing X-Session-ID header")
+		return nil
+	}
+
+	session, err := s.sessionManager.Get(sessionID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "session not found")
+		return nil
+	}
+
+	if client := requestClient(r); client == nil || client.ClientID != session.ClientID {
+		s.respondError(w, http.StatusForbidden, "session belongs to another client")
+		return nil
+	}
+	return session
+}
+
+// pairClient exchanges the pairing code shown on the server for a token
+func (s *Server) pairClient(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code       string `json:"code"`
+		ClientID   string `json:"client_id"`
+		ClientName string `json:"client_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ipAddress := s.getClientIP(r)
+	token, client, err := s.authManager.Pair(strings.TrimSpace(req.Code), req.ClientID, req.ClientName, ipAddress)
+	if err != nil {
+		log.Printf("⚠️  Pairing failed for %s from %s: %v", req.ClientID, ipAddress, err)
+		s.respondError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"token":  token,
+		"client": client,
+	})
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+// exportBundle returns a bundle archive of the configurations named by the
+// repeated config query parameter, or of all of them
+func (s *Server) exportBundle(w http.ResponseWriter, r *http.Request) {
+	bundle, err := s.configManager.ExportBundle(r.URL.Query()["config"])
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("robo-stream-%s.zip", bundle.ExportedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	if err := storage.WriteBundle(w, bundle); err != nil {
+		// The status is already sent; the client sees a truncated archive
+		log.Printf("⚠️  Failed to write bundle: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// searchButtons returns the buttons matching the query parameters q,
+// action, category, tag (repeatable), scene and input, sorted by sort
+// (order=desc reverses it) and paged by offset and limit
+func (s *Server) searchButtons(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := models.ButtonQuery{
+		Text:       values.Get("q"),
+		ActionType: values.Get("action"),
+		Category:   values.Get("category"),
+		Tags:       values["tag"],
+		Scene:      values.Get("scene"),
+		Input:      values.Get("input"),
+		Sort:       values.Get("sort"),
+		Desc:       values.Get("order") == "desc",
+	}
+
+	for name, dest := range map[string]*int{"offset": &query.Offset, "limit": &query.Limit} {
+		value := values.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "invalid "+name+" value")
+			return
+		}
+		*dest = n
+	}
+
+	result, err := s.buttonManager.Search(query)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, result)
+}
+
+// listButtonUsage reports where every button is placed and how often it is
+// pressed; unused=true keeps only buttons that are neither
+func (s *Server) listButtonUsage(w http.ResponseWriter, r *http.Request) {
+	unusedOnly := r.URL.Query().Get("unused") == "true"
+	s.respondJSON(w, http.StatusOK, s.configManager.Usage(unusedOnly))
+}
+
+// getButtonUsage reports where one button is placed and how often it is
+// pressed
+func (s *Server) getButtonUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.configManager.ButtonUsage(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, usage)
+}
+
+// validateButtons checks the library's scene, input and source references
+// against OBS
+func (s *Server) validateButtons(w http.ResponseWriter, r *http.Request) {
+	report, err := s.configManager.ValidateButtons()
+	if err != nil {
+		s.respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
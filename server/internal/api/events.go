@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/robomon1/robo-stream/server/internal/manager"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+const (
+	// Time allowed to write a message to a subscriber
+	eventWriteWait = 10 * time.Second
+
+	// Time allowed between pongs before a subscriber is considered gone
+	eventPongWait = 60 * time.Second
+
+	// Ping period, must be less than eventPongWait
+	eventPingPeriod = (eventPongWait * 9) / 10
+)
+
+// eventSubscriber is a WebSocket connection bound to a client session
+type eventSubscriber struct {
+	hub       *eventHub
+	clientID  string
+	sessionID string
+	conn      *websocket.Conn
+	send      chan models.OBSEvent
+}
+
+// sessionEvent is an event for the subscribers of one session
+type sessionEvent struct {
+	sessionID string
+	event     models.OBSEvent
+}
+
+// eventHub fans OBS events out to every connected session
+type eventHub struct {
+	subscribers map[*eventSubscriber]bool
+	broadcast   chan models.OBSEvent
+	direct      chan sessionEvent
+	register    chan *eventSubscriber
+	unregister  chan *eventSubscriber
+	disconnect  chan string // client ID whose streams are closed
+
+	// decorate adds per-session data to an event before it is written. It
+	// runs on the subscriber's write goroutine so a slow lookup only delays
+	// that subscriber.
+	decorate func(sessionID string, event models.OBSEvent) models.OBSEvent
+}
+
+// newEventHub creates a new eventHub
+func newEventHub(decorate func(sessionID string, event models.OBSEvent) models.OBSEvent) *eventHub {
+	return &eventHub{
+		subscribers: make(map[*eventSubscriber]bool),
+		broadcast:   make(chan models.OBSEvent, 64),
+		direct:      make(chan sessionEvent, 64),
+		register:    make(chan *eventSubscriber),
+		unregister:  make(chan *eventSubscriber),
+		disconnect:  make(chan string),
+		decorate:    decorate,
+	}
+}
+
+// run processes registrations and broadcasts until the process exits
+func (h *eventHub) run() {
+	for {
+		select {
+		case sub := <-h.register:
+			h.subscribers[sub] = true
+		case sub := <-h.unregister:
+			if _, ok := h.subscribers[sub]; ok {
+				delete(h.subscribers, sub)
+				close(sub.send)
+			}
+		case clientID := <-h.disconnect:
+			for sub := range h.subscribers {
+				if sub.clientID == clientID {
+					delete(h.subscribers, sub)
+					close(sub.send)
+				}
+			}
+		case event := <-h.broadcast:
+			for sub := range h.subscribers {
+				h.deliver(sub, event)
+			}
+		case direct := <-h.direct:
+			for sub := range h.subscribers {
+				if sub.sessionID == direct.sessionID {
+					h.deliver(sub, direct.event)
+				}
+			}
+		}
+	}
+}
+
+// deliver queues an event for a subscriber. Only called from run.
+func (h *eventHub) deliver(sub *eventSubscriber, event models.OBSEvent) {
+	select {
+	case sub.send <- event:
+	default:
+		// Subscriber is too slow; drop it rather than block everyone
+		delete(h.subscribers, sub)
+		close(sub.send)
+	}
+}
+
+// publishOBSEvent queues an OBS event for every subscriber
+func (s *Server) publishOBSEvent(event models.OBSEvent) {
+	select {
+	case s.events.broadcast <- event:
+	default:
+		log.Printf("⚠️  Event queue full, dropping %s event", event.Type)
+	}
+}
+
+// publishConfigChanges tells each session its configuration changed so the
+// client refetches it
+func (s *Server) publishConfigChanges(changes []manager.ConfigChange) {
+	for _, change := range changes {
+		event := models.OBSEvent{
+			Type: models.EventConfigChanged,
+			Data: map[string]interface{}{
+				"config_id": change.ConfigID,
+				"reason":    change.Reason,
+			},
+		}
+		select {
+		case s.events.direct <- sessionEvent{sessionID: change.SessionID, event: event}:
+		default:
+			log.Printf("⚠️  Event queue full, dropping %s event for session %s", event.Type, change.SessionID)
+		}
+	}
+}
+
+// decorateEvent adds the session's toggle button states to events that carry
+// a status snapshot
+func (s *Server) decorateEvent(sessionID string, event models.OBSEvent) models.OBSEvent {
+	if event.Status != nil {
+		event.Status = s.withButtonStates(sessionID, event.Status)
+	}
+	return event
+}
+
+// upgrader returns a WebSocket upgrader applying the CORS origin policy
+func (s *Server) upgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return s.originAllowed(r.Header.Get("Origin"))
+		},
+	}
+}
+
+// streamEvents upgrades to a WebSocket that receives OBS events.
+// Browsers can't set headers on WebSocket requests, so the session ID may
+// also be passed as the session_id query parameter.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get("X-Session-ID")
+	if sessionID == "" {
+		sessionID = r.URL.Query().Get("session_id")
+	}
+	session := s.requireSession(w, r, sessionID)
+	if session == nil {
+		return
+	}
+
+	conn, err := s.upgrader().Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	sub := &eventSubscriber{
+		hub:       s.events,
+		clientID:  session.ClientID,
+		sessionID: sessionID,
+		conn:      conn,
+		send:      make(chan models.OBSEvent, 64),
+	}
+
+	// Send a full snapshot first so the client doesn't need to poll
+	if status, err := s.obsManager.GetStatus(); err == nil {
+		sub.send <- models.OBSEvent{Type: models.EventStatus, Status: status}
+	}
+
+	s.events.register <- sub
+	s.sessionManager.UpdateActivity(sessionID)
+
+	go sub.writePump()
+	go sub.readPump()
+
+	log.Printf("📡 Event stream opened for session %s", sessionID)
+}
+
+// readPump discards client messages and detects closed connections
+func (sub *eventSubscriber) readPump() {
+	defer func() {
+		sub.hub.unregister <- sub
+		sub.conn.Close()
+		log.Printf("📡 Event stream closed for session %s", sub.sessionID)
+	}()
+
+	sub.conn.SetReadDeadline(time.Now().Add(eventPongWait))
+	sub.conn.SetPongHandler(func(string) error {
+		sub.conn.SetReadDeadline(time.Now().Add(eventPongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := sub.conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket error: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// writePump writes queued events and keepalive pings to the connection
+func (sub *eventSubscriber) writePump() {
+	ticker := time.NewTicker(eventPingPeriod)
+	defer func() {
+		ticker.Stop()
+		sub.conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-sub.send:
+			if !ok {
+				sub.conn.SetWriteDeadline(time.Now().Add(eventWriteWait))
+				sub.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if sub.hub.decorate != nil {
+				event = sub.hub.decorate(sub.sessionID, event)
+			}
+			message, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("⚠️  Failed to marshal OBS event: %v", err)
+				continue
+			}
+
+			sub.conn.SetWriteDeadline(time.Now().Add(eventWriteWait))
+			if err := sub.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			sub.conn.SetWriteDeadline(time.Now().Add(eventWriteWait))
+			if err := sub.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
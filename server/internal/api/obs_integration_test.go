@@ -0,0 +1,867 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/robomon1/robo-stream/server/internal/manager"
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/obstest"
+)
+
+// newOBSEnv is a test environment connected to a fake OBS with scenes Main
+// and Intro, a Mic and a Camera shown in Intro
+func newOBSEnv(t *testing.T, opts ...obstest.Option) (*testEnv, *obstest.Server) {
+	t.Helper()
+
+	fake := obstest.NewServer(opts...)
+	fake.AddInput("Mic", "wasapi_input_capture")
+	fake.AddInput("Camera", "dshow_input")
+	fake.AddScene("Main")
+	fake.AddScene("Intro", "Camera")
+
+	env := newTestEnv(t)
+	env.configManager.SetObjectLister(env.obsManager)
+	t.Cleanup(func() {
+		env.obsManager.Disconnect()
+		fake.Close()
+	})
+	return env, fake
+}
+
+// connect connects the environment's OBS manager to fake
+func (e *testEnv) connect(t *testing.T, fake *obstest.Server, password string) {
+	t.Helper()
+	if err := e.obsManager.Connect(fake.Host, password); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+}
+
+// place puts a new button running action on the default configuration, so
+// sessions may run it
+func (e *testEnv) place(t *testing.T, col int, action models.ButtonAction) *models.Button {
+	t.Helper()
+
+	btn := &models.Button{Name: action.Type, Action: action}
+	if err := e.buttonManager.Create(btn); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	cfg, err := e.configManager.Get(e.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	cfg.Buttons[models.PositionKey(0, 1, col)] = btn.ID
+	if err := e.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+	return btn
+}
+
+// press runs action as sessionID and returns the HTTP status and whether the
+// server reported success
+func (e *testEnv) press(t *testing.T, sessionID string, action models.ButtonAction) (int, bool) {
+	t.Helper()
+	var resp struct {
+		Success bool `json:"success"`
+	}
+	status := e.do(t, "POST", "/api/action", sessionID, action, &resp)
+	return status, resp.Success
+}
+
+// eventually fails the test unless cond becomes true within a few seconds
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestOBSActions drives a fake OBS through the API and checks that actions
+// reach it and its events reach a session's event stream
+func TestOBSActions(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	var status map[string]interface{}
+	env.do(t, "GET", "/api/obs/status", "", nil, &status)
+	if status["connected"] != true || status["current_scene"] != "Main" {
+		t.Errorf("status = %v, want connected on Main", status)
+	}
+
+	switchIntro := models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": "Intro"}}
+	muteMic := models.ButtonAction{Type: "toggle_input_mute", Params: map[string]interface{}{"input_name": "Mic"}}
+	env.place(t, 0, switchIntro)
+	env.place(t, 1, muteMic)
+	sessionID := env.register(t, "deck")
+
+	env.mu.Lock()
+	token := env.tokens[sessionID]
+	env.mu.Unlock()
+	srv := httptest.NewServer(env.server.router)
+	defer srv.Close()
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("X-Session-ID", sessionID)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/events", header)
+	if err != nil {
+		t.Fatalf("dial event stream: %v", err)
+	}
+	defer conn.Close()
+
+	// Waits for the next event of eventType, skipping others
+	waitEvent := func(eventType string) models.OBSEvent {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var event models.OBSEvent
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Fatalf("waiting for %s: %v", eventType, err)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		}
+	}
+	waitEvent(models.EventStatus)
+
+	if code, ok := env.press(t, sessionID, switchIntro); code != http.StatusOK || !ok {
+		t.Fatalf("switch scene: status %d, success %v", code, ok)
+	}
+	if scene := fake.CurrentScene(); scene != "Intro" {
+		t.Errorf("OBS scene = %q, want Intro", scene)
+	}
+	if event := waitEvent(models.EventSceneChanged); event.Data["scene_name"] != "Intro" {
+		t.Errorf("event = %+v, want scene_changed to Intro", event)
+	}
+
+	env.press(t, sessionID, muteMic)
+	if muted, _ := fake.InputMuted("Mic"); !muted {
+		t.Error("Mic not muted in OBS")
+	}
+	if event := waitEvent(models.EventInputMuteChanged); event.Data["input_name"] != "Mic" || event.Data["muted"] != true {
+		t.Errorf("event = %+v, want Mic muted", event)
+	}
+
+	// Changes made in OBS itself are pushed too
+	fake.SetStreaming(true)
+	if event := waitEvent(models.EventStreamStateChanged); event.Data["active"] != true {
+		t.Errorf("event = %+v, want stream started", event)
+	}
+	env.do(t, "GET", "/api/obs/status", "", nil, &status)
+	if status["streaming"] != true || status["current_scene"] != "Intro" {
+		t.Errorf("status = %v, want streaming on Intro", status)
+	}
+
+	// A request OBS rejects is reported as a failure
+	fake.FailRequest("SetCurrentProgramScene", obstest.StatusResourceNotFound, "No source was found")
+	if code, ok := env.press(t, sessionID, switchIntro); code != http.StatusInternalServerError || ok {
+		t.Errorf("failing switch: status %d, success %v, want 500 and failure", code, ok)
+	}
+}
+
+// TestOBSAuthAndReconnect checks that a wrong password stops reconnecting,
+// and that a dropped connection is reestablished
+func TestOBSAuthAndReconnect(t *testing.T) {
+	env, fake := newOBSEnv(t, obstest.WithPassword("secret"))
+
+	if err := env.obsManager.Connect(fake.Host, "wrong"); err == nil {
+		t.Fatal("connect with wrong password succeeded")
+	}
+	if state, _ := env.obsManager.State(); state != manager.StateAuthFailed {
+		t.Errorf("state = %s, want %s", state, manager.StateAuthFailed)
+	}
+
+	env.connect(t, fake, "secret")
+	fake.DropConnections()
+	eventually(t, "disconnect", func() bool {
+		state, _ := env.obsManager.State()
+		return state != manager.StateConnected
+	})
+	eventually(t, "reconnect", func() bool {
+		state, _ := env.obsManager.State()
+		return state == manager.StateConnected && fake.Connections() == 1
+	})
+
+	if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "toggle_record"}); err != nil {
+		t.Fatalf("action after reconnect: %v", err)
+	}
+	if !fake.Recording() {
+		t.Error("OBS not recording after reconnect")
+	}
+
+	// goobs races closing its event channel against delivering an event, so
+	// let RecordStateChanged arrive before the cleanup disconnects
+	eventually(t, "recording event", func() bool {
+		status, err := env.obsManager.GetStatus()
+		return err == nil && status["recording"] == true
+	})
+}
+
+// TestOBSRenamesAndDryRun checks that scene renames in OBS are followed by
+// buttons, and that a configuration test reports scenes OBS doesn't have
+func TestOBSRenamesAndDryRun(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+	defer env.buttonManager.FollowRenames(env.obsManager)()
+
+	btn := env.place(t, 0, models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": "Intro"}})
+	fake.RenameScene("Intro", "Opening")
+	eventually(t, "rename to be followed", func() bool {
+		got, err := env.buttonManager.Get(btn.ID)
+		return err == nil && got.Action.Params["scene_name"] == "Opening"
+	})
+
+	var report models.ConfigurationTest
+	env.do(t, "GET", "/api/configurations/"+env.defaultConfig.ID+"/test", "", nil, &report)
+	if !report.OK {
+		t.Errorf("report = %+v, want every button to pass", report)
+	}
+
+	fake.RemoveScene("Opening")
+	eventually(t, "scene removal", func() bool {
+		scenes, err := env.obsManager.GetScenes()
+		return err == nil && len(scenes) == 1
+	})
+	env.do(t, "GET", "/api/configurations/"+env.defaultConfig.ID+"/test", "", nil, &report)
+	if report.Failed != 1 {
+		t.Fatalf("report = %+v, want one failure", report)
+	}
+	for _, check := range report.Buttons {
+		if check.Action == "switch_scene" && check.Status != models.CheckMissingScene {
+			t.Errorf("switch_scene check = %+v, want %s", check, models.CheckMissingScene)
+		}
+	}
+}
+
+// TestOBSSourceActions shows, hides, moves and locks a source through the
+// API, and checks a button following its visibility
+func TestOBSSourceActions(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	camera := map[string]interface{}{"source_name": "Camera", "scene_name": "Intro"}
+	toggle := models.ButtonAction{Type: "toggle_source", Params: camera}
+	btn := env.place(t, 0, toggle)
+	btn.State = &models.ButtonState{Source: models.StateSourceSourceVisible, Params: camera}
+	if err := env.buttonManager.Update(btn); err != nil {
+		t.Fatalf("update button: %v", err)
+	}
+	move := models.ButtonAction{Type: "set_source_transform", Params: map[string]interface{}{
+		"source_name": "Camera", "scene_name": "Intro", "position_x": 100.0, "rotation": 90.0,
+	}}
+	env.place(t, 1, move)
+	lock := models.ButtonAction{Type: "lock_source", Params: camera}
+	env.place(t, 2, lock)
+	sessionID := env.register(t, "deck")
+
+	// buttonActive reads the toggle button's state from the session's status
+	buttonActive := func() bool {
+		t.Helper()
+		var status struct {
+			Buttons []models.ButtonStateUpdate `json:"buttons"`
+		}
+		env.do(t, "GET", "/api/obs/status", sessionID, nil, &status)
+		for _, state := range status.Buttons {
+			if state.ID == models.PositionKey(0, 1, 0) {
+				return state.Active
+			}
+		}
+		t.Fatalf("no state for the toggle button in %+v", status.Buttons)
+		return false
+	}
+	if !buttonActive() {
+		t.Error("button inactive while Camera is visible")
+	}
+
+	if code, ok := env.press(t, sessionID, toggle); code != http.StatusOK || !ok {
+		t.Fatalf("toggle source: status %d, success %v", code, ok)
+	}
+	if item, _ := fake.SceneItem("Intro", "Camera"); item.Enabled {
+		t.Error("Camera still visible in OBS")
+	}
+	eventually(t, "button to follow the hidden source", func() bool { return !buttonActive() })
+
+	env.press(t, sessionID, move)
+	env.press(t, sessionID, lock)
+	item, _ := fake.SceneItem("Intro", "Camera")
+	if item.Transform.PositionX != 100 || item.Transform.Rotation != 90 || item.Transform.ScaleX != 1 {
+		t.Errorf("transform = %+v, want moved and rotated at the same scale", item.Transform)
+	}
+	if !item.Locked {
+		t.Error("Camera not locked")
+	}
+
+	// Without a scene the program scene, Main, is used, which has no Camera
+	if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "show_source", Params: map[string]interface{}{"source_name": "Camera"}}); err == nil {
+		t.Error("showing a source missing from the program scene succeeded")
+	}
+	if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "set_source_transform", Params: camera}); err == nil {
+		t.Error("transform without any field succeeded")
+	}
+}
+
+// TestOBSVolume steps, sets and fades an input's volume, and checks that a
+// newer fade cancels an older one
+func TestOBSVolume(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	run := func(actionType string, params map[string]interface{}) {
+		t.Helper()
+		params["input_name"] = "Mic"
+		if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: actionType, Params: params}); err != nil {
+			t.Fatalf("%s: %v", actionType, err)
+		}
+	}
+	volumeDb := func() float64 {
+		mul, _ := fake.InputVolume("Mic")
+		return math.Round(20 * math.Log10(mul))
+	}
+
+	run("volume_set", map[string]interface{}{"volume": -6.0})
+	run("volume_step", map[string]interface{}{"step": -3.0})
+	if db := volumeDb(); db != -9 {
+		t.Errorf("volume = %v dB, want -9", db)
+	}
+	run("volume_step", map[string]interface{}{"step": 100.0})
+	if db := volumeDb(); db != 26 {
+		t.Errorf("volume = %v dB, want clamped to +26", db)
+	}
+	run("volume_set", map[string]interface{}{"volume": 50.0, "unit": "percent"})
+	run("volume_step", map[string]interface{}{"step": -80.0, "unit": "percent"})
+	if db := volumeDb(); db != -100 {
+		t.Errorf("volume = %v dB, want clamped to silence", db)
+	}
+
+	// A slow fade down is replaced by a quick one up
+	run("volume_fade", map[string]interface{}{"volume": -100.0, "duration_ms": 5000.0})
+	run("volume_fade", map[string]interface{}{"volume": 0.0, "duration_ms": 200.0, "curve": "linear"})
+	eventually(t, "fade to 0 dB", func() bool {
+		mul, _ := fake.InputVolume("Mic")
+		return mul == 1
+	})
+	time.Sleep(200 * time.Millisecond)
+	if mul, _ := fake.InputVolume("Mic"); mul != 1 {
+		t.Errorf("volume = %v after the fade, want 1; the first fade kept running", mul)
+	}
+
+	_, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "volume_fade", Params: map[string]interface{}{
+		"input_name": "Mic", "volume": 0.0, "duration_ms": 0.0,
+	}})
+	if err == nil {
+		t.Error("fade without a duration succeeded")
+	}
+}
+
+// TestOBSOutputs runs the virtual camera and replay buffer actions and checks
+// their state reaches the status and a toggle button
+func TestOBSOutputs(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	replay := models.ButtonAction{Type: "toggle_replay_buffer"}
+	btn := env.place(t, 0, replay)
+	btn.State = &models.ButtonState{Source: models.StateSourceReplayBufferActive}
+	if err := env.buttonManager.Update(btn); err != nil {
+		t.Fatalf("update button: %v", err)
+	}
+	save := models.ButtonAction{Type: "save_replay_buffer"}
+	env.place(t, 1, save)
+	vcam := models.ButtonAction{Type: "toggle_virtualcam"}
+	env.place(t, 2, vcam)
+	sessionID := env.register(t, "deck")
+
+	var status struct {
+		ReplayBuffer   bool                       `json:"replay_buffer"`
+		LastReplayPath string                     `json:"last_replay_path"`
+		Virtualcam     bool                       `json:"virtualcam"`
+		Buttons        []models.ButtonStateUpdate `json:"buttons"`
+	}
+	readStatus := func() {
+		t.Helper()
+		env.do(t, "GET", "/api/obs/status", sessionID, nil, &status)
+	}
+
+	// Saving needs the buffer running
+	if code, ok := env.press(t, sessionID, save); code != http.StatusInternalServerError || ok {
+		t.Errorf("save while stopped: status %d, success %v, want 500 and failure", code, ok)
+	}
+
+	if code, ok := env.press(t, sessionID, replay); code != http.StatusOK || !ok {
+		t.Fatalf("toggle replay buffer: status %d, success %v", code, ok)
+	}
+	if !fake.ReplayBuffer() {
+		t.Error("replay buffer not running in OBS")
+	}
+	eventually(t, "status to show the replay buffer", func() bool {
+		readStatus()
+		if !status.ReplayBuffer {
+			return false
+		}
+		for _, state := range status.Buttons {
+			if state.ID == models.PositionKey(0, 1, 0) {
+				return state.Active
+			}
+		}
+		return false
+	})
+
+	env.press(t, sessionID, save)
+	if fake.LastReplay() == "" {
+		t.Fatal("no replay saved in OBS")
+	}
+	eventually(t, "status to show the saved replay", func() bool {
+		readStatus()
+		return status.LastReplayPath == fake.LastReplay()
+	})
+
+	env.press(t, sessionID, vcam)
+	if !fake.VirtualCam() {
+		t.Error("virtual camera not running in OBS")
+	}
+	eventually(t, "status to show the virtual camera", func() bool {
+		readStatus()
+		return status.Virtualcam
+	})
+}
+
+// TestOBSStudioMode drives studio mode and transitions, including a
+// switch_scene transition override that is undone afterwards
+func TestOBSStudioMode(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	run := func(actionType string, params map[string]interface{}) error {
+		t.Helper()
+		_, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: actionType, Params: params})
+		return err
+	}
+	mustRun := func(actionType string, params map[string]interface{}) {
+		t.Helper()
+		if err := run(actionType, params); err != nil {
+			t.Fatalf("%s: %v", actionType, err)
+		}
+	}
+	status := func() map[string]interface{} {
+		t.Helper()
+		var status map[string]interface{}
+		env.do(t, "GET", "/api/obs/status", "", nil, &status)
+		return status
+	}
+
+	if st := status(); st["studio_mode"] != false || st["current_transition"] != obstest.TransitionFade || st["transition_duration_ms"] != 300.0 {
+		t.Errorf("status = %v, want studio mode off with a 300 ms Fade", st)
+	}
+	if err := run("set_preview_scene", map[string]interface{}{"scene_name": "Intro"}); err == nil {
+		t.Error("setting the preview outside studio mode succeeded")
+	}
+
+	mustRun("enable_studio_mode", nil)
+	mustRun("set_preview_scene", map[string]interface{}{"scene_name": "Intro"})
+	if !fake.StudioMode() || fake.PreviewScene() != "Intro" {
+		t.Errorf("studio mode %v with preview %q, want on with Intro", fake.StudioMode(), fake.PreviewScene())
+	}
+	eventually(t, "status to show the preview", func() bool {
+		st := status()
+		return st["studio_mode"] == true && st["preview_scene"] == "Intro"
+	})
+
+	mustRun("trigger_transition", nil)
+	if fake.CurrentScene() != "Intro" || fake.PreviewScene() != "Main" {
+		t.Errorf("program %q, preview %q after transition, want Intro and Main", fake.CurrentScene(), fake.PreviewScene())
+	}
+
+	// The override is used for the switch, then Fade comes back
+	mustRun("switch_scene", map[string]interface{}{"scene_name": "Main", "transition_name": obstest.TransitionCut})
+	if fake.CurrentScene() != "Main" || fake.LastTransition() != obstest.TransitionCut {
+		t.Errorf("switched to %q with %q, want Main with Cut", fake.CurrentScene(), fake.LastTransition())
+	}
+	eventually(t, "Fade to be restored", func() bool {
+		name, _ := fake.Transition()
+		return name == obstest.TransitionFade
+	})
+
+	mustRun("set_transition", map[string]interface{}{"transition_name": obstest.TransitionFade, "duration_ms": 500.0})
+	if _, ms := fake.Transition(); ms != 500 {
+		t.Errorf("transition duration = %v, want 500", ms)
+	}
+	if err := run("set_transition_duration", map[string]interface{}{"duration_ms": 10.0}); err == nil {
+		t.Error("a 10 ms transition was accepted")
+	}
+	if err := run("set_transition", map[string]interface{}{"transition_name": "Swipe"}); err == nil {
+		t.Error("setting a missing transition succeeded")
+	}
+
+	// From a fixed-length Cut, an override to Fade is restored only after
+	// Fade's own length
+	mustRun("set_transition_duration", map[string]interface{}{"duration_ms": 1500.0})
+	mustRun("set_transition", map[string]interface{}{"transition_name": obstest.TransitionCut})
+	start := time.Now()
+	mustRun("switch_scene", map[string]interface{}{"scene_name": "Intro", "transition_name": obstest.TransitionFade})
+	time.Sleep(500 * time.Millisecond)
+	if name, _ := fake.Transition(); name != obstest.TransitionFade && time.Since(start) < 1500*time.Millisecond {
+		t.Errorf("transition = %q during the override, want Fade", name)
+	}
+	eventually(t, "Cut to be restored", func() bool {
+		name, _ := fake.Transition()
+		return name == obstest.TransitionCut
+	})
+	if elapsed := time.Since(start); elapsed < 1500*time.Millisecond {
+		t.Errorf("Cut restored after %v, before Fade's 1500 ms", elapsed)
+	}
+	mustRun("set_transition", map[string]interface{}{"transition_name": obstest.TransitionFade, "duration_ms": 500.0})
+
+	mustRun("toggle_studio_mode", nil)
+	eventually(t, "status to show studio mode off", func() bool {
+		st := status()
+		return st["studio_mode"] == false && st["preview_scene"] == "" && st["transition_duration_ms"] == 500.0
+	})
+}
+
+// TestOBSMedia plays, seeks and stops a media source and reads its status
+// the way a button showing the clip's time would
+func TestOBSMedia(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	fake.AddMediaInput("Clip", 300000)
+	env.connect(t, fake, "")
+
+	clip := map[string]interface{}{"input_name": "Clip"}
+	toggle := models.ButtonAction{Type: "media_toggle", Params: clip}
+	btn := env.place(t, 0, toggle)
+	btn.State = &models.ButtonState{Source: models.StateSourceMediaPlaying, Params: clip}
+	if err := env.buttonManager.Update(btn); err != nil {
+		t.Fatalf("update button: %v", err)
+	}
+	sessionID := env.register(t, "deck")
+
+	run := func(actionType string, params map[string]interface{}) error {
+		t.Helper()
+		merged := map[string]interface{}{"input_name": "Clip"}
+		for key, value := range params {
+			merged[key] = value
+		}
+		_, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: actionType, Params: merged})
+		return err
+	}
+	mediaStatus := func() models.MediaStatus {
+		t.Helper()
+		var status models.MediaStatus
+		if code := env.do(t, "GET", "/api/obs/media?input_name=Clip", sessionID, nil, &status); code != http.StatusOK {
+			t.Fatalf("media status: %d", code)
+		}
+		return status
+	}
+	buttonActive := func() bool {
+		t.Helper()
+		var status struct {
+			Buttons []models.ButtonStateUpdate `json:"buttons"`
+		}
+		env.do(t, "GET", "/api/obs/status", sessionID, nil, &status)
+		for _, state := range status.Buttons {
+			if state.ID == models.PositionKey(0, 1, 0) {
+				return state.Active
+			}
+		}
+		return false
+	}
+
+	if status := mediaStatus(); status.State != models.MediaStopped || status.Time != "" {
+		t.Errorf("status = %+v, want stopped without a time", status)
+	}
+	if err := run("media_seek", map[string]interface{}{"position_ms": 1000.0}); err == nil {
+		t.Error("seeking stopped media succeeded")
+	}
+
+	if code, ok := env.press(t, sessionID, toggle); code != http.StatusOK || !ok {
+		t.Fatalf("toggle media: status %d, success %v", code, ok)
+	}
+	if err := run("media_seek", map[string]interface{}{"position_ms": 133000.0}); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if status := mediaStatus(); status.State != models.MediaPlaying || status.Time != "02:13 / 05:00" {
+		t.Errorf("status = %+v, want playing at 02:13 / 05:00", status)
+	}
+	if !buttonActive() {
+		t.Error("button inactive while Clip plays")
+	}
+
+	if err := run("media_offset", map[string]interface{}{"offset_ms": -200000.0}); err != nil {
+		t.Fatalf("offset: %v", err)
+	}
+	env.press(t, sessionID, toggle)
+	if state, cursor, _ := fake.Media("Clip"); state != obstest.MediaPaused || cursor != 0 {
+		t.Errorf("Clip %s at %v ms, want paused at 0", state, cursor)
+	}
+	if buttonActive() {
+		t.Error("button active while Clip is paused")
+	}
+
+	if err := run("media_stop", nil); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := run("media_seek", map[string]interface{}{"position_ms": -1.0}); err == nil {
+		t.Error("seeking before the start was accepted")
+	}
+	if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "media_play", Params: map[string]interface{}{"input_name": "Mic"}}); err == nil {
+		t.Error("playing an input without media succeeded")
+	}
+
+	// Playback ending in OBS is pushed to clients
+	ended := make(chan struct{})
+	unsubscribe := env.obsManager.Subscribe(func(event models.OBSEvent) {
+		if event.Type == models.EventMediaStateChanged && event.Data["action"] == "ended" {
+			close(ended)
+		}
+	})
+	defer unsubscribe()
+	run("media_play", nil)
+	fake.EndMedia("Clip")
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no media_state_changed event for the end of Clip")
+	}
+}
+
+// TestOBSDropDuringRequest checks that OBS going away while a state read
+// waits for its response fails the read instead of crashing the server
+func TestOBSDropDuringRequest(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	reads := []struct {
+		request string
+		read    func() error
+	}{
+		{"GetInputMute", func() error {
+			_, err := env.obsManager.ButtonStateActive(models.ButtonState{
+				Source: models.StateSourceInputMuted,
+				Params: map[string]interface{}{"input_name": "Mic"},
+			})
+			return err
+		}},
+		{"GetSceneList", func() error {
+			_, err := env.obsManager.GetScenes()
+			return err
+		}},
+	}
+	for _, r := range reads {
+		eventually(t, "state loaded", func() bool {
+			status, err := env.obsManager.GetStatus()
+			return err == nil && status["connected"] == true && status["current_scene"] != ""
+		})
+		fake.DelayRequest(r.request, time.Second)
+
+		errs := make(chan error, 1)
+		go func() { errs <- r.read() }()
+		eventually(t, r.request+" sent", func() bool {
+			return fake.Received(r.request) > 0
+		})
+		fake.DropConnections()
+
+		if err := <-errs; err == nil {
+			t.Errorf("%s on a dropped connection succeeded", r.request)
+		}
+		fake.ClearFailures()
+		eventually(t, "disconnect", func() bool {
+			state, _ := env.obsManager.State()
+			return state != manager.StateConnected
+		})
+	}
+}
+
+// TestOBSMacros runs macros against the fake OBS and checks the per-step
+// results, both error policies, delays, nesting and the limits
+func TestOBSMacros(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	// Steps are built as they arrive decoded from a request
+	step := func(action models.ButtonAction, delayMs float64) interface{} {
+		return map[string]interface{}{
+			"action":   map[string]interface{}{"type": action.Type, "params": action.Params},
+			"delay_ms": delayMs,
+		}
+	}
+	macro := func(onError string, steps ...interface{}) models.ButtonAction {
+		params := map[string]interface{}{"steps": steps}
+		if onError != "" {
+			params["on_error"] = onError
+		}
+		return models.ButtonAction{Type: "macro", Params: params}
+	}
+	scene := func(name string) models.ButtonAction {
+		return models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": name}}
+	}
+	muteMic := models.ButtonAction{Type: "toggle_input_mute", Params: map[string]interface{}{"input_name": "Mic"}}
+	statuses := func(results []models.MacroStepResult) string {
+		names := make([]string, len(results))
+		for i, result := range results {
+			names[i] = result.Status
+		}
+		return strings.Join(names, ",")
+	}
+
+	// Through the API, with a delay before the second step
+	delayed := macro("", step(scene("Intro"), 0), step(muteMic, 150))
+	env.place(t, 0, delayed)
+	sessionID := env.register(t, "deck")
+	var resp struct {
+		Success bool                     `json:"success"`
+		Result  []models.MacroStepResult `json:"result"`
+	}
+	start := time.Now()
+	if code := env.do(t, "POST", "/api/action", sessionID, delayed, &resp); code != http.StatusOK || !resp.Success {
+		t.Fatalf("macro: status %d, success %v", code, resp.Success)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("macro took %v, want the 150ms delay", elapsed)
+	}
+	if got := statuses(resp.Result); got != "ok,ok" {
+		t.Errorf("step statuses = %s, want ok,ok", got)
+	}
+	if resp.Result[1].Step != 2 || resp.Result[1].Type != "toggle_input_mute" {
+		t.Errorf("second step result = %+v", resp.Result[1])
+	}
+	if muted, _ := fake.InputMuted("Mic"); fake.CurrentScene() != "Intro" || !muted {
+		t.Errorf("OBS on %s with Mic muted %v, want Intro and muted", fake.CurrentScene(), muted)
+	}
+
+	// A failing step stops the macro by default and continues if asked to
+	fake.SetCurrentScene("Main")
+	fake.FailRequest("ToggleInputMute", obstest.StatusResourceNotFound, "No source was found")
+	result, err := env.obsManager.ExecuteAction(macro("", step(scene("Intro"), 0), step(muteMic, 0), step(scene("Main"), 0)))
+	results, _ := result.([]models.MacroStepResult)
+	if err == nil || !strings.Contains(err.Error(), "macro step 2 (toggle_input_mute) failed") {
+		t.Errorf("stopped macro error = %v", err)
+	}
+	if got := statuses(results); got != "ok,failed,skipped" {
+		t.Errorf("stopped macro step statuses = %s, want ok,failed,skipped", got)
+	}
+	if results[1].Error == "" {
+		t.Error("failed step has no error")
+	}
+	if scene := fake.CurrentScene(); scene != "Intro" {
+		t.Errorf("OBS scene = %s, want Intro; the step after the failure ran", scene)
+	}
+
+	result, err = env.obsManager.ExecuteAction(macro(manager.MacroContinueOnError, step(muteMic, 0), step(scene("Main"), 0)))
+	results, _ = result.([]models.MacroStepResult)
+	if err != nil {
+		t.Errorf("continued macro error = %v, want none", err)
+	}
+	if got := statuses(results); got != "failed,ok" {
+		t.Errorf("continued macro step statuses = %s, want failed,ok", got)
+	}
+	if scene := fake.CurrentScene(); scene != "Main" {
+		t.Errorf("OBS scene = %s, want Main", scene)
+	}
+	fake.ClearFailures()
+
+	// A nested macro reports its own steps as the result of its step
+	result, err = env.obsManager.ExecuteAction(macro("", step(macro("", step(scene("Intro"), 0)), 0)))
+	if err != nil {
+		t.Fatalf("nested macro: %v", err)
+	}
+	results, _ = result.([]models.MacroStepResult)
+	nested, _ := results[0].Result.([]models.MacroStepResult)
+	if got := statuses(results) + "/" + statuses(nested); got != "ok/ok" {
+		t.Errorf("nested step statuses = %s, want ok/ok", got)
+	}
+	eventually(t, "the scene change to reach the manager", func() bool {
+		status, _ := env.obsManager.GetStatus()
+		return status["current_scene"] == "Intro"
+	})
+
+	// Macros over the limits are rejected before any step runs
+	tooDeep := macro("", step(muteMic, 0))
+	for i := 0; i < 4; i++ {
+		tooDeep = macro("", step(tooDeep, 0))
+	}
+	tooLong := make([]interface{}, 51)
+	for i := range tooLong {
+		tooLong[i] = step(muteMic, 0)
+	}
+	rejected := map[string]models.ButtonAction{
+		"too deep":      tooDeep,
+		"too many":      macro("", tooLong...),
+		"no steps":      macro(""),
+		"long delay":    macro("", step(muteMic, 0), step(muteMic, 6*60*1000)),
+		"client action": macro("", step(muteMic, 0), step(models.ButtonAction{Type: "page_next"}, 0)),
+		"bad policy":    macro("sometimes", step(muteMic, 0)),
+	}
+	before := fake.Received("ToggleInputMute")
+	for name, action := range rejected {
+		if _, err := env.obsManager.ExecuteAction(action); err == nil {
+			t.Errorf("%s: macro ran", name)
+		}
+	}
+	if n := fake.Received("ToggleInputMute") - before; n != 0 {
+		t.Errorf("rejected macros ran %d steps", n)
+	}
+}
+
+// TestOBSNavigationActions checks that page navigation actions are listed
+// for editors but refused when a client sends them to the server
+func TestOBSNavigationActions(t *testing.T) {
+	env, fake := newOBSEnv(t)
+	env.connect(t, fake, "")
+
+	var catalog []manager.ActionSpec
+	env.do(t, "GET", "/api/actions", "", nil, &catalog)
+	clientSide := make(map[string]bool)
+	for _, spec := range catalog {
+		if spec.Category == manager.CategoryNavigation {
+			clientSide[spec.Name] = spec.ClientSide
+		}
+	}
+	for _, name := range []string{"folder", "page_next", "page_prev", "page_back"} {
+		if !clientSide[name] {
+			t.Errorf("%s not listed as a client-side navigation action", name)
+		}
+	}
+
+	folder := models.ButtonAction{Type: "folder", Params: map[string]interface{}{"page": 2.0}}
+	next := models.ButtonAction{Type: "page_next"}
+	env.place(t, 0, folder)
+	env.place(t, 1, next)
+	sessionID := env.register(t, "deck")
+
+	// Health checks may ping OBS meanwhile; anything else came from an action
+	sent := func() int {
+		n := 0
+		for _, requestType := range fake.Requests() {
+			if requestType != "GetVersion" {
+				n++
+			}
+		}
+		return n
+	}
+	requests := sent()
+	for _, action := range []models.ButtonAction{folder, next} {
+		var resp struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		code := env.do(t, "POST", "/api/action", sessionID, action, &resp)
+		if code != http.StatusInternalServerError || resp.Success || !strings.Contains(resp.Error, "handled by the client") {
+			t.Errorf("%s: status %d, success %v, error %q, want refused", action.Type, code, resp.Success, resp.Error)
+		}
+	}
+	if n := sent() - requests; n != 0 {
+		t.Errorf("navigation actions sent %d requests to OBS", n)
+	}
+
+	if _, err := env.obsManager.ExecuteAction(models.ButtonAction{Type: "folder"}); err == nil || strings.Contains(err.Error(), "handled by the client") {
+		t.Errorf("folder without a page: %v, want a parameter error", err)
+	}
+}
@@ -15,59 +15,105 @@ import (
 type Server struct {
 	router         *mux.Router
 	configManager  *manager.ConfigManager
+	buttonManager  *manager.ButtonManager
 	sessionManager *manager.SessionManager
 	obsManager     *manager.OBSManager
+	authManager    *manager.AuthManager
+	allowedOrigins []string
+	events         *eventHub
 }
 
-// NewServer creates a new API server
+// NewServer creates a new API server. allowedOrigins lists the browser
+// origins allowed by CORS; with none, cross-origin browser requests fail.
 func NewServer(
 	cm *manager.ConfigManager,
+	bm *manager.ButtonManager,
 	sm *manager.SessionManager,
 	om *manager.OBSManager,
+	am *manager.AuthManager,
+	allowedOrigins []string,
 ) *Server {
 	s := &Server{
 		router:         mux.NewRouter(),
 		configManager:  cm,
+		buttonManager:  bm,
 		sessionManager: sm,
 		obsManager:     om,
+		authManager:    am,
+		allowedOrigins: allowedOrigins,
 	}
+	s.events = newEventHub(s.decorateEvent)
 	s.setupRoutes()
+
+	// Fan OBS events out to connected clients
+	go s.events.run()
+	om.Subscribe(s.publishOBSEvent)
+
+	// Tell clients when their configuration is reassigned or edited
+	cm.OnConfigChange(s.publishConfigChanges)
+
+	// Close the event streams of revoked clients
+	am.OnRevoke(func(client *models.PairedClient) {
+		s.events.disconnect <- client.ClientID
+	})
+
 	return s
 }
 
 // setupRoutes configures API routes
 func (s *Server) setupRoutes() {
-	// Enable CORS
+	// Enable CORS, then require a client token
 	s.router.Use(s.corsMiddleware)
+	s.router.Use(s.authMiddleware)
 
 	// Configuration endpoints
 	s.router.HandleFunc("/api/configurations", s.listConfigurations).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/api/configurations/default", s.getDefaultConfiguration).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/api/configurations/{id}", s.getConfiguration).Methods("GET", "OPTIONS")
+	s.router.HandleFunc("/api/configurations/{id}/test", s.testConfiguration).Methods("GET", "OPTIONS")
+
+	// Button library endpoints
+	s.router.HandleFunc("/api/buttons", s.searchButtons).Methods("GET", "OPTIONS")
+	s.router.HandleFunc("/api/buttons/usage", s.listButtonUsage).Methods("GET", "OPTIONS")
+	s.router.HandleFunc("/api/buttons/validation", s.validateButtons).Methods("GET", "OPTIONS")
+	s.router.HandleFunc("/api/buttons/{id}/usage", s.getButtonUsage).Methods("GET", "OPTIONS")
 
 	// Client endpoints
+	s.router.HandleFunc("/api/client/pair", s.pairClient).Methods("POST", "OPTIONS")
 	s.router.HandleFunc("/api/client/register", s.registerClient).Methods("POST", "OPTIONS")
 	s.router.HandleFunc("/api/client/config", s.getClientConfig).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/api/client/config/{id}", s.switchClientConfig).Methods("PUT", "OPTIONS")
 
-	// Action endpoint
+	// Action endpoints
 	s.router.HandleFunc("/api/action", s.executeAction).Methods("POST", "OPTIONS")
+	s.router.HandleFunc("/api/actions", s.listActions).Methods("GET", "OPTIONS")
 
 	// OBS status endpoints
 	s.router.HandleFunc("/api/obs/status", s.getOBSStatus).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/api/obs/scenes", s.getScenes).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/api/obs/inputs", s.getInputs).Methods("GET", "OPTIONS")
+	s.router.HandleFunc("/api/obs/media", s.getMediaStatus).Methods("GET", "OPTIONS")
+
+	// Bundle export. Bundle and profile imports replace configurations, so
+	// they are only offered to the operator through the app.
+	s.router.HandleFunc("/api/bundle/export", s.exportBundle).Methods("GET", "OPTIONS")
+
+	// OBS event stream (WebSocket)
+	s.router.HandleFunc("/api/events", s.streamEvents).Methods("GET")
 
 	// Health check
 	s.router.HandleFunc("/api/health", s.healthCheck).Methods("GET", "OPTIONS")
 }
 
-// corsMiddleware handles CORS
+// corsMiddleware handles CORS for the configured origins
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && s.originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Session-ID, X-Client-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Session-ID, X-Client-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -88,10 +134,16 @@ func (s *Server) Start(addr string) error {
 
 // healthCheck returns server health status
 func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+	obsState, obsErr := s.obsManager.State()
+	health := map[string]interface{}{
 		"status": "ok",
 		"obs_connected": s.obsManager.IsConnected(),
-	})
+		"obs_state":     obsState,
+	}
+	if obsErr != nil {
+		health["obs_error"] = obsErr.Error()
+	}
+	s.respondJSON(w, http.StatusOK, health)
 }
 
 // listConfigurations returns all configurations
@@ -131,6 +183,22 @@ func (s *Server) getConfiguration(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusOK, resolved)
 }
 
+// testConfiguration dry-runs every button of a configuration against OBS
+func (s *Server) testConfiguration(w http.ResponseWriter, r *http.Request) {
+	resolved, err := s.configManager.Resolve(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	report, err := s.obsManager.DryRunConfiguration(resolved)
+	if err != nil {
+		s.respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
+
 // registerClient registers a new client or returns existing session
 func (s *Server) registerClient(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -143,17 +211,37 @@ func (s *Server) registerClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A token can only register the client it was issued to
+	if client := requestClient(r); client != nil {
+		if req.ClientID != "" && req.ClientID != client.ClientID {
+			s.respondError(w, http.StatusForbidden, "client_id does not match token")
+			return
+		}
+		req.ClientID = client.ClientID
+	}
+
 	// Get client IP
 	ipAddress := s.getClientIP(r)
 
 	// Check if client already has a session
 	existingSession, err := s.sessionManager.GetByClientID(req.ClientID)
 	if err == nil {
+		// Sessions from before a configuration was deleted fall back to the default
+		configID := existingSession.ConfigID
+		if _, err := s.configManager.Get(configID); err != nil {
+			defaultConfig, err := s.configManager.GetDefault()
+			if err != nil {
+				s.respondError(w, http.StatusInternalServerError, "no default configuration available")
+				return
+			}
+			configID = defaultConfig.ID
+		}
+
 		// Update existing session
 		session, err := s.sessionManager.RegisterOrUpdate(
 			req.ClientID,
 			req.ClientName,
-			existingSession.ConfigID,
+			configID,
 			ipAddress,
 		)
 		if err != nil {
@@ -212,14 +300,8 @@ func (s *Server) registerClient(w http.ResponseWriter, r *http.Request) {
 // getClientConfig returns the current configuration for a client
 func (s *Server) getClientConfig(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.Header.Get("X-Session-ID")
-	if sessionID == "" {
-		s.respondError(w, http.StatusBadRequest, "missing X-Session-ID header")
-		return
-	}
-
-	session, err := s.sessionManager.Get(sessionID)
-	if err != nil {
-		s.respondError(w, http.StatusNotFound, "session not found")
+	session := s.requireSession(w, r, sessionID)
+	if session == nil {
 		return
 	}
 
@@ -239,8 +321,8 @@ func (s *Server) getClientConfig(w http.ResponseWriter, r *http.Request) {
 // switchClientConfig switches a client to a different configuration
 func (s *Server) switchClientConfig(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.Header.Get("X-Session-ID")
-	if sessionID == "" {
-		s.respondError(w, http.StatusBadRequest, "missing X-Session-ID header")
+	session := s.requireSession(w, r, sessionID)
+	if session == nil {
 		return
 	}
 
@@ -254,8 +336,23 @@ func (s *Server) switchClientConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only configurations that allow it let a session leave them
+	if configID != session.ConfigID {
+		allowed, err := s.configManager.SwitchAllowed(session.ConfigID)
+		if err != nil {
+			s.respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if !allowed {
+			log.Printf("🚫 Denied switch to configuration %s for %s (session %s, configuration %s, %s)",
+				configID, session.ClientName, sessionID, session.ConfigID, s.getClientIP(r))
+			s.respondError(w, http.StatusForbidden, "configuration is assigned by the server operator")
+			return
+		}
+	}
+
 	// Update session
-	if err := s.sessionManager.UpdateConfig(sessionID, configID); err != nil {
+	if err := s.configManager.AssignSession(sessionID, configID); err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -273,32 +370,76 @@ func (s *Server) switchClientConfig(w http.ResponseWriter, r *http.Request) {
 // executeAction executes an OBS action
 func (s *Server) executeAction(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.Header.Get("X-Session-ID")
-	if sessionID == "" {
-		s.respondError(w, http.StatusBadRequest, "missing X-Session-ID header")
+	session := s.requireSession(w, r, sessionID)
+	if session == nil {
 		return
 	}
 
-	var action models.ButtonAction
-	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+	var req struct {
+		models.ButtonAction
+		Button string `json:"button"` // position of the pressed button, optional
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	action := req.ButtonAction
+
+	// Sessions may only run what their configuration offers them
+	allowed, err := s.configManager.ActionAllowed(session.ConfigID, action)
+	if err != nil {
+		s.respondError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if !allowed {
+		log.Printf("🚫 Denied %s action for %s (session %s, configuration %s, %s)",
+			action.Type, session.ClientName, sessionID, session.ConfigID, s.getClientIP(r))
+		s.respondError(w, http.StatusForbidden, "action not permitted by this configuration")
+		return
+	}
 
 	// Update activity
 	s.sessionManager.UpdateActivity(sessionID)
 
-	// Execute action
-	if err := s.obsManager.ExecuteAction(action); err != nil {
-		s.respondError(w, http.StatusInternalServerError, err.Error())
+	// Execute action. Macros run to completion server-side even if the
+	// client gives up waiting for the response.
+	result, err := s.obsManager.ExecuteAction(action)
+
+	// Count the press against the library button, whether or not OBS took it
+	if buttonID := s.configManager.PressedButton(session.ConfigID, req.Button, action); buttonID != "" {
+		if err := s.buttonManager.RecordPress(buttonID); err != nil {
+			log.Printf("⚠️  Failed to record press of button %s: %v", buttonID, err)
+		}
+	}
+
+	if err != nil {
+		response := map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}
+		if result != nil {
+			response["result"] = result
+		}
+		s.respondJSON(w, http.StatusInternalServerError, response)
 		return
 	}
 
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"success": true,
-	})
+	}
+	if result != nil {
+		response["result"] = result
+	}
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// listActions returns the catalog of action types and their parameters
+func (s *Server) listActions(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, s.obsManager.ActionCatalog())
 }
 
-// getOBSStatus returns current OBS status
+// getOBSStatus returns current OBS status. With an X-Session-ID header the
+// response also carries the live state of the session's toggle buttons.
 func (s *Server) getOBSStatus(w http.ResponseWriter, r *http.Request) {
 	status, err := s.obsManager.GetStatus()
 	if err != nil {
@@ -306,9 +447,36 @@ func (s *Server) getOBSStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sessionID := r.Header.Get("X-Session-ID"); sessionID != "" {
+		if s.requireSession(w, r, sessionID) == nil {
+			return
+		}
+		status = s.withButtonStates(sessionID, status)
+	}
+
 	s.respondJSON(w, http.StatusOK, status)
 }
 
+// withButtonStates returns a copy of status with the toggle button states of
+// the session's configuration added under "buttons"
+func (s *Server) withButtonStates(sessionID string, status map[string]interface{}) map[string]interface{} {
+	session, err := s.sessionManager.Get(sessionID)
+	if err != nil {
+		return status
+	}
+	states, err := s.configManager.ButtonStates(session.ConfigID)
+	if err != nil {
+		return status
+	}
+
+	out := make(map[string]interface{}, len(status)+1)
+	for key, value := range status {
+		out[key] = value
+	}
+	out["buttons"] = states
+	return out
+}
+
 // getScenes returns list of OBS scenes
 func (s *Server) getScenes(w http.ResponseWriter, r *http.Request) {
 	scenes, err := s.obsManager.GetScenes()
@@ -331,6 +499,24 @@ func (s *Server) getInputs(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusOK, inputs)
 }
 
+// getMediaStatus returns the playback state of the media input named by
+// the input_name query parameter. Names may hold slashes, so it isn't part
+// of the path.
+func (s *Server) getMediaStatus(w http.ResponseWriter, r *http.Request) {
+	inputName := r.URL.Query().Get("input_name")
+	if inputName == "" {
+		s.respondError(w, http.StatusBadRequest, "input_name is required")
+		return
+	}
+	status, err := s.obsManager.GetMediaStatus(inputName)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, status)
+}
+
 // ==================== HELPERS ====================
 
 // respondJSON writes a JSON response
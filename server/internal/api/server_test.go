@@ -0,0 +1,1162 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/robomon1/robo-stream/server/internal/manager"
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+var cascade = models.DeleteOptions{Mode: models.DeleteCascade}
+
+// testEnv is an API server backed by managers saving to a temp directory
+type testEnv struct {
+	server         *Server
+	storage        *storage.Storage
+	buttonManager  *manager.ButtonManager
+	configManager  *manager.ConfigManager
+	sessionManager *manager.SessionManager
+	authManager    *manager.AuthManager
+	obsManager     *manager.OBSManager
+	defaultConfig  *models.Configuration
+
+	mu     sync.Mutex
+	token  string            // paired in newTestEnv, used without a session
+	tokens map[string]string // by session ID
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	st, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	repo, err := storage.NewJSONRepository(st)
+	if err != nil {
+		t.Fatalf("storage.NewJSONRepository: %v", err)
+	}
+	store := manager.NewStore(repo)
+	bm := manager.NewButtonManager(store)
+	sm := manager.NewSessionManager(store)
+	cm := manager.NewConfigManager(store, bm, sm)
+	am := manager.NewAuthManager(store, sm)
+	om := manager.NewOBSManager()
+	cm.SetStateProvider(om)
+
+	btn := &models.Button{
+		Name:   "Stream",
+		Action: models.ButtonAction{Type: "toggle_stream"},
+		State:  &models.ButtonState{Source: models.StateSourceStreamActive},
+	}
+	if err := bm.Create(btn); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	cfg := &models.Configuration{
+		Name:    "Default",
+		Grid:    models.GridConfig{Rows: 2, Cols: 2},
+		Buttons: map[string]string{models.PositionKey(0, 0, 0): btn.ID},
+	}
+	if err := cm.Create(cfg); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+	if err := cm.SetDefault(cfg.ID); err != nil {
+		t.Fatalf("set default: %v", err)
+	}
+
+	env := &testEnv{
+		server:         NewServer(cm, bm, sm, om, am, nil),
+		storage:        st,
+		buttonManager:  bm,
+		configManager:  cm,
+		sessionManager: sm,
+		authManager:    am,
+		obsManager:     om,
+		defaultConfig:  cfg,
+		tokens:         make(map[string]string),
+	}
+	env.token = env.pair(t, "test-client")
+	return env
+}
+
+// pair pairs a client through the API and returns its token
+func (e *testEnv) pair(t *testing.T, clientID string) string {
+	t.Helper()
+
+	// Pairing codes are single use, so clients pair one at a time
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	code, err := e.authManager.NewPairingCode()
+	if err != nil {
+		t.Fatalf("new pairing code: %v", err)
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	status := e.request(t, "POST", "/api/client/pair", "", "", map[string]string{
+		"code":        code.Code,
+		"client_id":   clientID,
+		"client_name": clientID,
+	}, &resp)
+	if status != http.StatusOK || resp.Token == "" {
+		t.Errorf("pair %s: status %d", clientID, status)
+	}
+	return resp.Token
+}
+
+// do sends a request with the token of the client owning sessionID, or the
+// environment's token without a session
+func (e *testEnv) do(t *testing.T, method, path, sessionID string, body, out interface{}) int {
+	t.Helper()
+
+	e.mu.Lock()
+	token, ok := e.tokens[sessionID]
+	if !ok {
+		token = e.token
+	}
+	e.mu.Unlock()
+	return e.request(t, method, path, token, sessionID, body, out)
+}
+
+// request sends a request to the server and decodes the JSON response into out
+func (e *testEnv) request(t *testing.T, method, path, token, sessionID string, body, out interface{}) int {
+	t.Helper()
+
+	reader := bytes.NewReader(nil)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Errorf("encode body: %v", err)
+			return 0
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sessionID != "" {
+		req.Header.Set("X-Session-ID", sessionID)
+	}
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Errorf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// register pairs and registers a client and returns its session ID
+func (e *testEnv) register(t *testing.T, clientID string) string {
+	t.Helper()
+
+	token := e.pair(t, clientID)
+	var resp struct {
+		SessionID string `json:"session_id"`
+	}
+	code := e.request(t, "POST", "/api/client/register", token, "", map[string]string{
+		"client_id":   clientID,
+		"client_name": clientID,
+	}, &resp)
+	if code != http.StatusOK || resp.SessionID == "" {
+		t.Errorf("register %s: status %d, session %q", clientID, code, resp.SessionID)
+	}
+
+	e.mu.Lock()
+	e.tokens[resp.SessionID] = token
+	e.mu.Unlock()
+	return resp.SessionID
+}
+
+// TestConcurrentClientTraffic runs the requests clients make all at once,
+// alongside session cleanup. Run with -race.
+func TestConcurrentClientTraffic(t *testing.T) {
+	env := newTestEnv(t)
+
+	const clients = 8
+	const rounds = 20
+
+	var wg sync.WaitGroup
+	for c := 0; c < clients; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			sessionID := env.register(t, fmt.Sprintf("client-%d", c))
+
+			for i := 0; i < rounds; i++ {
+				var resolved models.ResolvedConfiguration
+				if code := env.do(t, "GET", "/api/client/config", sessionID, nil, &resolved); code != http.StatusOK {
+					t.Errorf("get client config: status %d", code)
+				}
+				env.do(t, "PUT", "/api/client/config/"+env.defaultConfig.ID, sessionID, nil, nil)
+				env.do(t, "GET", "/api/obs/status", sessionID, nil, nil)
+				env.do(t, "GET", "/api/configurations", "", nil, nil)
+				env.do(t, "POST", "/api/action", sessionID, models.ButtonAction{Type: "toggle_stream"}, nil)
+			}
+		}(c)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			env.sessionManager.CleanupInactive(time.Hour)
+		}
+	}()
+
+	wg.Wait()
+
+	if got := len(env.sessionManager.List()); got != clients {
+		t.Errorf("sessions = %d, want %d", got, clients)
+	}
+}
+
+// TestConcurrentButtonDeletes creates and deletes buttons while clients
+// resolve the configuration they are placed in
+func TestConcurrentButtonDeletes(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "reader")
+
+	const writers = 4
+	const rounds = 15
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				btn := &models.Button{Name: "Temp", Action: models.ButtonAction{Type: "toggle_record"}}
+				if err := env.buttonManager.Create(btn); err != nil {
+					t.Errorf("create button: %v", err)
+					return
+				}
+
+				cfg, err := env.configManager.Get(env.defaultConfig.ID)
+				if err != nil {
+					t.Errorf("get configuration: %v", err)
+					return
+				}
+				cfg.Buttons[models.PositionKey(w+1, 0, i%2)] = btn.ID
+				if err := env.configManager.Update(cfg); err != nil {
+					t.Errorf("update configuration: %v", err)
+					return
+				}
+
+				if _, err := env.configManager.DeleteButton(btn.ID, cascade); err != nil {
+					t.Errorf("delete button: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds*2; i++ {
+				env.do(t, "GET", "/api/client/config", sessionID, nil, nil)
+				env.do(t, "GET", "/api/configurations/"+env.defaultConfig.ID, "", nil, nil)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Get/Update pairs may race and put a deleted button back, which Resolve
+	// skips; the original button and its placement must survive
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	buttonID := env.defaultConfig.Buttons[models.PositionKey(0, 0, 0)]
+	if cfg.Buttons[models.PositionKey(0, 0, 0)] != buttonID {
+		t.Errorf("original placement lost: %v", cfg.Buttons)
+	}
+	if got := len(env.buttonManager.List()); got != 1 {
+		t.Errorf("buttons = %d, want 1", got)
+	}
+}
+
+// TestDeleteButtonPersistsBothFiles checks that deleting a button saves the
+// library and the configurations together
+func TestDeleteButtonPersistsBothFiles(t *testing.T) {
+	env := newTestEnv(t)
+
+	buttonID := env.defaultConfig.Buttons[models.PositionKey(0, 0, 0)]
+	if _, err := env.configManager.DeleteButton(buttonID, cascade); err != nil {
+		t.Fatalf("delete button: %v", err)
+	}
+
+	repo, err := storage.NewJSONRepository(env.storage)
+	if err != nil {
+		t.Fatalf("storage.NewJSONRepository: %v", err)
+	}
+	reloaded := manager.NewStore(repo)
+	bm := manager.NewButtonManager(reloaded)
+	cm := manager.NewConfigManager(reloaded, bm, manager.NewSessionManager(reloaded))
+
+	if _, err := bm.Get(buttonID); err == nil {
+		t.Errorf("button %s still saved", buttonID)
+	}
+	cfg, err := cm.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	if len(cfg.Buttons) != 0 {
+		t.Errorf("configuration still places %v", cfg.Buttons)
+	}
+}
+
+// TestDeleteUnknownButtonChangesNothing checks that a failed transaction
+// leaves state untouched
+func TestDeleteUnknownButtonChangesNothing(t *testing.T) {
+	env := newTestEnv(t)
+
+	if _, err := env.configManager.DeleteButton("missing", cascade); err == nil {
+		t.Fatal("expected an error deleting an unknown button")
+	}
+	if got := len(env.buttonManager.List()); got != 1 {
+		t.Errorf("buttons = %d, want 1", got)
+	}
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	if len(cfg.Buttons) != 1 {
+		t.Errorf("configuration buttons = %d, want 1", len(cfg.Buttons))
+	}
+}
+
+// TestDeleteButtonModes checks that a placed button is only deleted when the
+// caller says what to do with its placements
+func TestDeleteButtonModes(t *testing.T) {
+	env := newTestEnv(t)
+	position := models.PositionKey(0, 0, 0)
+	buttonID := env.defaultConfig.Buttons[position]
+
+	report, err := env.configManager.DeleteButton(buttonID, models.DeleteOptions{})
+	if err == nil {
+		t.Fatal("expected restrict mode to refuse deleting a placed button")
+	}
+	if report == nil || report.Deleted || len(report.Configurations) != 1 {
+		t.Fatalf("report = %+v, want one dependent configuration and not deleted", report)
+	}
+
+	replacement := &models.Button{Name: "Record", Action: models.ButtonAction{Type: "toggle_record"}}
+	if err := env.buttonManager.Create(replacement); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	report, err = env.configManager.DeleteButton(buttonID, models.DeleteOptions{
+		Mode:        models.DeleteReassign,
+		ReplaceWith: replacement.ID,
+	})
+	if err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	if !report.Deleted || report.ReplacedWith != replacement.ID {
+		t.Errorf("report = %+v, want deleted and replaced", report)
+	}
+
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	if cfg.Buttons[position] != replacement.ID {
+		t.Errorf("%s holds %q, want the replacement", position, cfg.Buttons[position])
+	}
+}
+
+// TestDeleteConfigurationKeepsDefault checks that sessions are moved and the
+// server is never left without a default configuration
+func TestDeleteConfigurationKeepsDefault(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "client")
+
+	if _, err := env.configManager.Delete(env.defaultConfig.ID, cascade); err == nil {
+		t.Fatal("expected deleting the only configuration to fail")
+	}
+
+	other := &models.Configuration{Name: "Other", Grid: models.GridConfig{Rows: 1, Cols: 1}}
+	if err := env.configManager.Create(other); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+	if other.IsDefault {
+		t.Fatal("second configuration should not take over the default")
+	}
+
+	reassign := models.DeleteOptions{Mode: models.DeleteReassign}
+	if _, err := env.configManager.Delete(env.defaultConfig.ID, reassign); err == nil {
+		t.Fatal("expected deleting the default without a new default to fail")
+	}
+
+	reassign.ReplaceWith = other.ID
+	report, err := env.configManager.Delete(env.defaultConfig.ID, reassign)
+	if err != nil {
+		t.Fatalf("delete default: %v", err)
+	}
+	if report.NewDefault != other.ID || len(report.Sessions) != 1 {
+		t.Errorf("report = %+v, want new default and one moved session", report)
+	}
+
+	def, err := env.configManager.GetDefault()
+	if err != nil || def.ID != other.ID {
+		t.Fatalf("default = %v, %v; want %s", def, err, other.ID)
+	}
+	sess, err := env.sessionManager.Get(sessionID)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if sess.ConfigID != other.ID {
+		t.Errorf("session config = %s, want %s", sess.ConfigID, other.ID)
+	}
+	if again := env.register(t, "client"); again != sessionID {
+		t.Errorf("register after delete: session %q, want %q", again, sessionID)
+	}
+}
+
+// TestRequestsNeedToken checks that only pairing and the health check are
+// open without a token
+func TestRequestsNeedToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	if code := env.request(t, "GET", "/api/configurations", "", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", code)
+	}
+	if code := env.request(t, "GET", "/api/configurations", "not-a-token", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want 401", code)
+	}
+	if code := env.request(t, "GET", "/api/health", "", "", nil, nil); code != http.StatusOK {
+		t.Errorf("health: status %d, want 200", code)
+	}
+
+	code, err := env.authManager.NewPairingCode()
+	if err != nil {
+		t.Fatalf("new pairing code: %v", err)
+	}
+	wrong := "000000"
+	if code.Code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < 5; i++ {
+		body := map[string]string{"code": wrong, "client_id": "guesser"}
+		if status := env.request(t, "POST", "/api/client/pair", "", "", body, nil); status != http.StatusForbidden {
+			t.Errorf("wrong code: status %d, want 403", status)
+		}
+	}
+	body := map[string]string{"code": code.Code, "client_id": "guesser"}
+	if status := env.request(t, "POST", "/api/client/pair", "", "", body, nil); status != http.StatusForbidden {
+		t.Errorf("code after too many guesses: status %d, want 403", status)
+	}
+}
+
+// TestSessionBelongsToClient checks that a token can't use another client's
+// session
+func TestSessionBelongsToClient(t *testing.T) {
+	env := newTestEnv(t)
+	mine := env.register(t, "mine")
+	theirs := env.register(t, "theirs")
+
+	env.mu.Lock()
+	token := env.tokens[mine]
+	env.mu.Unlock()
+
+	action := models.ButtonAction{Type: "toggle_stream"}
+	if code := env.request(t, "POST", "/api/action", token, theirs, action, nil); code != http.StatusForbidden {
+		t.Errorf("action with another session: status %d, want 403", code)
+	}
+	if code := env.request(t, "GET", "/api/client/config", token, theirs, nil, nil); code != http.StatusForbidden {
+		t.Errorf("config of another session: status %d, want 403", code)
+	}
+	body := map[string]string{"client_id": "theirs"}
+	if code := env.request(t, "POST", "/api/client/register", token, "", body, nil); code != http.StatusForbidden {
+		t.Errorf("register as another client: status %d, want 403", code)
+	}
+}
+
+// TestRevokeClient checks that a revoked token stops working and its
+// sessions end
+func TestRevokeClient(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "revoked")
+
+	var paired *models.PairedClient
+	for _, client := range env.authManager.List() {
+		if client.ClientID == "revoked" {
+			paired = client
+		}
+		if client.TokenHash != "" {
+			t.Errorf("List exposes the token hash of %s", client.ClientID)
+		}
+	}
+	if paired == nil {
+		t.Fatal("paired client not listed")
+	}
+
+	if err := env.authManager.Revoke(paired.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if code := env.do(t, "GET", "/api/client/config", sessionID, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("after revoke: status %d, want 401", code)
+	}
+	if _, err := env.sessionManager.Get(sessionID); err == nil {
+		t.Error("session of revoked client still exists")
+	}
+}
+
+// TestActionPermissions checks that a session can only execute the actions
+// of its configuration's buttons and allowlist
+func TestActionPermissions(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "guest")
+
+	scene := &models.Button{
+		Name:   "BRB",
+		Action: models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": "BRB"}},
+	}
+	if err := env.buttonManager.Create(scene); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	cfg.Buttons[models.PositionKey(0, 0, 1)] = scene.ID
+	if err := env.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+
+	permitted := func(action models.ButtonAction) bool {
+		return env.do(t, "POST", "/api/action", sessionID, action, nil) != http.StatusForbidden
+	}
+
+	if !permitted(models.ButtonAction{Type: "toggle_stream"}) {
+		t.Error("button action denied")
+	}
+	if !permitted(scene.Action) {
+		t.Error("button action with params denied")
+	}
+	if permitted(models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": "Live"}}) {
+		t.Error("action with other params permitted")
+	}
+	if permitted(models.ButtonAction{Type: "stop_stream"}) {
+		t.Error("action not in configuration permitted")
+	}
+
+	cfg.AllowedActions = []string{"stop_stream"}
+	if err := env.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+	if !permitted(models.ButtonAction{Type: "stop_stream"}) {
+		t.Error("allowlisted action denied")
+	}
+}
+
+// TestConfigChangePushed checks that a session's event stream is told when
+// it is reassigned and when a button it shows is edited
+func TestConfigChangePushed(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "deck")
+	env.mu.Lock()
+	token := env.tokens[sessionID]
+	env.mu.Unlock()
+
+	srv := httptest.NewServer(env.server.router)
+	defer srv.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("X-Session-ID", sessionID)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/events", header)
+	if err != nil {
+		t.Fatalf("dial event stream: %v", err)
+	}
+	defer conn.Close()
+
+	// The snapshot is only written once the subscriber is registered
+	next := func() models.OBSEvent {
+		t.Helper()
+		var event models.OBSEvent
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		return event
+	}
+	if event := next(); event.Type != models.EventStatus {
+		t.Fatalf("first event = %s, want status", event.Type)
+	}
+
+	other := &models.Configuration{Name: "Guest", Grid: models.GridConfig{Rows: 1, Cols: 1}}
+	if err := env.configManager.Create(other); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+	if err := env.configManager.AssignSession(sessionID, other.ID); err != nil {
+		t.Fatalf("assign session: %v", err)
+	}
+	event := next()
+	if event.Type != models.EventConfigChanged || event.Data["config_id"] != other.ID || event.Data["reason"] != manager.ConfigChangeReassigned {
+		t.Errorf("event = %+v, want config_changed to %s", event, other.ID)
+	}
+
+	if err := env.configManager.AssignSession(sessionID, env.defaultConfig.ID); err != nil {
+		t.Fatalf("assign session: %v", err)
+	}
+	next()
+
+	btn, err := env.buttonManager.Get(env.defaultConfig.Buttons[models.PositionKey(0, 0, 0)])
+	if err != nil {
+		t.Fatalf("get button: %v", err)
+	}
+	btn.Name = "Go Live"
+	if err := env.buttonManager.Update(btn); err != nil {
+		t.Fatalf("update button: %v", err)
+	}
+	event = next()
+	if event.Type != models.EventConfigChanged || event.Data["reason"] != manager.ConfigChangeUpdated {
+		t.Errorf("event = %+v, want config_changed for the edited button", event)
+	}
+}
+
+// TestBundleExportImport moves a deck between two servers that both have a
+// configuration and button of the same name
+func TestBundleExportImport(t *testing.T) {
+	src := newTestEnv(t)
+	dst := newTestEnv(t)
+
+	send := func(env *testEnv, method, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+env.token)
+		rec := httptest.NewRecorder()
+		env.server.router.ServeHTTP(rec, req)
+		return rec
+	}
+	importBundle := func(opts models.BundleImportOptions, archive []byte) *models.BundleImportReport {
+		t.Helper()
+		bundle, err := storage.ReadBundle(archive)
+		if err != nil {
+			t.Fatalf("read bundle: %v", err)
+		}
+		report, err := dst.configManager.ImportBundle(bundle, opts)
+		if err != nil {
+			t.Fatalf("import %+v: %v", opts, err)
+		}
+		return report
+	}
+
+	rec := send(src, "GET", "/api/bundle/export?config="+src.defaultConfig.ID, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: status %d: %s", rec.Code, rec.Body.String())
+	}
+	archive := rec.Body.Bytes()
+
+	report := importBundle(models.BundleImportOptions{Conflict: models.ConflictDuplicate, DryRun: true}, archive)
+	if len(report.Configurations) != 1 || report.Configurations[0].Result != models.ImportDuplicated {
+		t.Errorf("dry run configurations = %+v, want one duplicated", report.Configurations)
+	}
+	if len(report.Buttons) != 1 || report.Buttons[0].ConflictID == "" {
+		t.Errorf("dry run buttons = %+v, want one conflicting", report.Buttons)
+	}
+	if got := len(dst.configManager.List()); got != 1 {
+		t.Fatalf("dry run saved configurations: %d", got)
+	}
+
+	report = importBundle(models.BundleImportOptions{Conflict: models.ConflictSkip}, archive)
+	if report.Configurations[0].Result != models.ImportSkipped || len(dst.configManager.List()) != 1 {
+		t.Errorf("skip: report %+v", report.Configurations)
+	}
+
+	report = importBundle(models.BundleImportOptions{Conflict: models.ConflictDuplicate}, archive)
+	imported, err := dst.configManager.Get(report.Configurations[0].ID)
+	if err != nil {
+		t.Fatalf("get imported configuration: %v", err)
+	}
+	if imported.Name != "Default (imported)" || imported.IsDefault {
+		t.Errorf("imported configuration = %q (default %v)", imported.Name, imported.IsDefault)
+	}
+	buttonID := imported.Buttons[models.PositionKey(0, 0, 0)]
+	if buttonID != report.Buttons[0].ID || buttonID == src.defaultConfig.Buttons[models.PositionKey(0, 0, 0)] {
+		t.Errorf("placement = %s, want the new button %s", buttonID, report.Buttons[0].ID)
+	}
+	if _, err := dst.buttonManager.Get(buttonID); err != nil {
+		t.Errorf("imported button missing: %v", err)
+	}
+
+	if _, err := storage.ReadBundle([]byte("not a zip")); err == nil {
+		t.Error("invalid archive read without error")
+	}
+
+	// Clients can export but not import
+	if rec := send(dst, "POST", "/api/bundle/import", archive); rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("import over the API: status %d", rec.Code)
+	}
+}
+
+func TestProfileImport(t *testing.T) {
+	env := newTestEnv(t)
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	files := map[string]string{
+		"ABC.sdProfile/manifest.json": `{"Name": "Show", "Device": {"Model": "20GAI9901"}, "Actions": {
+			"0,0": {"UUID": "com.elgato.obsstudio.scene", "Settings": {"sceneId": "Intro"}, "States": [{"Title": "Intro"}]},
+			"1,0": {"UUID": "com.example.lights", "Name": "Lights"},
+			"2,1": {"UUID": "com.elgato.streamdeck.profile.openchild", "Settings": {"ProfileUUID": "DEF"}, "States": [{"Title": "More"}]}}}`,
+		"ABC.sdProfile/Profiles/DEF/manifest.json": `{"Actions": {
+			"0,0": {"UUID": "com.elgato.streamdeck.profile.backtoparent"},
+			"1,0": {"UUID": "com.elgato.obsstudio.record", "States": [{"Title": "Rec"}]}}}`,
+	}
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+
+	importProfile := func(name string, opts models.BundleImportOptions, body []byte) (*models.ProfileImportReport, error) {
+		profile, err := storage.ReadProfile(name, body)
+		if err != nil {
+			return nil, err
+		}
+		return env.configManager.ImportProfile(profile, opts)
+	}
+
+	report, err := importProfile("show.streamDeckProfile", models.BundleImportOptions{DryRun: true}, archive.Bytes())
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(report.Unmapped) != 1 || report.Unmapped[0].ActionID != "com.example.lights" {
+		t.Errorf("unmapped = %+v, want only the lights key", report.Unmapped)
+	}
+	if got := len(env.configManager.List()); got != 1 {
+		t.Fatalf("dry run saved configurations: %d", got)
+	}
+
+	report, err = importProfile("show.streamDeckProfile", models.BundleImportOptions{}, archive.Bytes())
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Format != models.ProfileFormatStreamDeck || len(report.Import.Configurations) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	cfg, err := env.configManager.Get(report.Import.Configurations[0].ID)
+	if err != nil {
+		t.Fatalf("get imported configuration: %v", err)
+	}
+	if cfg.Name != "Show" || cfg.Grid != (models.GridConfig{Rows: 2, Cols: 3}) || len(cfg.Pages) != 2 {
+		t.Errorf("configuration = %q grid %+v pages %+v", cfg.Name, cfg.Grid, cfg.Pages)
+	}
+
+	want := map[string]models.ButtonAction{
+		models.PositionKey(0, 0, 0): {Type: "switch_scene", Params: map[string]interface{}{"scene_name": "Intro"}},
+		models.PositionKey(0, 1, 2): {Type: "folder", Params: map[string]interface{}{"page": float64(2)}},
+		models.PositionKey(1, 0, 0): {Type: "page_back"},
+		models.PositionKey(1, 0, 1): {Type: "toggle_record"},
+	}
+	if len(cfg.Buttons) != len(want) {
+		t.Errorf("placements = %v, want %d", cfg.Buttons, len(want))
+	}
+	for position, action := range want {
+		btn, err := env.buttonManager.Get(cfg.Buttons[position])
+		if err != nil {
+			t.Errorf("%s: %v", position, err)
+			continue
+		}
+		got, _ := json.Marshal(btn.Action)
+		wantJSON, _ := json.Marshal(action)
+		if string(got) != string(wantJSON) {
+			t.Errorf("%s: action %s, want %s", position, got, wantJSON)
+		}
+	}
+
+	streamPi := []byte(`{"name": "Pi", "rows": 2, "cols": 2, "actions": [
+		{"id": "a", "pluginId": "com.stream_pi.obssuite.setmute", "displayText": "Mic",
+		 "row": 0, "col": 1, "properties": {"source": "Mic/Aux", "state": "false"}}]}`)
+	report, err = importProfile("", models.BundleImportOptions{}, streamPi)
+	if err != nil {
+		t.Fatalf("import Stream-Pi profile: %v", err)
+	}
+	cfg, err = env.configManager.Get(report.Import.Configurations[0].ID)
+	if err != nil {
+		t.Fatalf("get Stream-Pi configuration: %v", err)
+	}
+	btn, err := env.buttonManager.Get(cfg.Buttons[models.PositionKey(0, 0, 1)])
+	if err != nil || btn.Action.Type != "unmute_input" || btn.Action.Params["input_name"] != "Mic/Aux" {
+		t.Errorf("Stream-Pi button = %+v (%v)", btn, err)
+	}
+
+	if _, err := importProfile("", models.BundleImportOptions{}, []byte("hello")); err == nil {
+		t.Error("unknown format imported without error")
+	}
+
+	// Profiles are only imported through the app
+	code := env.request(t, "POST", "/api/profiles/import", env.token, "", nil, nil)
+	if code != http.StatusNotFound && code != http.StatusMethodNotAllowed {
+		t.Errorf("import over the API: status %d", code)
+	}
+}
+
+func TestButtonSearch(t *testing.T) {
+	env := newTestEnv(t)
+
+	buttons := []*models.Button{
+		{Name: "Intro Scene", Category: " Scenes ", Tags: []string{"show", "Show", " "},
+			Action: models.ButtonAction{Type: "switch_scene", Params: map[string]interface{}{"scene_name": "Intro"}}},
+		{Name: "mute mic", Description: "Silence the host", Tags: []string{"audio", "show"},
+			Action: models.ButtonAction{Type: "toggle_input_mute", Params: map[string]interface{}{"input_name": "Mic"}}},
+		{Name: "Open", Tags: []string{"show"},
+			Action: models.ButtonAction{Type: "macro", Params: map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"action": map[string]interface{}{
+					"type": "switch_scene", "params": map[string]interface{}{"scene_name": "Intro"}}},
+			}}}},
+	}
+	for _, btn := range buttons {
+		if err := env.buttonManager.Create(btn); err != nil {
+			t.Fatalf("create %s: %v", btn.Name, err)
+		}
+	}
+
+	saved, _ := env.buttonManager.Get(buttons[0].ID)
+	if saved.Category != "Scenes" || len(saved.Tags) != 1 || saved.Tags[0] != "show" {
+		t.Errorf("labels saved as %q %q, want trimmed and deduplicated", saved.Category, saved.Tags)
+	}
+
+	search := func(query string) []string {
+		t.Helper()
+		var result models.ButtonSearchResult
+		if status := env.do(t, "GET", "/api/buttons?"+query, "", nil, &result); status != http.StatusOK {
+			t.Fatalf("search %q: status %d", query, status)
+		}
+		names := make([]string, 0, len(result.Buttons))
+		for _, btn := range result.Buttons {
+			names = append(names, btn.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "Intro Scene,mute mic,Open,Stream"},
+		{"order=desc", "Stream,Open,mute mic,Intro Scene"},
+		{"q=HOST", "mute mic"},
+		{"action=switch_scene", "Intro Scene"},
+		{"category=scenes", "Intro Scene"},
+		{"tag=SHOW&tag=audio", "mute mic"},
+		{"scene=Intro", "Intro Scene,Open"},
+		{"input=Mic", "mute mic"},
+		{"sort=action", "Open,Intro Scene,mute mic,Stream"},
+		{"offset=1&limit=2", "mute mic,Open"},
+		{"offset=9", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(search(tt.query), ","); got != tt.want {
+			t.Errorf("search %q = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	if status := env.do(t, "GET", "/api/buttons?sort=color", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown sort: status %d, want 400", status)
+	}
+}
+
+// TestButtonUsage checks that presses are counted against the pressed button
+// and that usage lists where buttons are placed
+func TestButtonUsage(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "deck")
+
+	spare := &models.Button{Name: "Spare", Action: models.ButtonAction{Type: "stop_stream"}}
+	if err := env.buttonManager.Create(spare); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	streamID := env.defaultConfig.Buttons[models.PositionKey(0, 0, 0)]
+
+	// By position, then by action alone
+	press := map[string]interface{}{"type": "toggle_stream", "button": models.PositionKey(0, 0, 0)}
+	env.do(t, "POST", "/api/action", sessionID, press, nil)
+	env.do(t, "POST", "/api/action", sessionID, models.ButtonAction{Type: "toggle_stream"}, nil)
+
+	var usage models.ButtonUsage
+	if status := env.do(t, "GET", "/api/buttons/"+streamID+"/usage", "", nil, &usage); status != http.StatusOK {
+		t.Fatalf("usage: status %d", status)
+	}
+	if usage.PressCount != 2 || usage.LastPressedAt == nil {
+		t.Errorf("press count %d, last pressed %v, want 2 and a time", usage.PressCount, usage.LastPressedAt)
+	}
+	if len(usage.Configurations) != 1 || usage.Configurations[0].ID != env.defaultConfig.ID ||
+		len(usage.Configurations[0].Positions) != 1 {
+		t.Errorf("configurations = %+v, want the default at one position", usage.Configurations)
+	}
+
+	var unused []models.ButtonUsage
+	env.do(t, "GET", "/api/buttons/usage?unused=true", "", nil, &unused)
+	if len(unused) != 1 || unused[0].ID != spare.ID {
+		t.Errorf("unused = %+v, want only %s", unused, spare.Name)
+	}
+
+	if status := env.do(t, "GET", "/api/buttons/missing/usage", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown button: status %d, want 404", status)
+	}
+}
+
+// fakeObjects lists a fixed set of OBS scenes and inputs
+type fakeObjects struct {
+	scenes []string
+	inputs []string
+}
+
+func (f *fakeObjects) GetScenes() ([]string, error) { return f.scenes, nil }
+func (f *fakeObjects) GetInputs() ([]string, error) { return f.inputs, nil }
+
+// TestButtonValidation checks that references to missing scenes and inputs
+// are reported and flagged in resolved configurations, and that renames
+// rewrite them
+func TestButtonValidation(t *testing.T) {
+	env := newTestEnv(t)
+	env.configManager.SetObjectLister(&fakeObjects{scenes: []string{"Live"}, inputs: []string{"Mic"}})
+
+	if status := env.do(t, "GET", "/api/buttons/validation", "", nil, nil); status != http.StatusOK {
+		t.Fatalf("validation: status %d", status)
+	}
+
+	intro := &models.Button{
+		Name: "Intro",
+		Action: models.ButtonAction{Type: "macro", Params: map[string]interface{}{"steps": []interface{}{
+			map[string]interface{}{"action": map[string]interface{}{
+				"type": "switch_scene", "params": map[string]interface{}{"scene_name": "Main"}}},
+			map[string]interface{}{"action": map[string]interface{}{
+				"type": "mute_input", "params": map[string]interface{}{"input_name": "Mic"}}},
+		}}},
+	}
+	if err := env.buttonManager.Create(intro); err != nil {
+		t.Fatalf("create button: %v", err)
+	}
+	cfg, _ := env.configManager.Get(env.defaultConfig.ID)
+	cfg.Buttons[models.PositionKey(0, 0, 1)] = intro.ID
+	if err := env.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+
+	var report models.ValidationReport
+	env.do(t, "GET", "/api/buttons/validation", "", nil, &report)
+	if report.Checked != 2 || len(report.Broken) != 1 || report.Broken[0].ID != intro.ID ||
+		len(report.Broken[0].Issues) != 1 || report.Broken[0].Issues[0].Name != "Main" {
+		t.Errorf("report = %+v, want Intro broken by scene Main", report)
+	}
+
+	var resolved models.ResolvedConfiguration
+	env.do(t, "GET", "/api/configurations/"+cfg.ID, "", nil, &resolved)
+	for _, btn := range resolved.Buttons {
+		if want := btn.ID == models.PositionKey(0, 0, 1); btn.Broken != want {
+			t.Errorf("button %s broken = %v, want %v", btn.ID, btn.Broken, want)
+		}
+	}
+
+	count, err := env.buttonManager.RenameScene("Main", "Live")
+	if err != nil || count != 1 {
+		t.Fatalf("rename: %d buttons (%v), want 1", count, err)
+	}
+	env.do(t, "GET", "/api/buttons/validation", "", nil, &report)
+	if len(report.Broken) != 0 {
+		t.Errorf("after rename broken = %+v, want none", report.Broken)
+	}
+}
+
+// TestMacroPermissions checks that allowlisting macro doesn't allow the
+// steps of a macro the configuration wouldn't allow on their own
+func TestMacroPermissions(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "guest")
+
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	cfg.AllowedActions = []string{"macro"}
+	if err := env.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+
+	macro := func(types ...string) models.ButtonAction {
+		steps := make([]interface{}, len(types))
+		for i, actionType := range types {
+			steps[i] = map[string]interface{}{"action": map[string]interface{}{"type": actionType}}
+		}
+		return models.ButtonAction{Type: "macro", Params: map[string]interface{}{"steps": steps}}
+	}
+	permitted := func(action models.ButtonAction) bool {
+		return env.do(t, "POST", "/api/action", sessionID, action, nil) != http.StatusForbidden
+	}
+
+	if !permitted(macro("toggle_stream")) {
+		t.Error("macro of button actions denied")
+	}
+	if permitted(macro("toggle_stream", "stop_stream")) {
+		t.Error("macro with an action not in configuration permitted")
+	}
+	if permitted(models.ButtonAction{Type: "macro", Params: map[string]interface{}{
+		"steps": []interface{}{map[string]interface{}{"action": macro("stop_stream")}},
+	}}) {
+		t.Error("nested macro with an action not in configuration permitted")
+	}
+}
+
+// TestSwitchConfigurationPermission checks that a session only moves itself
+// to another configuration when its current one allows it
+func TestSwitchConfigurationPermission(t *testing.T) {
+	env := newTestEnv(t)
+	sessionID := env.register(t, "guest")
+
+	other := &models.Configuration{Name: "Studio", Grid: models.GridConfig{Rows: 2, Cols: 2}}
+	if err := env.configManager.Create(other); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+
+	if code := env.do(t, "PUT", "/api/client/config/"+env.defaultConfig.ID, sessionID, nil, nil); code != http.StatusOK {
+		t.Errorf("switch to current configuration: status %d", code)
+	}
+	if code := env.do(t, "PUT", "/api/client/config/"+other.ID, sessionID, nil, nil); code != http.StatusForbidden {
+		t.Errorf("switch without permission: status %d, want %d", code, http.StatusForbidden)
+	}
+	if session, _ := env.sessionManager.Get(sessionID); session.ConfigID != env.defaultConfig.ID {
+		t.Errorf("session moved to %s", session.ConfigID)
+	}
+
+	cfg, err := env.configManager.Get(env.defaultConfig.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	cfg.AllowSwitching = true
+	if err := env.configManager.Update(cfg); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+	if code := env.do(t, "PUT", "/api/client/config/"+other.ID, sessionID, nil, nil); code != http.StatusOK {
+		t.Errorf("switch with permission: status %d", code)
+	}
+}
+
+// TestConfigurationPages checks that buttons on several pages resolve to
+// their pages, and that saving normalizes legacy keys and missing pages
+func TestConfigurationPages(t *testing.T) {
+	env := newTestEnv(t)
+
+	ids := make([]string, 3)
+	for i := range ids {
+		btn := &models.Button{Name: fmt.Sprintf("Button %d", i), Action: models.ButtonAction{Type: "toggle_stream"}}
+		if err := env.buttonManager.Create(btn); err != nil {
+			t.Fatalf("create button: %v", err)
+		}
+		ids[i] = btn.ID
+	}
+	cfg := &models.Configuration{
+		Name: "Paged",
+		Grid: models.GridConfig{Rows: 2, Cols: 2},
+		Buttons: map[string]string{
+			"btn-1-0":     ids[0], // Written before pages existed
+			"btn-2-0-1":   ids[1], // On a page that isn't listed
+			"btn-1-1-1":   ids[2],
+			"not-a-place": ids[0],
+		},
+		Pages: []models.Page{{Name: "Home"}},
+	}
+	if err := env.configManager.Create(cfg); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+
+	saved, err := env.configManager.Get(cfg.ID)
+	if err != nil {
+		t.Fatalf("get configuration: %v", err)
+	}
+	if _, ok := saved.Buttons["btn-1-0"]; ok || saved.Buttons["btn-0-1-0"] != ids[0] {
+		t.Errorf("buttons = %v, want btn-1-0 saved as btn-0-1-0", saved.Buttons)
+	}
+	names := make([]string, len(saved.Pages))
+	for i, page := range saved.Pages {
+		names[i] = page.Name
+	}
+	if got := strings.Join(names, ","); got != "Home,Page 2,Page 3" {
+		t.Errorf("pages = %s, want Home,Page 2,Page 3", got)
+	}
+
+	var resolved models.ResolvedConfiguration
+	if code := env.do(t, "GET", "/api/configurations/"+cfg.ID, "", nil, &resolved); code != http.StatusOK {
+		t.Fatalf("get resolved configuration: status %d", code)
+	}
+	if len(resolved.Pages) != 3 {
+		t.Fatalf("resolved %d pages, want 3", len(resolved.Pages))
+	}
+	want := []struct {
+		id       string
+		row, col int
+		text     string
+	}{
+		{"btn-0-1-0", 1, 0, "Button 0"},
+		{"btn-1-1-1", 1, 1, "Button 2"},
+		{"btn-2-0-1", 0, 1, "Button 1"},
+	}
+	for i, page := range resolved.Pages {
+		if page.Index != i || page.Name != names[i] {
+			t.Errorf("page %d = %d %q, want %d %q", i, page.Index, page.Name, i, names[i])
+		}
+		if len(page.Buttons) != 1 {
+			t.Errorf("page %d has %d buttons, want 1", i, len(page.Buttons))
+			continue
+		}
+		btn := page.Buttons[0]
+		if btn.ID != want[i].id || btn.Page != i || btn.Row != want[i].row || btn.Col != want[i].col || btn.Text != want[i].text {
+			t.Errorf("page %d button = %+v, want %s", i, btn, want[i].id)
+		}
+	}
+	if len(resolved.Buttons) != 1 || resolved.Buttons[0].ID != "btn-0-1-0" {
+		t.Errorf("first page buttons = %+v, want only btn-0-1-0", resolved.Buttons)
+	}
+
+	// Dropping the pages brings back as many as the buttons need
+	saved.Pages = nil
+	delete(saved.Buttons, "btn-2-0-1")
+	if err := env.configManager.Update(saved); err != nil {
+		t.Fatalf("update configuration: %v", err)
+	}
+	if code := env.do(t, "GET", "/api/configurations/"+cfg.ID, "", nil, &resolved); code != http.StatusOK {
+		t.Fatalf("get resolved configuration: status %d", code)
+	}
+	if len(resolved.Pages) != 2 || resolved.Pages[0].Name != "Page 1" {
+		t.Errorf("pages after update = %+v, want Page 1 and Page 2", resolved.Pages)
+	}
+
+	// An empty configuration still has a page
+	empty := &models.Configuration{Name: "Empty", Grid: models.GridConfig{Rows: 1, Cols: 1}}
+	if err := env.configManager.Create(empty); err != nil {
+		t.Fatalf("create configuration: %v", err)
+	}
+	if code := env.do(t, "GET", "/api/configurations/"+empty.ID, "", nil, &resolved); code != http.StatusOK {
+		t.Fatalf("get resolved configuration: status %d", code)
+	}
+	if len(resolved.Pages) != 1 || resolved.Buttons == nil {
+		t.Errorf("empty configuration = %+v, want one empty page", resolved)
+	}
+}
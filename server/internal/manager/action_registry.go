@@ -0,0 +1,245 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// ParamType is the kind of value an action parameter holds
+type ParamType string
+
+const (
+	ParamString ParamType = "string"
+	ParamNumber ParamType = "number"
+	ParamBool   ParamType = "boolean"
+	ParamScene  ParamType = "scene"  // name of an OBS scene
+	ParamInput  ParamType = "input"  // name of an OBS input
+	ParamSource ParamType = "source" // name of an OBS source within a scene
+	ParamSteps  ParamType = "steps"  // list of macro steps
+	ParamPage   ParamType = "page"   // page number within the configuration
+)
+
+// ParamSpec describes one parameter of an action
+type ParamSpec struct {
+	Name        string    `json:"name"`
+	Type        ParamType `json:"type"`
+	Required    bool      `json:"required"`
+	Description string    `json:"description,omitempty"`
+	Options     []string  `json:"options,omitempty"` // allowed values, if restricted
+}
+
+// ActionSpec describes an action type for the /api/actions catalog
+type ActionSpec struct {
+	Name        string      `json:"name"`
+	Label       string      `json:"label"`
+	Category    string      `json:"category"`
+	Description string      `json:"description"`
+	Params      []ParamSpec `json:"params"`
+	ClientSide  bool        `json:"client_side,omitempty"` // handled by the client, never executed here
+}
+
+// ActionHandler executes one action type
+type ActionHandler interface {
+	// Spec describes the action and its parameters
+	Spec() ActionSpec
+
+	// Execute runs the action and returns optional result data for the
+	// caller. Params have already been validated against Spec.
+	Execute(client *goobs.Client, params ActionParams) (interface{}, error)
+}
+
+// paramsValidator is implemented by handlers whose params need checks beyond
+// the schema in their Spec
+type paramsValidator interface {
+	ValidateParams(params ActionParams) error
+}
+
+// ActionParams wraps a ButtonAction's params with typed accessors
+type ActionParams map[string]interface{}
+
+// String returns a string parameter, or "" if missing
+func (p ActionParams) String(name string) string {
+	s, _ := p[name].(string)
+	return s
+}
+
+// Float returns a numeric parameter and whether it was set
+func (p ActionParams) Float(name string) (float64, bool) {
+	switch v := p[name].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Bool returns a boolean parameter and whether it was set
+func (p ActionParams) Bool(name string) (bool, bool) {
+	b, ok := p[name].(bool)
+	return b, ok
+}
+
+// Validate checks params against the spec's parameter schema
+func (spec ActionSpec) Validate(params ActionParams) error {
+	for _, param := range spec.Params {
+		value, present := params[param.Name]
+		if !present || value == nil {
+			if param.Required {
+				return fmt.Errorf("missing %s parameter", param.Name)
+			}
+			continue
+		}
+
+		switch param.Type {
+		case ParamNumber, ParamPage:
+			if _, ok := params.Float(param.Name); !ok {
+				return fmt.Errorf("parameter %s must be a number", param.Name)
+			}
+		case ParamBool:
+			if _, ok := params.Bool(param.Name); !ok {
+				return fmt.Errorf("parameter %s must be a boolean", param.Name)
+			}
+		case ParamSteps:
+			if _, ok := value.([]interface{}); !ok {
+				return fmt.Errorf("parameter %s must be a list", param.Name)
+			}
+		default:
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("parameter %s must be a string", param.Name)
+			}
+			if s == "" && param.Required {
+				return fmt.Errorf("missing %s parameter", param.Name)
+			}
+			if len(param.Options) > 0 && s != "" && !containsString(param.Options, s) {
+				return fmt.Errorf("parameter %s must be one of %v", param.Name, param.Options)
+			}
+		}
+	}
+	return nil
+}
+
+// ActionRegistry maps action type names to their handlers
+type ActionRegistry struct {
+	handlers map[string]ActionHandler
+	mu       sync.RWMutex
+}
+
+// NewActionRegistry creates an empty ActionRegistry
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		handlers: make(map[string]ActionHandler),
+	}
+}
+
+// Register adds a handler under its spec name
+func (r *ActionRegistry) Register(handler ActionHandler) error {
+	name := handler.Spec().Name
+	if name == "" {
+		return fmt.Errorf("action handler has no name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[name]; exists {
+		return fmt.Errorf("action already registered: %s", name)
+	}
+	r.handlers[name] = handler
+	return nil
+}
+
+// Get returns the handler for an action type
+func (r *ActionRegistry) Get(name string) (ActionHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
+
+// Catalog returns the specs of all registered actions, sorted by category and name
+func (r *ActionRegistry) Catalog() []ActionSpec {
+	r.mu.RLock()
+	specs := make([]ActionSpec, 0, len(r.handlers))
+	for _, handler := range r.handlers {
+		specs = append(specs, handler.Spec())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].Category != specs[j].Category {
+			return specs[i].Category < specs[j].Category
+		}
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// Validate resolves an action's handler and checks its params
+func (r *ActionRegistry) Validate(action models.ButtonAction) (ActionHandler, error) {
+	handler, ok := r.Get(action.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown action type: %s", action.Type)
+	}
+	if err := handler.Spec().Validate(action.Params); err != nil {
+		return nil, err
+	}
+	if v, ok := handler.(paramsValidator); ok {
+		if err := v.ValidateParams(action.Params); err != nil {
+			return nil, err
+		}
+	}
+	return handler, nil
+}
+
+// actionFunc adapts a plain function to ActionHandler
+type actionFunc struct {
+	spec ActionSpec
+	run  func(client *goobs.Client, params ActionParams) error
+}
+
+func (a *actionFunc) Spec() ActionSpec { return a.spec }
+
+func (a *actionFunc) Execute(client *goobs.Client, params ActionParams) (interface{}, error) {
+	return nil, a.run(client, params)
+}
+
+// resultAction is a handler that may check its params beyond their specs
+// and may return data, such as the volume it set
+type resultAction struct {
+	spec     ActionSpec
+	validate func(params ActionParams) error // optional
+	run      func(client *goobs.Client, params ActionParams) (interface{}, error)
+}
+
+func (a *resultAction) Spec() ActionSpec { return a.spec }
+
+func (a *resultAction) ValidateParams(params ActionParams) error {
+	if a.validate == nil {
+		return nil
+	}
+	return a.validate(params)
+}
+
+func (a *resultAction) Execute(client *goobs.Client, params ActionParams) (interface{}, error) {
+	return a.run(client, params)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+)
+
+// Action categories used to group the catalog
+const (
+	CategoryScenes       = "scenes"
+	CategoryStreaming    = "streaming"
+	CategoryRecording    = "recording"
+	CategoryAudio        = "audio"
+	CategoryVirtualCam   = "virtualcam"
+	CategoryReplayBuffer = "replay_buffer"
+)
+
+// Parameters shared by several actions
+var (
+	sceneNameParam = ParamSpec{Name: "scene_name", Type: ParamScene, Required: true, Description: "Name of the OBS scene"}
+	inputNameParam = ParamSpec{Name: "input_name", Type: ParamInput, Required: true, Description: "Name of the OBS audio input"}
+)
+
+// builtinActions returns handlers for the core OBS actions. switch_scene
+// may override the transition through overrides.
+func builtinActions(overrides *transitionOverrides) []ActionHandler {
+	return []ActionHandler{
+		// Scenes
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "switch_scene",
+				Label:       "Switch Scene",
+				Category:    CategoryScenes,
+				Description: "Switch the program output to a scene",
+				Params:      []ParamSpec{sceneNameParam, overrideNameParam, overrideMsParam},
+			},
+			validate: transitionMsValidator("transition_duration_ms"),
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				sceneName := params.String("scene_name")
+				return nil, overrides.run(client, params, func() error {
+					_, err := client.Scenes.SetCurrentProgramScene(&scenes.SetCurrentProgramSceneParams{
+						SceneName: &sceneName,
+					})
+					return err
+				})
+			},
+		},
+
+		// Streaming
+		simpleAction("start_stream", "Start Stream", CategoryStreaming, "Start streaming", func(client *goobs.Client) error {
+			_, err := client.Stream.StartStream()
+			return err
+		}),
+		simpleAction("stop_stream", "Stop Stream", CategoryStreaming, "Stop streaming", func(client *goobs.Client) error {
+			_, err := client.Stream.StopStream()
+			return err
+		}),
+		simpleAction("toggle_stream", "Toggle Stream", CategoryStreaming, "Start or stop streaming", func(client *goobs.Client) error {
+			_, err := client.Stream.ToggleStream()
+			return err
+		}),
+
+		// Recording
+		simpleAction("start_record", "Start Recording", CategoryRecording, "Start recording", func(client *goobs.Client) error {
+			_, err := client.Record.StartRecord()
+			return err
+		}),
+		simpleAction("stop_record", "Stop Recording", CategoryRecording, "Stop recording", func(client *goobs.Client) error {
+			_, err := client.Record.StopRecord()
+			return err
+		}),
+		simpleAction("toggle_record", "Toggle Recording", CategoryRecording, "Start or stop recording", func(client *goobs.Client) error {
+			_, err := client.Record.ToggleRecord()
+			return err
+		}),
+		simpleAction("pause_record", "Pause Recording", CategoryRecording, "Pause the active recording", func(client *goobs.Client) error {
+			_, err := client.Record.PauseRecord()
+			return err
+		}),
+		simpleAction("resume_record", "Resume Recording", CategoryRecording, "Resume a paused recording", func(client *goobs.Client) error {
+			_, err := client.Record.ResumeRecord()
+			return err
+		}),
+
+		// Virtual camera
+		simpleAction("start_virtualcam", "Start Virtual Camera", CategoryVirtualCam, "Start the virtual camera", func(client *goobs.Client) error {
+			_, err := client.Outputs.StartVirtualCam()
+			return err
+		}),
+		simpleAction("stop_virtualcam", "Stop Virtual Camera", CategoryVirtualCam, "Stop the virtual camera", func(client *goobs.Client) error {
+			_, err := client.Outputs.StopVirtualCam()
+			return err
+		}),
+		simpleAction("toggle_virtualcam", "Toggle Virtual Camera", CategoryVirtualCam, "Start or stop the virtual camera", func(client *goobs.Client) error {
+			_, err := client.Outputs.ToggleVirtualCam()
+			return err
+		}),
+
+		// Replay buffer
+		simpleAction("start_replay_buffer", "Start Replay Buffer", CategoryReplayBuffer, "Start the replay buffer", func(client *goobs.Client) error {
+			_, err := client.Outputs.StartReplayBuffer()
+			return err
+		}),
+		simpleAction("stop_replay_buffer", "Stop Replay Buffer", CategoryReplayBuffer, "Stop the replay buffer", func(client *goobs.Client) error {
+			_, err := client.Outputs.StopReplayBuffer()
+			return err
+		}),
+		simpleAction("toggle_replay_buffer", "Toggle Replay Buffer", CategoryReplayBuffer, "Start or stop the replay buffer", func(client *goobs.Client) error {
+			_, err := client.Outputs.ToggleReplayBuffer()
+			return err
+		}),
+		simpleAction("save_replay_buffer", "Save Replay", CategoryReplayBuffer, "Save the replay buffer to a file", func(client *goobs.Client) error {
+			_, err := client.Outputs.SaveReplayBuffer()
+			return err
+		}),
+
+		// Audio
+		inputAction("toggle_input_mute", "Toggle Input Mute", "Mute or unmute an input", func(client *goobs.Client, inputName string) error {
+			_, err := client.Inputs.ToggleInputMute(&inputs.ToggleInputMuteParams{
+				InputName: &inputName,
+			})
+			return err
+		}),
+		inputAction("mute_input", "Mute Input", "Mute an input", func(client *goobs.Client, inputName string) error {
+			return setInputMute(client, inputName, true)
+		}),
+		inputAction("unmute_input", "Unmute Input", "Unmute an input", func(client *goobs.Client, inputName string) error {
+			return setInputMute(client, inputName, false)
+		}),
+	}
+}
+
+// simpleAction builds a handler for an action without parameters
+func simpleAction(name, label, category, description string, run func(client *goobs.Client) error) ActionHandler {
+	return &actionFunc{
+		spec: ActionSpec{
+			Name:        name,
+			Label:       label,
+			Category:    category,
+			Description: description,
+			Params:      []ParamSpec{},
+		},
+		run: func(client *goobs.Client, params ActionParams) error {
+			return run(client)
+		},
+	}
+}
+
+// inputAction builds a handler for an audio action taking input_name
+func inputAction(name, label, description string, run func(client *goobs.Client, inputName string) error) ActionHandler {
+	return &actionFunc{
+		spec: ActionSpec{
+			Name:        name,
+			Label:       label,
+			Category:    CategoryAudio,
+			Description: description,
+			Params:      []ParamSpec{inputNameParam},
+		},
+		run: func(client *goobs.Client, params ActionParams) error {
+			return run(client, params.String("input_name"))
+		},
+	}
+}
+
+// setInputMute sets the mute state of an input
+func setInputMute(client *goobs.Client, inputName string, muted bool) error {
+	_, err := client.Inputs.SetInputMute(&inputs.SetInputMuteParams{
+		InputName:  &inputName,
+		InputMuted: &muted,
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+const (
+	// CategoryMacros groups multi-step actions in the catalog
+	CategoryMacros = "macros"
+
+	// Macro error policies
+	MacroStopOnError     = "stop"
+	MacroContinueOnError = "continue"
+
+	// Limits that keep a mistyped macro from tying up the server
+	maxMacroSteps = 50
+	maxMacroDepth = 4
+	maxMacroDelay = 5 * time.Minute
+)
+
+// macroAction runs an ordered list of child actions, each after an optional
+// delay. Steps are executed through the manager rather than the client passed
+// to Execute, so a macro keeps going across an OBS reconnect.
+type macroAction struct {
+	om *OBSManager
+}
+
+func (m *macroAction) Spec() ActionSpec {
+	return ActionSpec{
+		Name:        "macro",
+		Label:       "Macro",
+		Category:    CategoryMacros,
+		Description: "Run several actions in order, with optional delays between them",
+		Params: []ParamSpec{
+			{Name: "steps", Type: ParamSteps, Required: true, Description: "Actions to run, each with an optional delay_ms"},
+			{Name: "on_error", Type: ParamString, Description: "What to do when a step fails (default stop)",
+				Options: []string{MacroStopOnError, MacroContinueOnError}},
+		},
+	}
+}
+
+// ValidateParams checks every step up front so a bad step doesn't leave OBS
+// half way through a macro
+func (m *macroAction) ValidateParams(params ActionParams) error {
+	return m.validate(params, 1)
+}
+
+func (m *macroAction) validate(params ActionParams, depth int) error {
+	if depth > maxMacroDepth {
+		return fmt.Errorf("macros nested more than %d deep", maxMacroDepth)
+	}
+
+	steps, err := parseMacroSteps(params["steps"])
+	if err != nil {
+		return err
+	}
+
+	for i, step := range steps {
+		if step.DelayMs < 0 || time.Duration(step.DelayMs)*time.Millisecond > maxMacroDelay {
+			return fmt.Errorf("step %d: delay_ms must be between 0 and %d", i+1, maxMacroDelay.Milliseconds())
+		}
+
+		handler, ok := m.om.actions.Get(step.Action.Type)
+		if !ok {
+			return fmt.Errorf("step %d: unknown action type: %s", i+1, step.Action.Type)
+		}
+		if handler.Spec().ClientSide {
+			return fmt.Errorf("step %d: %s can't be used in a macro", i+1, step.Action.Type)
+		}
+		if err := handler.Spec().Validate(step.Action.Params); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		// Recurse ourselves to keep track of the depth
+		if child, ok := handler.(*macroAction); ok {
+			err = child.validate(step.Action.Params, depth+1)
+		} else if v, ok := handler.(paramsValidator); ok {
+			err = v.ValidateParams(step.Action.Params)
+		}
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Execute runs the steps and returns a models.MacroStepResult per step. With
+// the stop policy, steps after a failure are reported as skipped and the
+// failure is returned as the error.
+func (m *macroAction) Execute(client *goobs.Client, params ActionParams) (interface{}, error) {
+	steps, err := parseMacroSteps(params["steps"])
+	if err != nil {
+		return nil, err
+	}
+	continueOnError := params.String("on_error") == MacroContinueOnError
+
+	results := make([]models.MacroStepResult, len(steps))
+	var firstErr error
+
+	for i, step := range steps {
+		results[i] = models.MacroStepResult{
+			Step: i + 1,
+			Type: step.Action.Type,
+		}
+
+		if firstErr != nil && !continueOnError {
+			results[i].Status = "skipped"
+			continue
+		}
+
+		if step.DelayMs > 0 {
+			time.Sleep(time.Duration(step.DelayMs) * time.Millisecond)
+		}
+
+		result, err := m.om.ExecuteAction(step.Action)
+		if err != nil {
+			log.Printf("⚠️  Macro step %d (%s) failed: %v", i+1, step.Action.Type, err)
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("macro step %d (%s) failed: %w", i+1, step.Action.Type, err)
+			}
+			continue
+		}
+
+		results[i].Status = "ok"
+		results[i].Result = result
+	}
+
+	if continueOnError {
+		return results, nil
+	}
+	return results, firstErr
+}
+
+// parseMacroSteps converts the decoded JSON steps param into MacroSteps
+func parseMacroSteps(value interface{}) ([]models.MacroStep, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid steps parameter: %w", err)
+	}
+
+	var steps []models.MacroStep
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		return nil, fmt.Errorf("invalid steps parameter: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("macro has no steps")
+	}
+	if len(steps) > maxMacroSteps {
+		return nil, fmt.Errorf("macro has more than %d steps", maxMacroSteps)
+	}
+	return steps, nil
+}
@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/requests/mediainputs"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// CategoryMedia holds playback actions on media inputs
+const CategoryMedia = "media"
+
+// Media actions as OBS names them
+const (
+	mediaActionPlay     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PLAY"
+	mediaActionPause    = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PAUSE"
+	mediaActionStop     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_STOP"
+	mediaActionRestart  = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_RESTART"
+	mediaActionNext     = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NEXT"
+	mediaActionPrevious = "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PREVIOUS"
+)
+
+var mediaInputParam = ParamSpec{Name: "input_name", Type: ParamInput, Required: true, Description: "Name of the OBS media source"}
+
+// mediaActions returns handlers for controlling media playback and reading
+// a media input's status
+func mediaActions() []ActionHandler {
+	return []ActionHandler{
+		mediaTrigger("media_play", "Play Media", "Play or resume a media source", mediaActionPlay),
+		mediaTrigger("media_pause", "Pause Media", "Pause a media source", mediaActionPause),
+		mediaTrigger("media_restart", "Restart Media", "Play a media source from the start", mediaActionRestart),
+		mediaTrigger("media_stop", "Stop Media", "Stop a media source", mediaActionStop),
+		mediaTrigger("media_next", "Next Media", "Skip to the next item of a playlist source", mediaActionNext),
+		mediaTrigger("media_previous", "Previous Media", "Go back to the previous item of a playlist source", mediaActionPrevious),
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "media_toggle",
+				Label:       "Play/Pause Media",
+				Category:    CategoryMedia,
+				Description: "Pause a playing media source, or play it from where it is",
+				Params:      []ParamSpec{mediaInputParam},
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				status, err := mediaStatus(client, input)
+				if err != nil {
+					return nil, err
+				}
+				switch status.State {
+				case models.MediaPlaying:
+					return nil, triggerMedia(client, input, mediaActionPause)
+				case models.MediaPaused:
+					return nil, triggerMedia(client, input, mediaActionPlay)
+				default:
+					return nil, triggerMedia(client, input, mediaActionRestart)
+				}
+			},
+		},
+
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "media_seek",
+				Label:       "Seek Media",
+				Category:    CategoryMedia,
+				Description: "Move a playing or paused media source to a time",
+				Params: []ParamSpec{
+					mediaInputParam,
+					{Name: "position_ms", Type: ParamNumber, Required: true, Description: "Time from the start in milliseconds"},
+				},
+			},
+			validate: func(params ActionParams) error {
+				if ms, _ := params.Float("position_ms"); ms < 0 {
+					return fmt.Errorf("position_ms can't be negative")
+				}
+				return nil
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				ms, _ := params.Float("position_ms")
+				_, err := client.MediaInputs.SetMediaInputCursor(&mediainputs.SetMediaInputCursorParams{
+					InputName:   &input,
+					MediaCursor: &ms,
+				})
+				return nil, err
+			},
+		},
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "media_offset",
+				Label:       "Skip Media",
+				Category:    CategoryMedia,
+				Description: "Jump a playing or paused media source forward or back",
+				Params: []ParamSpec{
+					mediaInputParam,
+					{Name: "offset_ms", Type: ParamNumber, Required: true, Description: "Milliseconds to jump; negative to go back"},
+				},
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				ms, _ := params.Float("offset_ms")
+				_, err := client.MediaInputs.OffsetMediaInputCursor(&mediainputs.OffsetMediaInputCursorParams{
+					InputName:         &input,
+					MediaCursorOffset: &ms,
+				})
+				return nil, err
+			},
+		},
+
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "media_status",
+				Label:       "Media Status",
+				Category:    CategoryMedia,
+				Description: "Read the state, position and length of a media source",
+				Params:      []ParamSpec{mediaInputParam},
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				return mediaStatus(client, params.String("input_name"))
+			},
+		},
+	}
+}
+
+// mediaTrigger builds a handler sending one media action to input_name
+func mediaTrigger(name, label, description, obsAction string) ActionHandler {
+	return &actionFunc{
+		spec: ActionSpec{
+			Name:        name,
+			Label:       label,
+			Category:    CategoryMedia,
+			Description: description,
+			Params:      []ParamSpec{mediaInputParam},
+		},
+		run: func(client *goobs.Client, params ActionParams) error {
+			return triggerMedia(client, params.String("input_name"), obsAction)
+		},
+	}
+}
+
+// triggerMedia sends an OBS media action, such as mediaActionPlay
+func triggerMedia(client *goobs.Client, input, obsAction string) error {
+	_, err := client.MediaInputs.TriggerMediaInputAction(&mediainputs.TriggerMediaInputActionParams{
+		InputName:   &input,
+		MediaAction: &obsAction,
+	})
+	return err
+}
+
+// mediaStatus reads a media input's playback state
+func mediaStatus(client *goobs.Client, input string) (*models.MediaStatus, error) {
+	resp, err := client.MediaInputs.GetMediaInputStatus(&mediainputs.GetMediaInputStatusParams{InputName: &input})
+	if err != nil {
+		return nil, err
+	}
+	status := &models.MediaStatus{
+		InputName:  input,
+		State:      strings.ToLower(strings.TrimPrefix(resp.MediaState, "OBS_MEDIA_STATE_")),
+		CursorMs:   resp.MediaCursor,
+		DurationMs: resp.MediaDuration,
+	}
+	if status.State == models.MediaPlaying || status.State == models.MediaPaused {
+		// Live sources have no length
+		status.Time = formatMediaTime(status.CursorMs)
+		if status.DurationMs > 0 {
+			status.Time += " / " + formatMediaTime(status.DurationMs)
+		}
+	}
+	return status, nil
+}
+
+// mediaActionName shortens an OBS media action to "play", "pause" and so on
+func mediaActionName(action string) string {
+	return strings.ToLower(strings.TrimPrefix(action, "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_"))
+}
+
+// formatMediaTime formats milliseconds as mm:ss, or h:mm:ss from an hour
+func formatMediaTime(ms float64) string {
+	total := int(ms / 1000)
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
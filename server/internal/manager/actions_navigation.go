@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/andreykaipov/goobs"
+)
+
+// CategoryNavigation groups the page actions clients handle themselves
+const CategoryNavigation = "navigation"
+
+// navigationActions returns the page navigation actions. They are listed in
+// the catalog so editors can offer them, but clients switch pages locally and
+// the server refuses to execute them.
+func navigationActions() []ActionHandler {
+	return []ActionHandler{
+		clientAction("folder", "Open Folder", "Open another page, remembering the current one for Back",
+			ParamSpec{Name: "page", Type: ParamPage, Required: true, Description: "Page number to open, starting at 1"}),
+		clientAction("page_next", "Next Page", "Go to the next page"),
+		clientAction("page_prev", "Previous Page", "Go to the previous page"),
+		clientAction("page_back", "Back", "Return to the page a folder was opened from"),
+	}
+}
+
+// clientAction builds a catalog entry for an action the client handles
+func clientAction(name, label, description string, params ...ParamSpec) ActionHandler {
+	if params == nil {
+		params = []ParamSpec{}
+	}
+	return &actionFunc{
+		spec: ActionSpec{
+			Name:        name,
+			Label:       label,
+			Category:    CategoryNavigation,
+			Description: description,
+			Params:      params,
+			ClientSide:  true,
+		},
+		run: func(client *goobs.Client, params ActionParams) error {
+			return fmt.Errorf("%s is handled by the client", name)
+		},
+	}
+}
@@ -0,0 +1,240 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/requests/sceneitems"
+	"github.com/andreykaipov/goobs/api/typedefs"
+)
+
+// CategorySources holds actions on sources placed in scenes
+const CategorySources = "sources"
+
+// Scenes a source action applies to when scene_name is empty
+const (
+	TargetProgram = "program"
+	TargetPreview = "preview"
+)
+
+var (
+	sourceNameParam  = ParamSpec{Name: "source_name", Type: ParamSource, Required: true, Description: "Name of the source in the scene"}
+	sourceSceneParam = ParamSpec{Name: "scene_name", Type: ParamScene, Description: "Scene holding the source; empty for the current scene"}
+	targetSceneParam = ParamSpec{
+		Name:        "target_scene",
+		Type:        ParamString,
+		Description: "Current scene to use when no scene is given (default program)",
+		Options:     []string{TargetProgram, TargetPreview},
+	}
+)
+
+// transformFields are the set_source_transform params, by the
+// SceneItemTransform field each sets
+var transformFields = []struct {
+	param string
+	set   func(t *typedefs.SceneItemTransform, v float64)
+}{
+	{"position_x", func(t *typedefs.SceneItemTransform, v float64) { t.PositionX = v }},
+	{"position_y", func(t *typedefs.SceneItemTransform, v float64) { t.PositionY = v }},
+	{"scale_x", func(t *typedefs.SceneItemTransform, v float64) { t.ScaleX = v }},
+	{"scale_y", func(t *typedefs.SceneItemTransform, v float64) { t.ScaleY = v }},
+	{"rotation", func(t *typedefs.SceneItemTransform, v float64) { t.Rotation = v }},
+	{"crop_left", func(t *typedefs.SceneItemTransform, v float64) { t.CropLeft = v }},
+	{"crop_right", func(t *typedefs.SceneItemTransform, v float64) { t.CropRight = v }},
+	{"crop_top", func(t *typedefs.SceneItemTransform, v float64) { t.CropTop = v }},
+	{"crop_bottom", func(t *typedefs.SceneItemTransform, v float64) { t.CropBottom = v }},
+}
+
+// sourceActions returns handlers for showing, hiding, moving and locking
+// sources within scenes
+func sourceActions() []ActionHandler {
+	return []ActionHandler{
+		sourceAction("show_source", "Show Source", "Make a source visible in a scene", func(client *goobs.Client, item sceneItemRef) error {
+			return setSceneItemEnabled(client, item, true)
+		}),
+		sourceAction("hide_source", "Hide Source", "Hide a source in a scene", func(client *goobs.Client, item sceneItemRef) error {
+			return setSceneItemEnabled(client, item, false)
+		}),
+		sourceAction("toggle_source", "Toggle Source", "Show or hide a source in a scene", func(client *goobs.Client, item sceneItemRef) error {
+			resp, err := client.SceneItems.GetSceneItemEnabled(&sceneitems.GetSceneItemEnabledParams{
+				SceneName:   &item.scene,
+				SceneItemId: &item.id,
+			})
+			if err != nil {
+				return err
+			}
+			return setSceneItemEnabled(client, item, !resp.SceneItemEnabled)
+		}),
+
+		sourceAction("lock_source", "Lock Source", "Lock a source so it can't be moved in OBS", func(client *goobs.Client, item sceneItemRef) error {
+			return setSceneItemLocked(client, item, true)
+		}),
+		sourceAction("unlock_source", "Unlock Source", "Unlock a source", func(client *goobs.Client, item sceneItemRef) error {
+			return setSceneItemLocked(client, item, false)
+		}),
+		sourceAction("toggle_source_lock", "Toggle Source Lock", "Lock or unlock a source", func(client *goobs.Client, item sceneItemRef) error {
+			resp, err := client.SceneItems.GetSceneItemLocked(&sceneitems.GetSceneItemLockedParams{
+				SceneName:   &item.scene,
+				SceneItemId: &item.id,
+			})
+			if err != nil {
+				return err
+			}
+			return setSceneItemLocked(client, item, !resp.SceneItemLocked)
+		}),
+
+		&transformAction{},
+	}
+}
+
+// sceneItemRef identifies a scene item by its scene and numeric id
+type sceneItemRef struct {
+	scene string
+	id    int
+}
+
+// sourceAction builds a handler for an action on one scene item, found from
+// source_name and scene_name or target_scene
+func sourceAction(name, label, description string, run func(client *goobs.Client, item sceneItemRef) error) ActionHandler {
+	return &actionFunc{
+		spec: ActionSpec{
+			Name:        name,
+			Label:       label,
+			Category:    CategorySources,
+			Description: description,
+			Params:      []ParamSpec{sourceNameParam, sourceSceneParam, targetSceneParam},
+		},
+		run: func(client *goobs.Client, params ActionParams) error {
+			item, err := findSceneItem(client, params)
+			if err != nil {
+				return err
+			}
+			return run(client, item)
+		},
+	}
+}
+
+// transformAction moves, scales, rotates or crops a source. Only the
+// transform params given are changed.
+type transformAction struct{}
+
+func (a *transformAction) Spec() ActionSpec {
+	params := []ParamSpec{sourceNameParam, sourceSceneParam, targetSceneParam}
+	for _, field := range transformFields {
+		params = append(params, ParamSpec{Name: field.param, Type: ParamNumber})
+	}
+	return ActionSpec{
+		Name:        "set_source_transform",
+		Label:       "Set Source Transform",
+		Category:    CategorySources,
+		Description: "Set the position, scale, rotation or crop of a source",
+		Params:      params,
+	}
+}
+
+// ValidateParams requires at least one transform param
+func (a *transformAction) ValidateParams(params ActionParams) error {
+	for _, field := range transformFields {
+		if _, ok := params.Float(field.param); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("set at least one of position, scale, rotation or crop")
+}
+
+func (a *transformAction) Execute(client *goobs.Client, params ActionParams) (interface{}, error) {
+	item, err := findSceneItem(client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// OBS changes only the fields it is sent, but goobs sends them all, so
+	// start from the current transform
+	resp, err := client.SceneItems.GetSceneItemTransform(&sceneitems.GetSceneItemTransformParams{
+		SceneName:   &item.scene,
+		SceneItemId: &item.id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	transform := typedefs.SceneItemTransform{}
+	if resp.SceneItemTransform != nil {
+		transform = *resp.SceneItemTransform
+	}
+	for _, field := range transformFields {
+		if v, ok := params.Float(field.param); ok {
+			field.set(&transform, v)
+		}
+	}
+	// OBS reports unused bounds as 0 but rejects bounds below 1
+	if transform.BoundsWidth < 1 {
+		transform.BoundsWidth = 1
+	}
+	if transform.BoundsHeight < 1 {
+		transform.BoundsHeight = 1
+	}
+
+	_, err = client.SceneItems.SetSceneItemTransform(&sceneitems.SetSceneItemTransformParams{
+		SceneName:          &item.scene,
+		SceneItemId:        &item.id,
+		SceneItemTransform: &transform,
+	})
+	return nil, err
+}
+
+// findSceneItem looks up the scene item an action's params name
+func findSceneItem(client *goobs.Client, params ActionParams) (sceneItemRef, error) {
+	scene, err := targetScene(client, params)
+	if err != nil {
+		return sceneItemRef{}, err
+	}
+	source := params.String("source_name")
+	resp, err := client.SceneItems.GetSceneItemId(&sceneitems.GetSceneItemIdParams{
+		SceneName:  &scene,
+		SourceName: &source,
+	})
+	if err != nil {
+		return sceneItemRef{}, fmt.Errorf("source %q not found in scene %q: %w", source, scene, err)
+	}
+	return sceneItemRef{scene: scene, id: resp.SceneItemId}, nil
+}
+
+// targetScene returns scene_name, or else the current program or preview
+// scene as target_scene says
+func targetScene(client *goobs.Client, params ActionParams) (string, error) {
+	if scene := params.String("scene_name"); scene != "" {
+		return scene, nil
+	}
+	if params.String("target_scene") == TargetPreview {
+		resp, err := client.Scenes.GetCurrentPreviewScene()
+		if err != nil {
+			return "", fmt.Errorf("failed to get preview scene (is studio mode on?): %w", err)
+		}
+		return resp.CurrentPreviewSceneName, nil
+	}
+	resp, err := client.Scenes.GetCurrentProgramScene()
+	if err != nil {
+		return "", err
+	}
+	return resp.CurrentProgramSceneName, nil
+}
+
+// setSceneItemEnabled shows or hides a scene item
+func setSceneItemEnabled(client *goobs.Client, item sceneItemRef, enabled bool) error {
+	_, err := client.SceneItems.SetSceneItemEnabled(&sceneitems.SetSceneItemEnabledParams{
+		SceneName:        &item.scene,
+		SceneItemId:      &item.id,
+		SceneItemEnabled: &enabled,
+	})
+	return err
+}
+
+// setSceneItemLocked locks or unlocks a scene item
+func setSceneItemLocked(client *goobs.Client, item sceneItemRef, locked bool) error {
+	_, err := client.SceneItems.SetSceneItemLocked(&sceneitems.SetSceneItemLockedParams{
+		SceneName:       &item.scene,
+		SceneItemId:     &item.id,
+		SceneItemLocked: &locked,
+	})
+	return err
+}
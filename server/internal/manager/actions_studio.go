@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+	"github.com/andreykaipov/goobs/api/requests/transitions"
+	"github.com/andreykaipov/goobs/api/requests/ui"
+)
+
+// CategoryStudio holds studio mode and scene transition actions
+const CategoryStudio = "studio"
+
+const (
+	// OBS's range for a transition duration
+	minTransitionMs = 50
+	maxTransitionMs = 20000
+
+	// How long after an overridden transition should have ended the
+	// user's own transition is put back
+	transitionRestoreMargin = 250 * time.Millisecond
+)
+
+var (
+	transitionNameParam = ParamSpec{Name: "transition_name", Type: ParamString, Required: true, Description: "Name of the OBS scene transition"}
+	transitionMsParam   = ParamSpec{Name: "duration_ms", Type: ParamNumber, Required: true, Description: "Transition length in milliseconds"}
+
+	// Optional per-button override taken by switch_scene and
+	// trigger_transition
+	overrideNameParam = ParamSpec{Name: "transition_name", Type: ParamString, Description: "Transition to use instead of the current one"}
+	overrideMsParam   = ParamSpec{Name: "transition_duration_ms", Type: ParamNumber, Description: "Transition length to use, in milliseconds"}
+)
+
+// studioActions returns handlers for studio mode, the preview scene and
+// scene transitions. Transition overrides go through overrides so the
+// user's own transition comes back afterwards.
+func studioActions(overrides *transitionOverrides) []ActionHandler {
+	return []ActionHandler{
+		simpleAction("enable_studio_mode", "Enable Studio Mode", CategoryStudio, "Turn on studio mode", func(client *goobs.Client) error {
+			return setStudioMode(client, true)
+		}),
+		simpleAction("disable_studio_mode", "Disable Studio Mode", CategoryStudio, "Turn off studio mode", func(client *goobs.Client) error {
+			return setStudioMode(client, false)
+		}),
+		simpleAction("toggle_studio_mode", "Toggle Studio Mode", CategoryStudio, "Turn studio mode on or off", func(client *goobs.Client) error {
+			resp, err := client.Ui.GetStudioModeEnabled()
+			if err != nil {
+				return err
+			}
+			return setStudioMode(client, !resp.StudioModeEnabled)
+		}),
+
+		&actionFunc{
+			spec: ActionSpec{
+				Name:        "set_preview_scene",
+				Label:       "Set Preview Scene",
+				Category:    CategoryStudio,
+				Description: "Put a scene in the studio mode preview",
+				Params:      []ParamSpec{sceneNameParam},
+			},
+			run: func(client *goobs.Client, params ActionParams) error {
+				sceneName := params.String("scene_name")
+				_, err := client.Scenes.SetCurrentPreviewScene(&scenes.SetCurrentPreviewSceneParams{
+					SceneName: &sceneName,
+				})
+				return err
+			},
+		},
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "trigger_transition",
+				Label:       "Transition",
+				Category:    CategoryStudio,
+				Description: "Transition the preview scene to program in studio mode",
+				Params:      []ParamSpec{overrideNameParam, overrideMsParam},
+			},
+			validate: transitionMsValidator("transition_duration_ms"),
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				return nil, overrides.run(client, params, func() error {
+					_, err := client.Transitions.TriggerStudioModeTransition()
+					return err
+				})
+			},
+		},
+
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "set_transition",
+				Label:       "Set Transition",
+				Category:    CategoryStudio,
+				Description: "Pick the scene transition, and optionally its length",
+				Params: []ParamSpec{
+					transitionNameParam,
+					{Name: "duration_ms", Type: ParamNumber, Description: "Transition length in milliseconds"},
+				},
+			},
+			validate: transitionMsValidator("duration_ms"),
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				overrides.cancel()
+				if err := setTransition(client, params.String("transition_name")); err != nil {
+					return nil, err
+				}
+				if ms, ok := params.Float("duration_ms"); ok {
+					return nil, setTransitionDuration(client, ms)
+				}
+				return nil, nil
+			},
+		},
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "set_transition_duration",
+				Label:       "Set Transition Duration",
+				Category:    CategoryStudio,
+				Description: "Set the length of the scene transition",
+				Params:      []ParamSpec{transitionMsParam},
+			},
+			validate: transitionMsValidator("duration_ms"),
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				overrides.cancel()
+				ms, _ := params.Float("duration_ms")
+				return nil, setTransitionDuration(client, ms)
+			},
+		},
+	}
+}
+
+// transitionMsValidator checks the transition length in param, if given,
+// against OBS's range
+func transitionMsValidator(param string) func(params ActionParams) error {
+	return func(params ActionParams) error {
+		if ms, ok := params.Float(param); ok && (ms < minTransitionMs || ms > maxTransitionMs) {
+			return fmt.Errorf("%s must be between %d and %d", param, minTransitionMs, maxTransitionMs)
+		}
+		return nil
+	}
+}
+
+// transitionOverrides lets a button switch scenes with its own transition.
+// The user's transition is saved before the first override and put back
+// once the last one has had time to run.
+type transitionOverrides struct {
+	mu      sync.Mutex
+	saved   *savedTransition
+	restore *time.Timer
+}
+
+// savedTransition is the transition in use before an override
+type savedTransition struct {
+	client   *goobs.Client
+	name     string
+	duration float64 // 0 for fixed-length transitions
+}
+
+func newTransitionOverrides() *transitionOverrides {
+	return &transitionOverrides{}
+}
+
+// run calls switchScene, first applying the transition_name and
+// transition_duration_ms override in params if there is one
+func (o *transitionOverrides) run(client *goobs.Client, params ActionParams, switchScene func() error) error {
+	name := params.String("transition_name")
+	ms, hasMs := params.Float("transition_duration_ms")
+	if name == "" && !hasMs {
+		return switchScene()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.saved == nil || o.saved.client != client {
+		resp, err := client.Transitions.GetCurrentSceneTransition()
+		if err != nil {
+			return err
+		}
+		o.saved = &savedTransition{client: client, name: resp.TransitionName, duration: resp.TransitionDuration}
+	}
+	if o.restore != nil {
+		o.restore.Stop()
+	}
+
+	err := func() error {
+		if name != "" {
+			if err := setTransition(client, name); err != nil {
+				return err
+			}
+		}
+		if hasMs {
+			if err := setTransitionDuration(client, ms); err != nil {
+				return err
+			}
+		} else {
+			// The restore waits for the override transition's own length
+			resp, err := client.Transitions.GetCurrentSceneTransition()
+			if err != nil {
+				return err
+			}
+			ms = resp.TransitionDuration
+		}
+		return switchScene()
+	}()
+	if err != nil {
+		o.restore = nil
+		o.restoreLocked()
+		return err
+	}
+
+	// A newer override or cancel replaces o.restore, and the old timer
+	// may already be waiting on the lock, so it checks it is still current
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(ms)*time.Millisecond+transitionRestoreMargin, func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if o.restore == timer {
+			o.restore = nil
+			o.restoreLocked()
+		}
+	})
+	o.restore = timer
+	return nil
+}
+
+// restoreLocked puts the saved transition back. Caller must hold o.mu.
+func (o *transitionOverrides) restoreLocked() {
+	saved := o.saved
+	o.saved = nil
+	if saved == nil {
+		return
+	}
+	err := obsRequest(func() error {
+		if err := setTransition(saved.client, saved.name); err != nil {
+			return err
+		}
+		if saved.duration > 0 {
+			return setTransitionDuration(saved.client, saved.duration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to restore transition %s: %v", saved.name, err)
+	}
+}
+
+// cancel forgets the saved transition without putting it back, for when
+// the transition is picked on purpose or OBS goes away
+func (o *transitionOverrides) cancel() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.restore != nil {
+		o.restore.Stop()
+		o.restore = nil
+	}
+	o.saved = nil
+}
+
+// setStudioMode turns studio mode on or off
+func setStudioMode(client *goobs.Client, enabled bool) error {
+	_, err := client.Ui.SetStudioModeEnabled(&ui.SetStudioModeEnabledParams{StudioModeEnabled: &enabled})
+	return err
+}
+
+// setTransition makes a transition the current one
+func setTransition(client *goobs.Client, name string) error {
+	_, err := client.Transitions.SetCurrentSceneTransition(&transitions.SetCurrentSceneTransitionParams{
+		TransitionName: &name,
+	})
+	return err
+}
+
+// setTransitionDuration sets the current transition's length
+func setTransitionDuration(client *goobs.Client, ms float64) error {
+	_, err := client.Transitions.SetCurrentSceneTransitionDuration(&transitions.SetCurrentSceneTransitionDurationParams{
+		TransitionDuration: &ms,
+	})
+	return err
+}
@@ -0,0 +1,325 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/requests/inputs"
+)
+
+// Units a volume is given in
+const (
+	VolumeDb      = "db"      // decibels, 0 being unchanged
+	VolumePercent = "percent" // percent of the volume multiplier, 100 being 0 dB
+)
+
+// Fade curves
+const (
+	FadeLinear = "linear" // even steps of the multiplier
+	FadeLog    = "log"    // even steps in dB, which sound even
+)
+
+const (
+	// OBS's volume range. It treats -100 dB as silence.
+	minVolumeDb  = -100.0
+	maxVolumeDb  = 26.0
+	maxVolumeMul = 20.0
+
+	// How often a fade sets the volume, and the longest fade
+	fadeInterval    = 50 * time.Millisecond
+	maxFadeDuration = 10 * time.Minute
+)
+
+var volumeUnitParam = ParamSpec{
+	Name:        "unit",
+	Type:        ParamString,
+	Description: "Unit of the volume (default db)",
+	Options:     []string{VolumeDb, VolumePercent},
+}
+
+// volumeActions returns handlers for setting, stepping and fading input
+// volume. Fades run in the background and are tracked in fades, so any
+// volume action on an input stops the fade running on it.
+func volumeActions(fades *fadeTracker) []ActionHandler {
+	return []ActionHandler{
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "volume_set",
+				Label:       "Set Volume",
+				Category:    CategoryAudio,
+				Description: "Set the volume of an input",
+				Params: []ParamSpec{
+					inputNameParam,
+					{Name: "volume", Type: ParamNumber, Required: true, Description: "Volume in dB, or percent"},
+					volumeUnitParam,
+				},
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				fades.cancel(input)
+				volume, _ := params.Float("volume")
+				return setInputVolume(client, input, volumeMul(volume, params.String("unit")))
+			},
+		},
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "volume_step",
+				Label:       "Step Volume",
+				Category:    CategoryAudio,
+				Description: "Raise or lower the volume of an input, within OBS's range",
+				Params: []ParamSpec{
+					inputNameParam,
+					{Name: "step", Type: ParamNumber, Required: true, Description: "Change in dB, or percent; negative to lower"},
+					volumeUnitParam,
+				},
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				fades.cancel(input)
+				current, err := getInputVolume(client, input)
+				if err != nil {
+					return nil, err
+				}
+				step, _ := params.Float("step")
+				var mul float64
+				if params.String("unit") == VolumePercent {
+					mul = volumeMul(current*100+step, VolumePercent)
+				} else {
+					mul = volumeMul(mulToDb(current)+step, VolumeDb)
+				}
+				return setInputVolume(client, input, mul)
+			},
+		},
+		&resultAction{
+			spec: ActionSpec{
+				Name:        "volume_fade",
+				Label:       "Fade Volume",
+				Category:    CategoryAudio,
+				Description: "Fade the volume of an input to a target over time",
+				Params: []ParamSpec{
+					inputNameParam,
+					{Name: "volume", Type: ParamNumber, Required: true, Description: "Target volume in dB, or percent"},
+					volumeUnitParam,
+					{Name: "duration_ms", Type: ParamNumber, Required: true, Description: "Length of the fade in milliseconds"},
+					{Name: "curve", Type: ParamString, Description: "How the volume moves (default log)", Options: []string{FadeLog, FadeLinear}},
+				},
+			},
+			validate: func(params ActionParams) error {
+				ms, _ := params.Float("duration_ms")
+				if ms <= 0 || time.Duration(ms)*time.Millisecond > maxFadeDuration {
+					return fmt.Errorf("duration_ms must be between 0 and %d", maxFadeDuration.Milliseconds())
+				}
+				return nil
+			},
+			run: func(client *goobs.Client, params ActionParams) (interface{}, error) {
+				input := params.String("input_name")
+				from, err := getInputVolume(client, input)
+				if err != nil {
+					return nil, err
+				}
+				volume, _ := params.Float("volume")
+				to := volumeMul(volume, params.String("unit"))
+				ms, _ := params.Float("duration_ms")
+				duration := time.Duration(ms) * time.Millisecond
+
+				fd := fades.start(input)
+				go func() {
+					defer fades.finish(input, fd)
+					runFade(client, input, from, to, duration, params.String("curve"), fd)
+				}()
+				return map[string]interface{}{
+					"from_db":     mulToDb(from),
+					"to_db":       mulToDb(to),
+					"duration_ms": duration.Milliseconds(),
+				}, nil
+			},
+		},
+	}
+}
+
+// runFade moves an input's volume from one multiplier to another over
+// duration, until done or the fade is halted
+func runFade(client *goobs.Client, input string, from, to float64, duration time.Duration, curve string, fd *fade) {
+	ticker := time.NewTicker(fadeInterval)
+	defer ticker.Stop()
+	start := time.Now()
+
+	for {
+		select {
+		case <-fd.stop:
+			return
+		case <-ticker.C:
+		}
+
+		progress := math.Min(float64(time.Since(start))/float64(duration), 1)
+		mul := to
+		if progress < 1 {
+			mul = fadeStep(from, to, progress, curve)
+		}
+		ran, err := fd.step(func() error {
+			return obsRequest(func() error {
+				return setVolume(client, input, mul)
+			})
+		})
+		if err != nil {
+			log.Printf("⚠️  Volume fade of %s stopped: %v", input, err)
+			return
+		}
+		if !ran || progress >= 1 {
+			return
+		}
+	}
+}
+
+// fadeStep returns the multiplier progress (0 to 1) of the way from one
+// multiplier to another along curve
+func fadeStep(from, to, progress float64, curve string) float64 {
+	if curve == FadeLinear {
+		return from + (to-from)*progress
+	}
+	fromDb, toDb := mulToDb(from), mulToDb(to)
+	return dbToMul(fromDb + (toDb-fromDb)*progress)
+}
+
+// fade is one running volume fade
+type fade struct {
+	mu      sync.Mutex // held while the fade sets the volume
+	stopped bool
+	stop    chan struct{}
+}
+
+// step runs set unless the fade was halted, and reports whether it ran
+func (fd *fade) step(set func() error) (bool, error) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if fd.stopped {
+		return false, nil
+	}
+	return true, set()
+}
+
+// halt stops the fade. Once it returns the fade won't set the volume again.
+func (fd *fade) halt() {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if !fd.stopped {
+		fd.stopped = true
+		close(fd.stop)
+	}
+}
+
+// fadeTracker remembers the fade running on each input so a new volume
+// action can stop it
+type fadeTracker struct {
+	mu    sync.Mutex
+	fades map[string]*fade
+}
+
+func newFadeTracker() *fadeTracker {
+	return &fadeTracker{fades: make(map[string]*fade)}
+}
+
+// start halts any fade on input and registers a new one. Call finish when
+// the new fade ends.
+func (f *fadeTracker) start(input string) *fade {
+	fd := &fade{stop: make(chan struct{})}
+	f.mu.Lock()
+	old := f.fades[input]
+	f.fades[input] = fd
+	f.mu.Unlock()
+
+	if old != nil {
+		old.halt()
+	}
+	return fd
+}
+
+// finish forgets fd if it is still input's fade
+func (f *fadeTracker) finish(input string, fd *fade) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fades[input] == fd {
+		delete(f.fades, input)
+	}
+}
+
+// cancel halts the fade on input, if any
+func (f *fadeTracker) cancel(input string) {
+	f.mu.Lock()
+	fd := f.fades[input]
+	delete(f.fades, input)
+	f.mu.Unlock()
+
+	if fd != nil {
+		fd.halt()
+	}
+}
+
+// cancelAll halts every fade
+func (f *fadeTracker) cancelAll() {
+	f.mu.Lock()
+	fades := f.fades
+	f.fades = make(map[string]*fade)
+	f.mu.Unlock()
+
+	for _, fd := range fades {
+		fd.halt()
+	}
+}
+
+// volumeMul converts a volume in unit to a multiplier within OBS's range
+func volumeMul(volume float64, unit string) float64 {
+	if unit == VolumePercent {
+		return math.Max(0, math.Min(volume/100, maxVolumeMul))
+	}
+	return dbToMul(math.Min(volume, maxVolumeDb))
+}
+
+func dbToMul(db float64) float64 {
+	if db <= minVolumeDb {
+		return 0
+	}
+	return math.Pow(10, db/20)
+}
+
+func mulToDb(mul float64) float64 {
+	if mul <= 0 {
+		return minVolumeDb
+	}
+	return math.Max(20*math.Log10(mul), minVolumeDb)
+}
+
+// getInputVolume returns an input's volume multiplier
+func getInputVolume(client *goobs.Client, input string) (float64, error) {
+	resp, err := client.Inputs.GetInputVolume(&inputs.GetInputVolumeParams{InputName: &input})
+	if err != nil {
+		return 0, err
+	}
+	return resp.InputVolumeMul, nil
+}
+
+// setInputVolume sets an input's volume multiplier and reports what was set
+func setInputVolume(client *goobs.Client, input string, mul float64) (interface{}, error) {
+	if err := setVolume(client, input, mul); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"volume_db": mulToDb(mul), "volume_percent": mul * 100}, nil
+}
+
+// setVolume sends a volume multiplier to OBS. Silence is sent as -100 dB,
+// since goobs leaves a multiplier of 0 out of the request.
+func setVolume(client *goobs.Client, input string, mul float64) error {
+	params := &inputs.SetInputVolumeParams{InputName: &input}
+	if mul > 0 {
+		params.InputVolumeMul = &mul
+	} else {
+		db := minVolumeDb
+		params.InputVolumeDb = &db
+	}
+	_, err := client.Inputs.SetInputVolume(params)
+	return err
+}
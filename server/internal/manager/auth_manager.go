@@ -0,0 +1,249 @@
+package manager
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+const (
+	// How long a pairing code can be used
+	pairingCodeTTL = 5 * time.Minute
+
+	// Wrong guesses allowed before a pairing code is thrown away
+	maxPairingAttempts = 5
+)
+
+// ErrUnauthorized is returned for a missing, unknown or revoked token
+var ErrUnauthorized = fmt.Errorf("pairing required")
+
+// AuthManager pairs clients and checks the tokens they are issued
+type AuthManager struct {
+	store          *Store
+	sessionManager *SessionManager
+	clients        map[string]*models.PairedClient // by ID
+	byToken        map[string]*models.PairedClient // by token hash
+
+	pairingMu sync.Mutex
+	pairing   *models.PairingCode
+	attempts  int
+
+	revokeMu sync.Mutex
+	onRevoke []func(client *models.PairedClient)
+}
+
+// NewAuthManager creates a new AuthManager
+func NewAuthManager(store *Store, sessionManager *SessionManager) *AuthManager {
+	am := &AuthManager{
+		store:          store,
+		sessionManager: sessionManager,
+		clients:        make(map[string]*models.PairedClient),
+		byToken:        make(map[string]*models.PairedClient),
+	}
+	if err := am.load(); err != nil {
+		log.Printf("⚠️  Failed to load paired clients: %v", err)
+	}
+	store.register(storage.KindClients, am.load)
+	return am
+}
+
+// load reads paired clients from the repository
+func (am *AuthManager) load() error {
+	clients, err := am.store.repo.LoadClients()
+	if err != nil {
+		return err
+	}
+	am.clients = make(map[string]*models.PairedClient, len(clients))
+	am.byToken = make(map[string]*models.PairedClient, len(clients))
+	for _, client := range clients {
+		am.clients[client.ID] = client
+		am.byToken[client.TokenHash] = client
+	}
+	return nil
+}
+
+// NewPairingCode creates a six digit code for pairing one client, replacing
+// any code shown before
+func (am *AuthManager) NewPairingCode() (*models.PairingCode, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, err
+	}
+
+	am.pairingMu.Lock()
+	defer am.pairingMu.Unlock()
+	am.pairing = &models.PairingCode{
+		Code:      fmt.Sprintf("%06d", n.Int64()),
+		ExpiresAt: time.Now().Add(pairingCodeTTL),
+	}
+	am.attempts = 0
+
+	out := *am.pairing
+	return &out, nil
+}
+
+// Pair exchanges the current pairing code for a token. The token is only
+// returned here; the server keeps a hash. A client pairing again replaces
+// its previous token.
+func (am *AuthManager) Pair(code, clientID, clientName, ipAddress string) (string, *models.PairedClient, error) {
+	if clientID == "" {
+		return "", nil, fmt.Errorf("client_id is required")
+	}
+	if err := am.usePairingCode(code); err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	token := hex.EncodeToString(raw)
+
+	client := &models.PairedClient{
+		ID:         uuid.New().String(),
+		ClientID:   clientID,
+		ClientName: clientName,
+		IPAddress:  ipAddress,
+		TokenHash:  hashToken(token),
+		PairedAt:   time.Now(),
+	}
+
+	err := am.store.Update(func(tx *Tx) error {
+		for id, existing := range am.clients {
+			if existing.ClientID == clientID {
+				tx.Delete(storage.KindClients, id)
+				delete(am.clients, id)
+				delete(am.byToken, existing.TokenHash)
+			}
+		}
+		tx.Put(storage.KindClients, client.ID, client)
+		am.clients[client.ID] = client
+		am.byToken[client.TokenHash] = client
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	log.Printf("🔑 Paired client %s (%s) from %s", clientName, clientID, ipAddress)
+	return token, copyPairedClient(client), nil
+}
+
+// usePairingCode checks code against the current pairing code and consumes
+// it. Too many wrong guesses throw the code away.
+func (am *AuthManager) usePairingCode(code string) error {
+	am.pairingMu.Lock()
+	defer am.pairingMu.Unlock()
+
+	if am.pairing == nil || time.Now().After(am.pairing.ExpiresAt) {
+		am.pairing = nil
+		return fmt.Errorf("no pairing code is active, show a new one on the server")
+	}
+	if subtle.ConstantTimeCompare([]byte(code), []byte(am.pairing.Code)) != 1 {
+		am.attempts++
+		if am.attempts >= maxPairingAttempts {
+			am.pairing = nil
+			log.Printf("⚠️  Pairing code discarded after %d wrong attempts", am.attempts)
+		}
+		return fmt.Errorf("invalid pairing code")
+	}
+
+	am.pairing = nil
+	return nil
+}
+
+// Authenticate returns the paired client a token was issued to
+func (am *AuthManager) Authenticate(token string) (*models.PairedClient, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	var client *models.PairedClient
+	err := am.store.View(func() error {
+		found, ok := am.byToken[hashToken(token)]
+		if !ok {
+			return ErrUnauthorized
+		}
+		client = copyPairedClient(found)
+		return nil
+	})
+	return client, err
+}
+
+// List returns every paired client, without token hashes
+func (am *AuthManager) List() []*models.PairedClient {
+	clients := make([]*models.PairedClient, 0)
+	am.store.View(func() error {
+		for _, client := range am.clients {
+			clients = append(clients, copyPairedClient(client))
+		}
+		return nil
+	})
+	return clients
+}
+
+// Revoke invalidates a client's token and ends its sessions. Requests with
+// the token fail from then on and open event streams are closed.
+func (am *AuthManager) Revoke(id string) error {
+	var revoked *models.PairedClient
+	err := am.store.Update(func(tx *Tx) error {
+		client, ok := am.clients[id]
+		if !ok {
+			return fmt.Errorf("paired client not found: %s", id)
+		}
+
+		tx.Delete(storage.KindClients, id)
+		delete(am.clients, id)
+		delete(am.byToken, client.TokenHash)
+		for sessionID, sess := range am.sessionManager.sessions {
+			if sess.ClientID == client.ClientID {
+				tx.Delete(storage.KindSessions, sessionID)
+				delete(am.sessionManager.sessions, sessionID)
+			}
+		}
+		revoked = copyPairedClient(client)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔒 Revoked client %s (%s)", revoked.ClientName, revoked.ClientID)
+	am.revokeMu.Lock()
+	handlers := append([]func(*models.PairedClient){}, am.onRevoke...)
+	am.revokeMu.Unlock()
+	for _, handler := range handlers {
+		handler(revoked)
+	}
+	return nil
+}
+
+// OnRevoke registers a handler called after a client is revoked
+func (am *AuthManager) OnRevoke(handler func(client *models.PairedClient)) {
+	am.revokeMu.Lock()
+	defer am.revokeMu.Unlock()
+	am.onRevoke = append(am.onRevoke, handler)
+}
+
+// hashToken returns the hex SHA-256 of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// copyPairedClient returns a copy of a paired client without its token hash
+func copyPairedClient(client *models.PairedClient) *models.PairedClient {
+	out := *client
+	out.TokenHash = ""
+	return &out
+}
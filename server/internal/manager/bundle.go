@@ -0,0 +1,324 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+// ExportBundle collects configurations and every button they place. With
+// no IDs, every configuration is exported.
+func (cm *ConfigManager) ExportBundle(configIDs []string) (*models.Bundle, error) {
+	bundle := &models.Bundle{
+		Version:        models.BundleVersion,
+		ExportedAt:     time.Now(),
+		Configurations: make([]*models.Configuration, 0),
+		Buttons:        make([]*models.Button, 0),
+		Icons:          make([]string, 0),
+	}
+
+	err := cm.store.View(func() error {
+		ids := configIDs
+		if len(ids) == 0 {
+			for id := range cm.configs {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+		}
+
+		exported := make(map[string]bool)
+		icons := make(map[string]bool)
+		for _, id := range ids {
+			cfg, ok := cm.configs[id]
+			if !ok {
+				return fmt.Errorf("configuration not found: %s", id)
+			}
+			if exported[id] {
+				continue
+			}
+			exported[id] = true
+
+			out := copyConfiguration(cfg)
+			out.IsDefault = false // The importing server keeps its own default
+			bundle.Configurations = append(bundle.Configurations, out)
+
+			for _, buttonID := range cfg.Buttons {
+				btn, ok := cm.buttonManager.buttons[buttonID]
+				if !ok || exported[buttonID] {
+					continue
+				}
+				exported[buttonID] = true
+				bundle.Buttons = append(bundle.Buttons, copyButton(btn))
+
+				if btn.Icon != "" {
+					icons[btn.Icon] = true
+				}
+				if btn.State != nil && btn.State.OnIcon != "" {
+					icons[btn.State.OnIcon] = true
+				}
+			}
+		}
+
+		sort.Slice(bundle.Buttons, func(i, j int) bool { return bundle.Buttons[i].ID < bundle.Buttons[j].ID })
+		for icon := range icons {
+			bundle.Icons = append(bundle.Icons, icon)
+		}
+		sort.Strings(bundle.Icons)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// ImportBundle adds a bundle's buttons and configurations to the library.
+// A bundle record conflicts with an existing one that has its ID, or for
+// configurations its name, or for buttons its name and action; opts.Conflict
+// says what happens then. Records keep their bundle IDs where those are
+// free and get new ones otherwise, and configurations are rewritten to
+// place the imported buttons. Everything is saved in one transaction; with
+// opts.DryRun nothing is and the report says what would happen.
+func (cm *ConfigManager) ImportBundle(bundle *models.Bundle, opts models.BundleImportOptions) (*models.BundleImportReport, error) {
+	mode, err := conflictMode(opts.Conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BundleImportReport{
+		DryRun:         opts.DryRun,
+		Conflict:       mode,
+		Configurations: make([]models.BundleImportItem, 0),
+		Buttons:        make([]models.BundleImportItem, 0),
+		Warnings:       make([]string, 0),
+	}
+
+	if opts.DryRun {
+		err = cm.store.View(func() error {
+			_, err := cm.importLocked(bundle, mode, nil, report)
+			return err
+		})
+		return report, err
+	}
+
+	var changes []ConfigChange
+	err = cm.store.Update(func(tx *Tx) error {
+		touched, err := cm.importLocked(bundle, mode, tx, report)
+		if err != nil {
+			return err
+		}
+		changes = cm.sessionsOnLocked(touched, ConfigChangeUpdated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cm.notify(changes)
+	return report, nil
+}
+
+// importLocked fills in the report for importing a bundle and, unless tx is
+// nil, makes the changes. It returns the existing configurations whose
+// contents changed. Caller must hold the store lock.
+func (cm *ConfigManager) importLocked(bundle *models.Bundle, mode models.ConflictMode, tx *Tx, report *models.BundleImportReport) (map[string]bool, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("empty bundle")
+	}
+
+	now := time.Now()
+	buttonIDs := make(map[string]string) // bundle ID -> library ID
+	touched := make(map[string]bool)
+
+	for _, src := range bundle.Buttons {
+		if src == nil || src.ID == "" {
+			report.Warnings = append(report.Warnings, "skipped a button without an ID")
+			continue
+		}
+
+		existing := cm.matchButtonLocked(src)
+		item := importItem(src.ID, src.Name, existing != nil, mode)
+		if existing != nil {
+			item.ConflictID = existing.ID
+		}
+
+		btn := copyButton(src)
+		btn.UpdatedAt = now
+		switch item.Result {
+		case models.ImportSkipped, models.ImportOverwritten:
+			item.ID = existing.ID
+			btn.ID = existing.ID
+			btn.CreatedAt = existing.CreatedAt
+		case models.ImportCreated:
+			item.ID = cm.freeIDLocked(src.ID)
+			btn.ID = item.ID
+			btn.CreatedAt = now
+		case models.ImportDuplicated:
+			item.ID = uuid.New().String()
+			btn.ID = item.ID
+			btn.Name = src.Name + " (imported)"
+			btn.CreatedAt = now
+		}
+		buttonIDs[src.ID] = item.ID
+		report.Buttons = append(report.Buttons, item)
+
+		if tx == nil || item.Result == models.ImportSkipped {
+			continue
+		}
+		cm.buttonManager.buttons[btn.ID] = btn
+		tx.Put(storage.KindButtons, btn.ID, btn)
+		if item.Result == models.ImportOverwritten {
+			for _, cfg := range cm.configs {
+				for _, buttonID := range cfg.Buttons {
+					if buttonID == btn.ID {
+						touched[cfg.ID] = true
+					}
+				}
+			}
+		}
+	}
+
+	firstCreated := ""
+	for _, src := range bundle.Configurations {
+		if src == nil || src.ID == "" {
+			report.Warnings = append(report.Warnings, "skipped a configuration without an ID")
+			continue
+		}
+
+		existing := cm.matchConfigurationLocked(src)
+		item := importItem(src.ID, src.Name, existing != nil, mode)
+		if existing != nil {
+			item.ConflictID = existing.ID
+		}
+
+		cfg := copyConfiguration(src)
+		cfg.IsDefault = false
+		cfg.UpdatedAt = now
+		switch item.Result {
+		case models.ImportSkipped, models.ImportOverwritten:
+			item.ID = existing.ID
+			cfg.ID = existing.ID
+			cfg.IsDefault = existing.IsDefault
+			cfg.CreatedAt = existing.CreatedAt
+		case models.ImportCreated:
+			item.ID = cm.freeIDLocked(src.ID)
+			cfg.ID = item.ID
+			cfg.CreatedAt = now
+		case models.ImportDuplicated:
+			item.ID = uuid.New().String()
+			cfg.ID = item.ID
+			cfg.Name = src.Name + " (imported)"
+			cfg.CreatedAt = now
+		}
+		report.Configurations = append(report.Configurations, item)
+		if item.Result == models.ImportSkipped {
+			continue
+		}
+
+		// Point placements at the imported buttons
+		positions := make([]string, 0, len(src.Buttons))
+		for position := range src.Buttons {
+			positions = append(positions, position)
+		}
+		sort.Strings(positions)
+		for _, position := range positions {
+			buttonID := src.Buttons[position]
+			if libraryID, ok := buttonIDs[buttonID]; ok {
+				cfg.Buttons[position] = libraryID
+			} else if _, ok := cm.buttonManager.buttons[buttonID]; !ok {
+				delete(cfg.Buttons, position)
+				report.Warnings = append(report.Warnings,
+					fmt.Sprintf("configuration %s: button %s at %s is not in the bundle and was left empty", src.Name, buttonID, position))
+			}
+		}
+		normalizePages(cfg)
+
+		if tx == nil {
+			continue
+		}
+		cm.configs[cfg.ID] = cfg
+		tx.Put(storage.KindConfigurations, cfg.ID, cfg)
+		if item.Result == models.ImportOverwritten {
+			touched[cfg.ID] = true
+		} else if firstCreated == "" {
+			firstCreated = cfg.ID
+		}
+	}
+
+	// A library that had no configurations gets a default
+	if tx != nil && firstCreated != "" {
+		if _, err := cm.getDefaultLocked(); err != nil {
+			cm.setDefaultLocked(tx, firstCreated)
+		}
+	}
+	return touched, nil
+}
+
+// importItem decides what happens to one bundle record
+func importItem(sourceID, name string, conflict bool, mode models.ConflictMode) models.BundleImportItem {
+	item := models.BundleImportItem{SourceID: sourceID, Name: name, Result: models.ImportCreated}
+	if conflict {
+		switch mode {
+		case models.ConflictOverwrite:
+			item.Result = models.ImportOverwritten
+		case models.ConflictDuplicate:
+			item.Result = models.ImportDuplicated
+		default:
+			item.Result = models.ImportSkipped
+		}
+	}
+	return item
+}
+
+// matchButtonLocked returns the library button a bundle button conflicts
+// with: the one with its ID, or else one with the same name and action
+func (cm *ConfigManager) matchButtonLocked(src *models.Button) *models.Button {
+	if btn, ok := cm.buttonManager.buttons[src.ID]; ok {
+		return btn
+	}
+	for _, btn := range cm.buttonManager.buttons {
+		if strings.EqualFold(btn.Name, src.Name) && sameAction(btn.Action, src.Action) {
+			return btn
+		}
+	}
+	return nil
+}
+
+// matchConfigurationLocked returns the configuration a bundle configuration
+// conflicts with: the one with its ID, or else one with the same name
+func (cm *ConfigManager) matchConfigurationLocked(src *models.Configuration) *models.Configuration {
+	if cfg, ok := cm.configs[src.ID]; ok {
+		return cfg
+	}
+	for _, cfg := range cm.configs {
+		if strings.EqualFold(cfg.Name, src.Name) {
+			return cfg
+		}
+	}
+	return nil
+}
+
+// freeIDLocked returns id if no button or configuration uses it, or a new ID
+func (cm *ConfigManager) freeIDLocked(id string) string {
+	_, button := cm.buttonManager.buttons[id]
+	_, config := cm.configs[id]
+	if button || config {
+		return uuid.New().String()
+	}
+	return id
+}
+
+// conflictMode validates a conflict mode, defaulting to skip
+func conflictMode(mode models.ConflictMode) (models.ConflictMode, error) {
+	switch mode {
+	case "":
+		return models.ConflictSkip, nil
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictDuplicate:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown conflict mode: %s", mode)
+}
@@ -2,6 +2,9 @@ package manager
 
 import (
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,90 +12,306 @@ import (
 	"github.com/robomon1/robo-stream/server/internal/storage"
 )
 
-// ButtonManager manages the button library
+// ButtonManager manages the button library. Buttons are deleted through
+// ConfigManager.DeleteButton, which also deals with the configurations
+// placing them.
 type ButtonManager struct {
-	storage *storage.Storage
+	store   *Store
 	buttons map[string]*models.Button
+	stats   map[string]*models.ButtonStats // by button ID; absent until pressed
+
+	// onUpdate is called with the ID of an edited button, after it is saved
+	onUpdate func(id string)
 }
 
 // NewButtonManager creates a new ButtonManager
-func NewButtonManager(storage *storage.Storage) *ButtonManager {
+func NewButtonManager(store *Store) *ButtonManager {
 	bm := &ButtonManager{
-		storage: storage,
+		store:   store,
 		buttons: make(map[string]*models.Button),
+		stats:   make(map[string]*models.ButtonStats),
+	}
+	if err := bm.load(); err != nil {
+		log.Printf("⚠️  Failed to load buttons: %v", err)
 	}
-	bm.load()
+	if err := bm.loadStats(); err != nil {
+		log.Printf("⚠️  Failed to load button stats: %v", err)
+	}
+	store.register(storage.KindButtons, bm.load)
+	store.register(storage.KindButtonStats, bm.loadStats)
 	return bm
 }
 
-// load reads buttons from storage
+// load reads buttons from the repository
 func (bm *ButtonManager) load() error {
-	var buttons []*models.Button
-	if err := bm.storage.LoadJSON("buttons.json", &buttons); err != nil {
+	buttons, err := bm.store.repo.LoadButtons()
+	if err != nil {
 		return err
 	}
+	bm.buttons = make(map[string]*models.Button, len(buttons))
 	for _, btn := range buttons {
 		bm.buttons[btn.ID] = btn
 	}
 	return nil
 }
 
-// save writes buttons to storage
-func (bm *ButtonManager) save() error {
-	buttons := make([]*models.Button, 0, len(bm.buttons))
-	for _, btn := range bm.buttons {
-		buttons = append(buttons, btn)
+// loadStats reads button press counts from the repository
+func (bm *ButtonManager) loadStats() error {
+	stats, err := bm.store.repo.LoadButtonStats()
+	if err != nil {
+		return err
 	}
-	return bm.storage.SaveJSON("buttons.json", buttons)
+	bm.stats = make(map[string]*models.ButtonStats, len(stats))
+	for _, stat := range stats {
+		bm.stats[stat.ButtonID] = stat
+	}
+	return nil
 }
 
 // Create creates a new button
 func (bm *ButtonManager) Create(btn *models.Button) error {
-	btn.ID = uuid.New().String()
-	btn.CreatedAt = time.Now()
-	btn.UpdatedAt = time.Now()
-	bm.buttons[btn.ID] = btn
-	return bm.save()
+	return bm.store.Update(func(tx *Tx) error {
+		btn.ID = uuid.New().String()
+		btn.CreatedAt = time.Now()
+		btn.UpdatedAt = time.Now()
+		normalizeLabels(btn)
+
+		bm.buttons[btn.ID] = copyButton(btn)
+		tx.Put(storage.KindButtons, btn.ID, bm.buttons[btn.ID])
+		return nil
+	})
 }
 
 // Get retrieves a button by ID
 func (bm *ButtonManager) Get(id string) (*models.Button, error) {
+	var btn *models.Button
+	err := bm.store.View(func() error {
+		var err error
+		btn, err = bm.getLocked(id)
+		return err
+	})
+	return btn, err
+}
+
+// getLocked returns a copy of a button. Caller must hold the store lock.
+func (bm *ButtonManager) getLocked(id string) (*models.Button, error) {
 	btn, ok := bm.buttons[id]
 	if !ok {
 		return nil, fmt.Errorf("button not found: %s", id)
 	}
-	return btn, nil
+	return copyButton(btn), nil
 }
 
-// List returns all buttons
+// List returns all buttons sorted by name
 func (bm *ButtonManager) List() []*models.Button {
-	buttons := make([]*models.Button, 0, len(bm.buttons))
-	for _, btn := range bm.buttons {
-		buttons = append(buttons, btn)
-	}
+	buttons := make([]*models.Button, 0)
+	bm.store.View(func() error {
+		for _, btn := range bm.buttons {
+			buttons = append(buttons, copyButton(btn))
+		}
+		return nil
+	})
+	sortButtons(buttons, models.ButtonSortName, false)
 	return buttons
 }
 
 // Update updates an existing button
 func (bm *ButtonManager) Update(btn *models.Button) error {
-	if _, ok := bm.buttons[btn.ID]; !ok {
-		return fmt.Errorf("button not found: %s", btn.ID)
+	err := bm.store.Update(func(tx *Tx) error {
+		existing, ok := bm.buttons[btn.ID]
+		if !ok {
+			return fmt.Errorf("button not found: %s", btn.ID)
+		}
+		btn.CreatedAt = existing.CreatedAt
+		btn.UpdatedAt = time.Now()
+		normalizeLabels(btn)
+
+		bm.buttons[btn.ID] = copyButton(btn)
+		tx.Put(storage.KindButtons, btn.ID, bm.buttons[btn.ID])
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	btn.UpdatedAt = time.Now()
-	bm.buttons[btn.ID] = btn
-	return bm.save()
+	if bm.onUpdate != nil {
+		bm.onUpdate(btn.ID)
+	}
+	return nil
+}
+
+// RecordPress counts a press of a library button
+func (bm *ButtonManager) RecordPress(id string) error {
+	return bm.store.Update(func(tx *Tx) error {
+		if _, ok := bm.buttons[id]; !ok {
+			return fmt.Errorf("button not found: %s", id)
+		}
+		stat, ok := bm.stats[id]
+		if !ok {
+			stat = &models.ButtonStats{ButtonID: id}
+			bm.stats[id] = stat
+		}
+		now := time.Now()
+		stat.PressCount++
+		stat.LastPressedAt = &now
+		tx.Put(storage.KindButtonStats, id, stat)
+		return nil
+	})
 }
 
-// Delete removes a button
-func (bm *ButtonManager) Delete(id string) error {
-	delete(bm.buttons, id)
-	return bm.save()
+// Search returns the page of buttons matching a query, in its sort order
+func (bm *ButtonManager) Search(query models.ButtonQuery) (*models.ButtonSearchResult, error) {
+	switch query.Sort {
+	case "":
+		query.Sort = models.ButtonSortName
+	case models.ButtonSortName, models.ButtonSortCategory, models.ButtonSortAction,
+		models.ButtonSortCreated, models.ButtonSortUpdated:
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", query.Sort)
+	}
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, fmt.Errorf("offset and limit must not be negative")
+	}
+
+	matches := make([]*models.Button, 0)
+	bm.store.View(func() error {
+		for _, btn := range bm.buttons {
+			if buttonMatches(btn, query) {
+				matches = append(matches, copyButton(btn))
+			}
+		}
+		return nil
+	})
+	sortButtons(matches, query.Sort, query.Desc)
+
+	result := &models.ButtonSearchResult{
+		Total:  len(matches),
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	}
+	if query.Offset > len(matches) {
+		query.Offset = len(matches)
+	}
+	end := len(matches)
+	if query.Limit > 0 && query.Offset+query.Limit < end {
+		end = query.Offset + query.Limit
+	}
+	result.Buttons = matches[query.Offset:end]
+	return result, nil
 }
 
-// Search finds buttons matching a query
-func (bm *ButtonManager) Search(query string) []*models.Button {
-	// Simple search implementation
-	results := make([]*models.Button, 0)
-	// TODO: Implement proper search logic
-	return results
+// buttonMatches reports whether a button passes every filter of a query
+func buttonMatches(btn *models.Button, query models.ButtonQuery) bool {
+	if text := strings.ToLower(strings.TrimSpace(query.Text)); text != "" &&
+		!strings.Contains(strings.ToLower(btn.Name), text) &&
+		!strings.Contains(strings.ToLower(btn.Description), text) {
+		return false
+	}
+	if query.ActionType != "" && btn.Action.Type != query.ActionType {
+		return false
+	}
+	if query.Category != "" && !strings.EqualFold(btn.Category, strings.TrimSpace(query.Category)) {
+		return false
+	}
+	for _, tag := range query.Tags {
+		if !hasLabel(btn.Tags, tag) {
+			return false
+		}
+	}
+
+	if query.Scene != "" || query.Input != "" {
+		refs := buttonRefs(btn)
+		if query.Scene != "" && !refs.Scenes[query.Scene] {
+			return false
+		}
+		if query.Input != "" && !refs.Inputs[query.Input] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortButtons orders buttons by a sort key. Ties are broken by name and
+// then ID, so the order is stable across calls.
+func sortButtons(buttons []*models.Button, by string, desc bool) {
+	sort.SliceStable(buttons, func(i, j int) bool {
+		a, b := buttons[i], buttons[j]
+		if desc {
+			a, b = b, a
+		}
+
+		var cmp int
+		switch by {
+		case models.ButtonSortCategory:
+			cmp = strings.Compare(strings.ToLower(a.Category), strings.ToLower(b.Category))
+		case models.ButtonSortAction:
+			cmp = strings.Compare(a.Action.Type, b.Action.Type)
+		case models.ButtonSortCreated:
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		case models.ButtonSortUpdated:
+			cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		if cmp == 0 {
+			cmp = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+		if cmp == 0 {
+			cmp = strings.Compare(a.ID, b.ID)
+		}
+		return cmp < 0
+	})
+}
+
+// normalizeLabels trims a button's category and tags and drops empty and
+// repeated tags
+func normalizeLabels(btn *models.Button) {
+	btn.Category = strings.TrimSpace(btn.Category)
+
+	tags := make([]string, 0, len(btn.Tags))
+	for _, tag := range btn.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !hasLabel(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	btn.Tags = tags
+}
+
+// hasLabel reports whether labels holds label, ignoring case
+func hasLabel(labels []string, label string) bool {
+	label = strings.TrimSpace(label)
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyButton returns a copy of btn that shares nothing mutable with it
+func copyButton(btn *models.Button) *models.Button {
+	out := *btn
+	out.Action.Params = copyParams(btn.Action.Params)
+	if btn.Tags != nil {
+		out.Tags = append([]string(nil), btn.Tags...)
+	}
+	if btn.State != nil {
+		state := *btn.State
+		state.Params = copyParams(btn.State.Params)
+		out.State = &state
+	}
+	return &out
+}
+
+// copyParams returns a shallow copy of a params map
+func copyParams(params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		out[key] = value
+	}
+	return out
 }
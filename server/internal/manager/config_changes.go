@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"sort"
+	"sync"
+)
+
+// Reasons a session's configuration changed
+const (
+	ConfigChangeReassigned = "reassigned" // the session was moved to another configuration
+	ConfigChangeUpdated    = "updated"    // the configuration or one of its buttons was edited
+)
+
+// ConfigChange tells a session that what it displays has changed
+type ConfigChange struct {
+	SessionID string
+	ConfigID  string
+	Reason    string
+}
+
+// configListeners holds the handlers registered with OnConfigChange
+type configListeners struct {
+	mu       sync.Mutex
+	handlers []func(changes []ConfigChange)
+}
+
+// OnConfigChange registers a handler called after sessions are reassigned or
+// the configuration they display is edited. It runs without the store lock.
+func (cm *ConfigManager) OnConfigChange(handler func(changes []ConfigChange)) {
+	cm.listeners.mu.Lock()
+	defer cm.listeners.mu.Unlock()
+	cm.listeners.handlers = append(cm.listeners.handlers, handler)
+}
+
+// notify calls every OnConfigChange handler. Call it after the store
+// transaction has been saved.
+func (cm *ConfigManager) notify(changes []ConfigChange) {
+	if len(changes) == 0 {
+		return
+	}
+	cm.listeners.mu.Lock()
+	handlers := append([]func([]ConfigChange){}, cm.listeners.handlers...)
+	cm.listeners.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(changes)
+	}
+}
+
+// sessionsOnLocked returns a change for every session using one of the
+// configurations. Caller must hold the store lock.
+func (cm *ConfigManager) sessionsOnLocked(configIDs map[string]bool, reason string) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+	for _, sess := range cm.sessionManager.sessions {
+		if configIDs[sess.ConfigID] {
+			changes = append(changes, ConfigChange{SessionID: sess.SessionID, ConfigID: sess.ConfigID, Reason: reason})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].SessionID < changes[j].SessionID })
+	return changes
+}
+
+// buttonUpdated notifies the sessions showing a configuration that places
+// an edited button
+func (cm *ConfigManager) buttonUpdated(id string) {
+	var changes []ConfigChange
+	cm.store.View(func() error {
+		configIDs := make(map[string]bool)
+		for _, cfg := range cm.configs {
+			for _, buttonID := range cfg.Buttons {
+				if buttonID == id {
+					configIDs[cfg.ID] = true
+				}
+			}
+		}
+		changes = cm.sessionsOnLocked(configIDs, ConfigChangeUpdated)
+		return nil
+	})
+	cm.notify(changes)
+}
@@ -2,8 +2,7 @@ package manager
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,159 +10,354 @@ import (
 	"github.com/robomon1/robo-stream/server/internal/storage"
 )
 
+// ButtonStateProvider reports the live OBS state toggle buttons follow
+type ButtonStateProvider interface {
+	ButtonStateActive(state models.ButtonState) (bool, error)
+}
+
 // ConfigManager manages button configurations
 type ConfigManager struct {
-	storage       *storage.Storage
-	buttonManager *ButtonManager
-	configs       map[string]*models.Configuration
+	store          *Store
+	buttonManager  *ButtonManager
+	sessionManager *SessionManager
+	configs        map[string]*models.Configuration
+	stateProvider  ButtonStateProvider
+	objectLister   OBSObjectLister
+	listeners      configListeners
 }
 
 // NewConfigManager creates a new ConfigManager
-func NewConfigManager(storage *storage.Storage, buttonManager *ButtonManager) *ConfigManager {
+func NewConfigManager(store *Store, buttonManager *ButtonManager, sessionManager *SessionManager) *ConfigManager {
 	cm := &ConfigManager{
-		storage:       storage,
-		buttonManager: buttonManager,
-		configs:       make(map[string]*models.Configuration),
+		store:          store,
+		buttonManager:  buttonManager,
+		sessionManager: sessionManager,
+		configs:        make(map[string]*models.Configuration),
+	}
+	if err := cm.load(); err != nil {
+		log.Printf("⚠️  Failed to load configurations: %v", err)
 	}
-	cm.load()
+	store.register(storage.KindConfigurations, cm.load)
+	buttonManager.onUpdate = cm.buttonUpdated
 	return cm
 }
 
-// load reads configurations from storage
+// load reads configurations from the repository
 func (cm *ConfigManager) load() error {
-	var configs []*models.Configuration
-	if err := cm.storage.LoadJSON("configs.json", &configs); err != nil {
+	configs, err := cm.store.repo.LoadConfigurations()
+	if err != nil {
 		return err
 	}
+	cm.configs = make(map[string]*models.Configuration, len(configs))
 	for _, cfg := range configs {
+		normalizePages(cfg)
 		cm.configs[cfg.ID] = cfg
 	}
 	return nil
 }
 
-// save writes configurations to storage
-func (cm *ConfigManager) save() error {
-	configs := make([]*models.Configuration, 0, len(cm.configs))
-	for _, cfg := range cm.configs {
-		configs = append(configs, cfg)
-	}
-	return cm.storage.SaveJSON("configs.json", configs)
-}
-
 // Create creates a new configuration
 func (cm *ConfigManager) Create(config *models.Configuration) error {
-	config.ID = uuid.New().String()
-	config.CreatedAt = time.Now()
-	config.UpdatedAt = time.Now()
-	if config.Buttons == nil {
-		config.Buttons = make(map[string]string)
-	}
-	cm.configs[config.ID] = config
-	return cm.save()
+	return cm.store.Update(func(tx *Tx) error {
+		config.ID = uuid.New().String()
+		config.CreatedAt = time.Now()
+		config.UpdatedAt = time.Now()
+		if config.Buttons == nil {
+			config.Buttons = make(map[string]string)
+		}
+		normalizePages(config)
+
+		// The first configuration becomes the default
+		if _, err := cm.getDefaultLocked(); err != nil {
+			config.IsDefault = true
+		}
+		cm.configs[config.ID] = copyConfiguration(config)
+		tx.Put(storage.KindConfigurations, config.ID, cm.configs[config.ID])
+		if config.IsDefault {
+			cm.setDefaultLocked(tx, config.ID)
+		}
+		return nil
+	})
 }
 
 // Get retrieves a configuration by ID
 func (cm *ConfigManager) Get(id string) (*models.Configuration, error) {
+	var cfg *models.Configuration
+	err := cm.store.View(func() error {
+		var err error
+		cfg, err = cm.getLocked(id)
+		return err
+	})
+	return cfg, err
+}
+
+// getLocked returns a copy of a configuration. Caller must hold the store lock.
+func (cm *ConfigManager) getLocked(id string) (*models.Configuration, error) {
 	cfg, ok := cm.configs[id]
 	if !ok {
 		return nil, fmt.Errorf("configuration not found: %s", id)
 	}
-	return cfg, nil
+	return copyConfiguration(cfg), nil
 }
 
 // List returns all configurations
 func (cm *ConfigManager) List() []*models.Configuration {
-	configs := make([]*models.Configuration, 0, len(cm.configs))
-	for _, cfg := range cm.configs {
-		configs = append(configs, cfg)
-	}
+	configs := make([]*models.Configuration, 0)
+	cm.store.View(func() error {
+		for _, cfg := range cm.configs {
+			configs = append(configs, copyConfiguration(cfg))
+		}
+		return nil
+	})
 	return configs
 }
 
-// Update updates an existing configuration
+// Update updates an existing configuration. Sessions using it are notified.
 func (cm *ConfigManager) Update(config *models.Configuration) error {
-	if _, ok := cm.configs[config.ID]; !ok {
-		return fmt.Errorf("configuration not found: %s", config.ID)
-	}
-	config.UpdatedAt = time.Now()
-	cm.configs[config.ID] = config
-	return cm.save()
-}
+	var changes []ConfigChange
+	err := cm.store.Update(func(tx *Tx) error {
+		existing, ok := cm.configs[config.ID]
+		if !ok {
+			return fmt.Errorf("configuration not found: %s", config.ID)
+		}
+		config.CreatedAt = existing.CreatedAt
+		config.UpdatedAt = time.Now()
+		config.IsDefault = existing.IsDefault // Changed through SetDefault and Delete
+		if config.Buttons == nil {
+			config.Buttons = make(map[string]string)
+		}
+		normalizePages(config)
 
-// Delete removes a configuration
-func (cm *ConfigManager) Delete(id string) error {
-	delete(cm.configs, id)
-	return cm.save()
+		cm.configs[config.ID] = copyConfiguration(config)
+		tx.Put(storage.KindConfigurations, config.ID, cm.configs[config.ID])
+		changes = cm.sessionsOnLocked(map[string]bool{config.ID: true}, ConfigChangeUpdated)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cm.notify(changes)
+	return nil
 }
 
 // SetDefault sets a configuration as the default
 func (cm *ConfigManager) SetDefault(id string) error {
-	// Clear default flag from all configs
-	for _, cfg := range cm.configs {
-		cfg.IsDefault = false
-	}
+	return cm.store.Update(func(tx *Tx) error {
+		if _, ok := cm.configs[id]; !ok {
+			return fmt.Errorf("configuration not found: %s", id)
+		}
+		cm.setDefaultLocked(tx, id)
+		return nil
+	})
+}
 
-	// Set new default
-	cfg, ok := cm.configs[id]
-	if !ok {
-		return fmt.Errorf("configuration not found: %s", id)
+// setDefaultLocked makes id the only default configuration, saving every
+// configuration whose flag changes. Caller must hold the store lock.
+func (cm *ConfigManager) setDefaultLocked(tx *Tx, id string) {
+	for otherID, other := range cm.configs {
+		if isDefault := otherID == id; other.IsDefault != isDefault {
+			other.IsDefault = isDefault
+			tx.Put(storage.KindConfigurations, otherID, other)
+		}
 	}
-	cfg.IsDefault = true
-
-	return cm.save()
 }
 
 // GetDefault returns the default configuration
 func (cm *ConfigManager) GetDefault() (*models.Configuration, error) {
+	var cfg *models.Configuration
+	err := cm.store.View(func() error {
+		var err error
+		cfg, err = cm.getDefaultLocked()
+		return err
+	})
+	return cfg, err
+}
+
+// getDefaultLocked returns a copy of the default configuration. Caller must
+// hold the store lock.
+func (cm *ConfigManager) getDefaultLocked() (*models.Configuration, error) {
 	for _, cfg := range cm.configs {
 		if cfg.IsDefault {
-			return cfg, nil
+			return copyConfiguration(cfg), nil
 		}
 	}
 	return nil, fmt.Errorf("no default configuration set")
 }
 
+// SetStateProvider sets where toggle buttons read their state from
+func (cm *ConfigManager) SetStateProvider(provider ButtonStateProvider) {
+	cm.stateProvider = provider
+}
+
 // Resolve converts a configuration to a resolved configuration with full button details
 func (cm *ConfigManager) Resolve(id string) (*models.ResolvedConfiguration, error) {
-	cfg, err := cm.Get(id)
+	var resolved *models.ResolvedConfiguration
+	stateful := make(map[string]*models.Button) // position -> toggle button
+	refs := make(map[string]objectRefs)         // position -> OBS objects it uses
+
+	err := cm.store.View(func() error {
+		cfg, ok := cm.configs[id]
+		if !ok {
+			return fmt.Errorf("configuration not found: %s", id)
+		}
+
+		resolved = &models.ResolvedConfiguration{
+			ID:      cfg.ID,
+			Name:    cfg.Name,
+			Grid:    cfg.Grid,
+			Buttons: make([]models.ResolvedButton, 0),
+			Pages:   make([]models.ResolvedPage, len(cfg.Pages)),
+		}
+		for i, page := range cfg.Pages {
+			resolved.Pages[i] = models.ResolvedPage{
+				Index:   i,
+				Name:    page.Name,
+				Buttons: make([]models.ResolvedButton, 0),
+			}
+		}
+
+		// Resolve each button
+		for position, buttonID := range cfg.Buttons {
+			button, err := cm.buttonManager.getLocked(buttonID)
+			if err != nil {
+				continue // Skip if button not found
+			}
+
+			page, row, col, err := models.ParsePosition(position)
+			if err != nil || page >= len(resolved.Pages) {
+				continue
+			}
+
+			resolvedBtn := models.ResolvedButton{
+				ID:     position,
+				Page:   page,
+				Row:    row,
+				Col:    col,
+				Text:   button.Name,
+				Icon:   button.Icon,
+				Color:  button.Color,
+				Action: button.Action,
+			}
+			if button.State != nil && button.State.Source != "" {
+				stateful[position] = button
+			}
+			refs[position] = buttonRefs(button)
+
+			resolved.Pages[page].Buttons = append(resolved.Pages[page].Buttons, resolvedBtn)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	resolved := &models.ResolvedConfiguration{
-		ID:      cfg.ID,
-		Name:    cfg.Name,
-		Grid:    cfg.Grid,
-		Buttons: make([]models.ResolvedButton, 0),
+	// Toggle state may need a round-trip to OBS, so read it without the lock
+	for p := range resolved.Pages {
+		for i := range resolved.Pages[p].Buttons {
+			btn := &resolved.Pages[p].Buttons[i]
+			if button, ok := stateful[btn.ID]; ok {
+				cm.applyState(btn, button)
+			}
+		}
 	}
+	cm.flagBroken(resolved, refs)
+	resolved.Buttons = resolved.Pages[0].Buttons
 
-	// Resolve each button
-	for position, buttonID := range cfg.Buttons {
-		button, err := cm.buttonManager.Get(buttonID)
-		if err != nil {
-			continue // Skip if button not found
+	return resolved, nil
+}
+
+// ButtonStates returns the live appearance of every toggle button in a
+// configuration
+func (cm *ConfigManager) ButtonStates(id string) ([]models.ButtonStateUpdate, error) {
+	resolved, err := cm.Resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]models.ButtonStateUpdate, 0)
+	for _, page := range resolved.Pages {
+		for _, btn := range page.Buttons {
+			if !btn.Toggle {
+				continue
+			}
+			states = append(states, models.ButtonStateUpdate{
+				ID:     btn.ID,
+				Active: btn.Active,
+				Text:   btn.Text,
+				Icon:   btn.Icon,
+				Color:  btn.Color,
+			})
 		}
+	}
+	return states, nil
+}
 
-		// Parse position (btn-0-0 -> row=0, col=0)
-		parts := strings.Split(position, "-")
-		if len(parts) != 3 {
+// normalizePages rewrites legacy btn-<row>-<col> keys as page 0 and makes
+// sure every page a key refers to exists, with at least one page overall
+func normalizePages(cfg *models.Configuration) {
+	pageCount := len(cfg.Pages)
+	for position, buttonID := range cfg.Buttons {
+		page, row, col, err := models.ParsePosition(position)
+		if err != nil {
 			continue
 		}
-		row, _ := strconv.Atoi(parts[1])
-		col, _ := strconv.Atoi(parts[2])
-
-		resolvedBtn := models.ResolvedButton{
-			ID:     position,
-			Row:    row,
-			Col:    col,
-			Text:   button.Name,
-			Icon:   button.Icon,
-			Color:  button.Color,
-			Action: button.Action,
+		if key := models.PositionKey(page, row, col); key != position {
+			delete(cfg.Buttons, position)
+			cfg.Buttons[key] = buttonID
+		}
+		if page >= pageCount {
+			pageCount = page + 1
 		}
+	}
+	if pageCount == 0 {
+		pageCount = 1
+	}
 
-		resolved.Buttons = append(resolved.Buttons, resolvedBtn)
+	for i := len(cfg.Pages); i < pageCount; i++ {
+		cfg.Pages = append(cfg.Pages, models.Page{Name: fmt.Sprintf("Page %d", i+1)})
 	}
+}
 
-	return resolved, nil
+// copyConfiguration returns a copy of cfg that shares nothing mutable with it
+func copyConfiguration(cfg *models.Configuration) *models.Configuration {
+	out := *cfg
+	out.Buttons = make(map[string]string, len(cfg.Buttons))
+	for position, buttonID := range cfg.Buttons {
+		out.Buttons[position] = buttonID
+	}
+	out.Pages = append([]models.Page(nil), cfg.Pages...)
+	out.AllowedActions = append([]string(nil), cfg.AllowedActions...)
+	return &out
+}
+
+// applyState marks a resolved button as a toggle and switches it to its
+// "on" appearance when its state source is active
+func (cm *ConfigManager) applyState(resolved *models.ResolvedButton, button *models.Button) {
+	if button.State == nil || button.State.Source == "" {
+		return
+	}
+	resolved.Toggle = true
+
+	if cm.stateProvider == nil {
+		return
+	}
+	active, err := cm.stateProvider.ButtonStateActive(*button.State)
+	if err != nil {
+		log.Printf("⚠️  Failed to read state for button %s: %v", button.ID, err)
+		return
+	}
+	resolved.Active = active
+	if !active {
+		return
+	}
+
+	if button.State.OnText != "" {
+		resolved.Text = button.State.OnText
+	}
+	if button.State.OnIcon != "" {
+		resolved.Icon = button.State.OnIcon
+	}
+	if button.State.OnColor != "" {
+		resolved.Color = button.State.OnColor
+	}
 }
@@ -0,0 +1,299 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+// ButtonDependents reports which configurations place a button
+func (cm *ConfigManager) ButtonDependents(id string) (*models.DeleteReport, error) {
+	var report *models.DeleteReport
+	err := cm.store.View(func() error {
+		var err error
+		report, err = cm.buttonReportLocked(id)
+		return err
+	})
+	return report, err
+}
+
+// ConfigurationDependents reports which sessions use a configuration
+func (cm *ConfigManager) ConfigurationDependents(id string) (*models.DeleteReport, error) {
+	var report *models.DeleteReport
+	err := cm.store.View(func() error {
+		var err error
+		report, err = cm.configReportLocked(id)
+		return err
+	})
+	return report, err
+}
+
+// DeleteButton removes a button from the library. Configurations placing it
+// are left alone (restrict, the default, refuses instead), have it removed
+// (cascade) or get opts.ReplaceWith in its place (reassign). The library and
+// configurations are saved in one transaction. The report is returned even
+// when the delete is refused.
+func (cm *ConfigManager) DeleteButton(id string, opts models.DeleteOptions) (*models.DeleteReport, error) {
+	var report *models.DeleteReport
+	var changes []ConfigChange
+	err := cm.store.Update(func(tx *Tx) error {
+		var err error
+		report, err = cm.buttonReportLocked(id)
+		if err != nil {
+			return err
+		}
+		mode, err := deleteMode(opts.Mode)
+		if err != nil {
+			return err
+		}
+
+		if report.HasDependents() {
+			switch mode {
+			case models.DeleteRestrict:
+				return fmt.Errorf("button %s is placed in %d configuration(s)", report.Name, len(report.Configurations))
+			case models.DeleteReassign:
+				if opts.ReplaceWith == "" || opts.ReplaceWith == id {
+					return fmt.Errorf("reassigning needs a different button to replace %s", report.Name)
+				}
+				if _, ok := cm.buttonManager.buttons[opts.ReplaceWith]; !ok {
+					return fmt.Errorf("button not found: %s", opts.ReplaceWith)
+				}
+				report.ReplacedWith = opts.ReplaceWith
+			}
+
+			configIDs := make(map[string]bool)
+			for _, dep := range report.Configurations {
+				configIDs[dep.ID] = true
+				cfg := cm.configs[dep.ID]
+				tx.Put(storage.KindConfigurations, cfg.ID, cfg)
+				for _, position := range dep.Positions {
+					if mode == models.DeleteReassign {
+						cfg.Buttons[position] = opts.ReplaceWith
+					} else {
+						delete(cfg.Buttons, position)
+					}
+				}
+				cfg.UpdatedAt = time.Now()
+			}
+			changes = cm.sessionsOnLocked(configIDs, ConfigChangeUpdated)
+		}
+
+		tx.Delete(storage.KindButtons, id)
+		delete(cm.buttonManager.buttons, id)
+		if _, ok := cm.buttonManager.stats[id]; ok {
+			tx.Delete(storage.KindButtonStats, id)
+			delete(cm.buttonManager.stats, id)
+		}
+		return nil
+	})
+	if report != nil {
+		report.Deleted = err == nil
+	}
+	if err == nil {
+		cm.notify(changes)
+	}
+	return report, err
+}
+
+// Delete removes a configuration. Sessions using it are left alone
+// (restrict, the default, refuses instead), ended (cascade) or moved to
+// opts.ReplaceWith or the default (reassign). The default configuration can
+// only be deleted when opts.ReplaceWith names the new default, so the server
+// always has one. The report is returned even when the delete is refused.
+func (cm *ConfigManager) Delete(id string, opts models.DeleteOptions) (*models.DeleteReport, error) {
+	var report *models.DeleteReport
+	var changes []ConfigChange
+	err := cm.store.Update(func(tx *Tx) error {
+		var err error
+		report, err = cm.configReportLocked(id)
+		if err != nil {
+			return err
+		}
+		mode, err := deleteMode(opts.Mode)
+		if err != nil {
+			return err
+		}
+
+		if opts.ReplaceWith != "" {
+			if opts.ReplaceWith == id {
+				return fmt.Errorf("configuration %s cannot replace itself", report.Name)
+			}
+			if _, ok := cm.configs[opts.ReplaceWith]; !ok {
+				return fmt.Errorf("configuration not found: %s", opts.ReplaceWith)
+			}
+		}
+		if report.IsDefault {
+			if len(cm.configs) == 1 {
+				return fmt.Errorf("cannot delete %s: it is the only configuration", report.Name)
+			}
+			if opts.ReplaceWith == "" {
+				return fmt.Errorf("cannot delete %s: it is the default configuration, choose a new default", report.Name)
+			}
+		}
+
+		if report.HasDependents() {
+			target := opts.ReplaceWith
+			switch mode {
+			case models.DeleteRestrict:
+				return fmt.Errorf("configuration %s is used by %d session(s)", report.Name, len(report.Sessions))
+			case models.DeleteReassign:
+				if target == "" {
+					def, err := cm.getDefaultLocked()
+					if err != nil {
+						return err
+					}
+					target = def.ID
+				}
+				report.ReplacedWith = target
+			}
+
+			for _, dep := range report.Sessions {
+				if mode == models.DeleteReassign {
+					sess := cm.sessionManager.sessions[dep.ID]
+					tx.Put(storage.KindSessions, dep.ID, sess)
+					sess.ConfigID = target
+					changes = append(changes, ConfigChange{SessionID: dep.ID, ConfigID: target, Reason: ConfigChangeReassigned})
+				} else {
+					tx.Delete(storage.KindSessions, dep.ID)
+					delete(cm.sessionManager.sessions, dep.ID)
+				}
+			}
+		}
+
+		tx.Delete(storage.KindConfigurations, id)
+		delete(cm.configs, id)
+		if report.IsDefault {
+			cm.setDefaultLocked(tx, opts.ReplaceWith)
+			report.NewDefault = opts.ReplaceWith
+		}
+		return nil
+	})
+	if report != nil {
+		report.Deleted = err == nil
+	}
+	if err == nil {
+		cm.notify(changes)
+	}
+	return report, err
+}
+
+// AssignSession switches a session to a configuration, checking both exist
+// in the same transaction that saves the change. The session is notified.
+func (cm *ConfigManager) AssignSession(sessionID, configID string) error {
+	err := cm.store.Update(func(tx *Tx) error {
+		if _, ok := cm.configs[configID]; !ok {
+			return fmt.Errorf("configuration not found: %s", configID)
+		}
+		sess, ok := cm.sessionManager.sessions[sessionID]
+		if !ok {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+
+		tx.Put(storage.KindSessions, sessionID, sess)
+		sess.ConfigID = configID
+		sess.LastActive = time.Now()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cm.notify([]ConfigChange{{SessionID: sessionID, ConfigID: configID, Reason: ConfigChangeReassigned}})
+	return nil
+}
+
+// buttonReportLocked lists the configurations placing a button. Caller must
+// hold the store lock.
+func (cm *ConfigManager) buttonReportLocked(id string) (*models.DeleteReport, error) {
+	btn, ok := cm.buttonManager.buttons[id]
+	if !ok {
+		return nil, fmt.Errorf("button not found: %s", id)
+	}
+
+	report := newDeleteReport(id, btn.Name)
+	if placements := cm.placementsLocked()[id]; placements != nil {
+		report.Configurations = placements
+	}
+	return report, nil
+}
+
+// placementsLocked lists, by button ID, the configurations placing each
+// button and where. Caller must hold the store lock.
+func (cm *ConfigManager) placementsLocked() map[string][]models.Dependent {
+	placements := make(map[string][]models.Dependent)
+	for _, cfg := range cm.configs {
+		positions := make(map[string][]string)
+		for position, buttonID := range cfg.Buttons {
+			positions[buttonID] = append(positions[buttonID], position)
+		}
+		for buttonID, list := range positions {
+			sort.Strings(list)
+			placements[buttonID] = append(placements[buttonID], models.Dependent{
+				ID:        cfg.ID,
+				Name:      cfg.Name,
+				Positions: list,
+			})
+		}
+	}
+	for _, deps := range placements {
+		sortDependents(deps)
+	}
+	return placements
+}
+
+// configReportLocked lists the sessions using a configuration. Caller must
+// hold the store lock.
+func (cm *ConfigManager) configReportLocked(id string) (*models.DeleteReport, error) {
+	cfg, ok := cm.configs[id]
+	if !ok {
+		return nil, fmt.Errorf("configuration not found: %s", id)
+	}
+
+	report := newDeleteReport(id, cfg.Name)
+	report.IsDefault = cfg.IsDefault
+	for _, sess := range cm.sessionManager.sessions {
+		if sess.ConfigID != id {
+			continue
+		}
+		name := sess.ClientName
+		if name == "" {
+			name = sess.ClientID
+		}
+		report.Sessions = append(report.Sessions, models.Dependent{ID: sess.SessionID, Name: name})
+	}
+	sortDependents(report.Sessions)
+	return report, nil
+}
+
+// newDeleteReport returns a report with empty, non-nil dependent lists
+func newDeleteReport(id, name string) *models.DeleteReport {
+	return &models.DeleteReport{
+		ID:             id,
+		Name:           name,
+		Configurations: make([]models.Dependent, 0),
+		Sessions:       make([]models.Dependent, 0),
+	}
+}
+
+// sortDependents orders dependents by name, then ID
+func sortDependents(deps []models.Dependent) {
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].ID < deps[j].ID
+	})
+}
+
+// deleteMode defaults an empty mode to restrict and rejects unknown ones
+func deleteMode(mode models.DeleteMode) (models.DeleteMode, error) {
+	switch mode {
+	case "":
+		return models.DeleteRestrict, nil
+	case models.DeleteRestrict, models.DeleteCascade, models.DeleteReassign:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown delete mode: %s", mode)
+}
@@ -0,0 +1,337 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/closecodes"
+	"github.com/gorilla/websocket"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// ConnectionState describes the OBS connection lifecycle
+type ConnectionState string
+
+const (
+	StateDisconnected ConnectionState = "disconnected"
+	StateConnecting   ConnectionState = "connecting"
+	StateConnected    ConnectionState = "connected"
+	StateAuthFailed   ConnectionState = "auth_failed"
+)
+
+const (
+	// Delay before the first reconnect attempt; doubles up to maxReconnectDelay
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+
+	// How often a connected socket is probed. goobs doesn't always notice a
+	// dead TCP connection, so we ask OBS for its version as a heartbeat.
+	healthCheckInterval = 5 * time.Second
+)
+
+// goobsSendRequestFrame is the goobs function that panics when a connection
+// closes while a request waits for its response. TestDroppedDuringRequest
+// pins it to the goobs version in go.mod.
+const goobsSendRequestFrame = "github.com/andreykaipov/goobs/api.(*Client).SendRequest"
+
+// obsSession is one supervised connection target. Calling Connect again or
+// Disconnect closes stop, which ends the supervisor for the old target.
+type obsSession struct {
+	url      string
+	password string
+	stop     chan struct{}
+}
+
+// obsConn is one connection to OBS. goobs writes the close frame in
+// Disconnect without locking the socket, and the health check, a stop and a
+// cancelled dial may all close the same connection, so disconnect closes it
+// only once.
+type obsConn struct {
+	client *goobs.Client
+	once   sync.Once
+}
+
+// disconnect closes the connection; later calls do nothing
+func (c *obsConn) disconnect() {
+	c.once.Do(func() {
+		c.client.Disconnect()
+	})
+}
+
+// Connect connects to OBS WebSocket. The first attempt is made synchronously
+// so callers get immediate feedback; after that a supervisor keeps the
+// connection alive, reconnecting with exponential backoff whenever it drops.
+// Authentication failures stop the supervisor until Connect is called again
+// with new credentials.
+func (om *OBSManager) Connect(url, password string) error {
+	session := &obsSession{
+		url:      url,
+		password: password,
+		stop:     make(chan struct{}),
+	}
+
+	om.mu.Lock()
+	om.stopSessionLocked()
+	om.session = session
+	om.url = url
+	om.mu.Unlock()
+
+	om.setState(session, StateConnecting, nil)
+	conn, err := om.dial(session)
+	if err != nil {
+		if isAuthError(err) {
+			om.setState(session, StateAuthFailed, err)
+			return fmt.Errorf("failed to connect to OBS: authentication failed")
+		}
+		om.setState(session, StateDisconnected, err)
+		go om.supervise(session, nil)
+		return fmt.Errorf("failed to connect to OBS: %w", err)
+	}
+
+	if !om.attach(session, conn) {
+		conn.disconnect()
+		return fmt.Errorf("connection to OBS was cancelled")
+	}
+	om.setState(session, StateConnected, nil)
+
+	go om.supervise(session, conn)
+	return nil
+}
+
+// Disconnect disconnects from OBS and stops reconnecting
+func (om *OBSManager) Disconnect() error {
+	om.mu.Lock()
+	session := om.session
+	om.stopSessionLocked()
+	om.session = nil
+	om.mu.Unlock()
+
+	if session != nil {
+		om.setState(session, StateDisconnected, nil)
+	}
+	return nil
+}
+
+// stopSessionLocked ends the current supervisor and closes its connection.
+// Caller must hold om.mu.
+func (om *OBSManager) stopSessionLocked() {
+	if om.session != nil {
+		close(om.session.stop)
+	}
+	if om.conn != nil {
+		om.conn.disconnect()
+		om.conn = nil
+		om.client = nil
+	}
+	om.state = obsState{}
+	om.fades.cancelAll()
+	om.overrides.cancel()
+}
+
+// State returns the current connection state and the last connection error
+func (om *OBSManager) State() (ConnectionState, error) {
+	om.mu.RLock()
+	defer om.mu.RUnlock()
+	return om.connState, om.lastErr
+}
+
+// OnStateChange registers a callback for connection state transitions
+func (om *OBSManager) OnStateChange(handler func(state ConnectionState, err error)) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.stateHandlers = append(om.stateHandlers, handler)
+}
+
+// setState records a state transition for session, ignoring stale sessions,
+// and notifies callbacks and event subscribers
+func (om *OBSManager) setState(session *obsSession, state ConnectionState, err error) {
+	om.mu.Lock()
+	if om.session != session && !(om.session == nil && state == StateDisconnected) {
+		om.mu.Unlock()
+		return
+	}
+	changed := om.connState != state
+	om.connState = state
+	om.lastErr = err
+	handlers := append([]func(ConnectionState, error){}, om.stateHandlers...)
+	status := om.statusLocked()
+	om.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err != nil {
+		log.Printf("🔌 OBS connection %s: %v", state, err)
+	} else {
+		log.Printf("🔌 OBS connection %s", state)
+	}
+
+	for _, handler := range handlers {
+		handler(state, err)
+	}
+
+	om.publish(models.OBSEvent{
+		Type:   models.EventConnectionChanged,
+		Data:   map[string]interface{}{"state": state},
+		Status: status,
+	})
+}
+
+// dial opens a new connection for session
+func (om *OBSManager) dial(session *obsSession) (*obsConn, error) {
+	client, err := goobs.New(session.url, goobs.WithPassword(session.password))
+	if err != nil {
+		return nil, err
+	}
+	return &obsConn{client: client}, nil
+}
+
+// supervise keeps session connected until it is stopped. conn is an
+// already-attached connection to start from, or nil to dial first.
+func (om *OBSManager) supervise(session *obsSession, conn *obsConn) {
+	delay := minReconnectDelay
+
+	for {
+		if conn == nil {
+			select {
+			case <-session.stop:
+				return
+			case <-time.After(delay):
+			}
+
+			om.setState(session, StateConnecting, nil)
+			var err error
+			conn, err = om.dial(session)
+			if err != nil {
+				if isAuthError(err) {
+					om.setState(session, StateAuthFailed, err)
+					return
+				}
+				om.setState(session, StateDisconnected, err)
+				delay *= 2
+				if delay > maxReconnectDelay {
+					delay = maxReconnectDelay
+				}
+				continue
+			}
+
+			if !om.attach(session, conn) {
+				// Stopped while dialing
+				conn.disconnect()
+				return
+			}
+			delay = minReconnectDelay
+			om.setState(session, StateConnected, nil)
+		}
+
+		// Blocks until the socket closes
+		done := make(chan struct{})
+		go om.healthCheck(conn, done)
+		om.listen(conn.client)
+		close(done)
+
+		select {
+		case <-session.stop:
+			return
+		default:
+		}
+
+		om.detach(conn)
+		om.setState(session, StateDisconnected, errors.New("connection lost"))
+		conn = nil
+	}
+}
+
+// attach makes conn the active connection if session is still current
+func (om *OBSManager) attach(session *obsSession, conn *obsConn) bool {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	if om.session != session {
+		return false
+	}
+	om.conn = conn
+	om.client = conn.client
+	om.state = obsState{}
+	return true
+}
+
+// detach clears conn if it is still the active connection
+func (om *OBSManager) detach(conn *obsConn) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	if om.conn == conn {
+		om.conn = nil
+		om.client = nil
+		om.state = obsState{}
+	}
+}
+
+// healthCheck probes client until done is closed, forcing a disconnect when
+// OBS stops answering so that listen returns and the supervisor reconnects
+func (om *OBSManager) healthCheck(conn *obsConn, done <-chan struct{}) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := ping(conn.client); err != nil {
+				log.Printf("⚠️  OBS health check failed: %v", err)
+				conn.disconnect()
+				return
+			}
+		}
+	}
+}
+
+// ping asks OBS for its version
+func ping(client *goobs.Client) error {
+	return obsRequest(func() error {
+		_, err := client.General.GetVersion()
+		return err
+	})
+}
+
+// obsRequest runs fn, which sends requests to OBS. goobs panics when the
+// connection closes while a request is waiting for its response; that panic
+// is returned as an error, and any other is passed on. Requests are sent from
+// API handlers, event pushes and timers alike, so every one goes through here.
+func obsRequest(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if !droppedDuringRequest(r) {
+				panic(r)
+			}
+			err = fmt.Errorf("connection to OBS closed during request: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// droppedDuringRequest reports whether a recovered panic is goobs reading
+// the missing response of a request whose connection closed. Call it from
+// the deferred function, while the panicking frames are still on the stack.
+func droppedDuringRequest(r interface{}) bool {
+	if _, ok := r.(runtime.Error); !ok {
+		return false
+	}
+	return strings.Contains(string(debug.Stack()), goobsSendRequestFrame)
+}
+
+// isAuthError reports whether err is OBS rejecting our password
+func isAuthError(err error) bool {
+	var closeErr *websocket.CloseError
+	return errors.As(err, &closeErr) && closeErr.Code == closecodes.AuthenticationFailed
+}
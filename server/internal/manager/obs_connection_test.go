@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/robomon1/robo-stream/server/internal/obstest"
+)
+
+// dialFake connects a goobs client to a new fake OBS. Like supervise, it
+// leaves disconnecting to the caller, since a dropped connection must not be.
+func dialFake(t *testing.T) (*obstest.Server, *obsConn) {
+	t.Helper()
+
+	fake := obstest.NewServer()
+	t.Cleanup(fake.Close)
+	client, err := goobs.New(fake.Host)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return fake, &obsConn{client: client}
+}
+
+// TestDroppedDuringRequest pins droppedDuringRequest to the goobs version in
+// go.mod: the panic goobs raises when a connection closes under a request
+// must be recognised, and any other panic must not be
+func TestDroppedDuringRequest(t *testing.T) {
+	fake, conn := dialFake(t)
+	fake.DelayRequest("GetVersion", time.Second)
+
+	recovered := make(chan interface{}, 1)
+	dropped := make(chan bool, 1)
+	go func() {
+		defer func() {
+			r := recover()
+			recovered <- r
+			dropped <- r != nil && droppedDuringRequest(r)
+		}()
+		conn.client.General.GetVersion()
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fake.Received("GetVersion") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("GetVersion was not sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	fake.DropConnections()
+
+	if r := <-recovered; r == nil {
+		t.Fatal("goobs no longer panics when the connection closes during a request; obsRequest may not need to recover")
+	} else if !<-dropped {
+		t.Fatalf("goobs panicked with %v, which droppedDuringRequest doesn't recognise; check %s still exists", r, goobsSendRequestFrame)
+	}
+
+	// Bugs of our own are passed on rather than reported as a dropped connection
+	others := map[string]func() error{
+		"nil map": func() error {
+			var m map[string]int
+			m["x"] = 1
+			return nil
+		},
+		"value": func() error { panic("boom") },
+		"error": func() error { panic(errors.New("boom")) },
+	}
+	for name, fn := range others {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s: obsRequest swallowed the panic", name)
+				}
+			}()
+			obsRequest(fn)
+		}()
+	}
+}
+
+// TestObsConnDisconnectOnce closes one connection from several goroutines,
+// as the health check and a stop can. Run with -race.
+func TestObsConnDisconnectOnce(t *testing.T) {
+	_, conn := dialFake(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.disconnect()
+		}()
+	}
+	wg.Wait()
+}
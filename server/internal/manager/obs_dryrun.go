@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// DryRunAction checks an action the way ExecuteAction would run it, and that
+// the scenes, inputs and sources it names exist, without sending it to OBS
+func (om *OBSManager) DryRunAction(action models.ButtonAction) (models.ActionCheck, error) {
+	objects, err := listObjects(om)
+	if err != nil {
+		return models.ActionCheck{}, err
+	}
+	return om.dryRun(action, objects), nil
+}
+
+// DryRunConfiguration dry-runs the action of every button of a resolved
+// configuration, in page, row and column order
+func (om *OBSManager) DryRunConfiguration(resolved *models.ResolvedConfiguration) (*models.ConfigurationTest, error) {
+	objects, err := listObjects(om)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ConfigurationTest{
+		ConfigID: resolved.ID,
+		Name:     resolved.Name,
+		Buttons:  make([]models.ButtonCheck, 0),
+	}
+	for _, page := range resolved.Pages {
+		for _, btn := range page.Buttons {
+			check := om.dryRun(btn.Action, objects)
+			if check.OK() && btn.Action.Type == "folder" {
+				check = checkFolderPage(btn.Action, len(resolved.Pages))
+			}
+			if !check.OK() {
+				report.Failed++
+			}
+			report.Buttons = append(report.Buttons, models.ButtonCheck{
+				ID:          btn.ID,
+				Page:        btn.Page,
+				Row:         btn.Row,
+				Col:         btn.Col,
+				Text:        btn.Text,
+				Action:      btn.Action.Type,
+				ActionCheck: check,
+			})
+		}
+	}
+	report.OK = report.Failed == 0
+
+	sort.Slice(report.Buttons, func(i, j int) bool {
+		a, b := report.Buttons[i], report.Buttons[j]
+		if a.Page != b.Page {
+			return a.Page < b.Page
+		}
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		return a.Col < b.Col
+	})
+	return report, nil
+}
+
+// dryRun checks an action against the registry and objects, descending
+// into macro steps. The first problem found is reported.
+func (om *OBSManager) dryRun(action models.ButtonAction, objects *obsObjects) models.ActionCheck {
+	handler, ok := om.actions.Get(action.Type)
+	if !ok {
+		return models.ActionCheck{
+			Status:  models.CheckUnknownAction,
+			Message: fmt.Sprintf("unknown action type: %s", action.Type),
+		}
+	}
+	if _, err := om.actions.Validate(action); err != nil {
+		return models.ActionCheck{Status: models.CheckInvalidParams, Message: err.Error()}
+	}
+
+	params := ActionParams(action.Params)
+	for _, param := range handler.Spec().Params {
+		name := params.String(param.Name)
+		if name == "" {
+			continue
+		}
+		switch {
+		case param.Type == ParamScene && !objects.scenes[name]:
+			return models.ActionCheck{
+				Status:  models.CheckMissingScene,
+				Message: fmt.Sprintf("scene %q does not exist in OBS", name),
+			}
+		case param.Type == ParamInput && !objects.inputs[name]:
+			return models.ActionCheck{
+				Status:  models.CheckMissingInput,
+				Message: fmt.Sprintf("input %q does not exist in OBS", name),
+			}
+		case param.Type == ParamSource && !objects.scenes[name] && !objects.inputs[name]:
+			return models.ActionCheck{
+				Status:  models.CheckMissingSource,
+				Message: fmt.Sprintf("source %q does not exist in OBS", name),
+			}
+		}
+	}
+
+	if _, ok := handler.(*macroAction); ok {
+		// Validate has already checked the steps parse
+		steps, _ := parseMacroSteps(action.Params["steps"])
+		for i, step := range steps {
+			if check := om.dryRun(step.Action, objects); !check.OK() {
+				check.Message = fmt.Sprintf("step %d: %s", i+1, check.Message)
+				return check
+			}
+		}
+	}
+
+	return models.ActionCheck{Status: models.CheckOK}
+}
+
+// checkFolderPage checks that a folder action opens a page the
+// configuration has. Pages are numbered from 1.
+func checkFolderPage(action models.ButtonAction, pageCount int) models.ActionCheck {
+	page, _ := ActionParams(action.Params).Float("page")
+	if page < 1 || int(page) > pageCount {
+		return models.ActionCheck{
+			Status:  models.CheckInvalidParams,
+			Message: fmt.Sprintf("page %v does not exist (configuration has %d)", page, pageCount),
+		}
+	}
+	return models.ActionCheck{Status: models.CheckOK}
+}
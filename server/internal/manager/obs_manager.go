@@ -6,59 +6,93 @@ import (
 	"sync"
 
 	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/events"
 	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/sceneitems"
 	"github.com/andreykaipov/goobs/api/requests/scenes"
 	"github.com/robomon1/robo-stream/server/internal/models"
 )
 
 // OBSManager manages OBS WebSocket connection
 type OBSManager struct {
-	client *goobs.Client
-	url    string
-	mu     sync.RWMutex
+	client    *goobs.Client // conn's client, kept for sending requests
+	conn      *obsConn
+	url       string
+	state     obsState
+	connState ConnectionState
+	lastErr   error
+	session   *obsSession
+	actions   *ActionRegistry
+	fades     *fadeTracker
+	overrides *transitionOverrides
+	mu        sync.RWMutex
+
+	listeners      map[int]func(models.OBSEvent)
+	nextListenerID int
+	listenersMu    sync.RWMutex
+
+	stateHandlers []func(ConnectionState, error)
 }
 
-// NewOBSManager creates a new OBSManager
-func NewOBSManager() *OBSManager {
-	return &OBSManager{}
+// obsState caches OBS state so status reads don't need a round-trip.
+// It is loaded once on connect and then kept current by OBS events.
+type obsState struct {
+	loaded       bool
+	streaming    bool
+	recording    bool
+	recordPaused bool
+	virtualcam   bool
+	replayBuffer bool
+	lastReplay   string // path of the last saved replay
+	currentScene string
+	studioMode   bool
+	previewScene string // empty outside studio mode
+
+	currentTransition  string
+	transitionDuration float64         // in ms; 0 for fixed-length transitions
+	inputMuted         map[string]bool // filled lazily as inputs are asked about
+
+	// Scene item ids and flags, filled lazily like inputMuted. Ids are
+	// dropped whenever items are added, removed or renamed.
+	sceneItemIDs     map[sceneSource]int
+	sceneItemEnabled map[sceneItemRef]bool
+	sceneItemLocked  map[sceneItemRef]bool
+
+	// Scene and input names, nil until first asked for. Events that add,
+	// remove or rename one drop the list so the next read fetches it again.
+	scenes         []string
+	inputs         []string
+	objectsVersion int // bumped whenever a list is dropped
 }
 
-// Connect connects to OBS WebSocket
-func (om *OBSManager) Connect(url, password string) error {
-	om.mu.Lock()
-	defer om.mu.Unlock()
-
-	if om.client != nil {
-		om.client.Disconnect()
-	}
-
-	client, err := goobs.New(url, goobs.WithPassword(password))
-	if err != nil {
-		return fmt.Errorf("failed to connect to OBS: %w", err)
+// NewOBSManager creates a new OBSManager
+func NewOBSManager() *OBSManager {
+	om := &OBSManager{
+		connState: StateDisconnected,
+		actions:   NewActionRegistry(),
+		fades:     newFadeTracker(),
+		overrides: newTransitionOverrides(),
+		listeners: make(map[int]func(models.OBSEvent)),
 	}
-
-	om.client = client
-	om.url = url
-	return nil
-}
-
-// Disconnect disconnects from OBS
-func (om *OBSManager) Disconnect() error {
-	om.mu.Lock()
-	defer om.mu.Unlock()
-
-	if om.client != nil {
-		om.client.Disconnect()
-		om.client = nil
+	handlers := append(builtinActions(om.overrides), sourceActions()...)
+	handlers = append(handlers, studioActions(om.overrides)...)
+	handlers = append(handlers, volumeActions(om.fades)...)
+	handlers = append(handlers, mediaActions()...)
+	handlers = append(handlers, navigationActions()...)
+	handlers = append(handlers, &macroAction{om: om})
+	for _, handler := range handlers {
+		if err := om.actions.Register(handler); err != nil {
+			log.Printf("⚠️  Failed to register action: %v", err)
+		}
 	}
-	return nil
+	return om
 }
 
 // IsConnected returns whether connected to OBS
 func (om *OBSManager) IsConnected() bool {
 	om.mu.RLock()
 	defer om.mu.RUnlock()
-	return om.client != nil
+	return om.client != nil && om.connState == StateConnected
 }
 
 // GetURL returns the OBS WebSocket URL
@@ -72,13 +106,22 @@ func (om *OBSManager) GetURL() string {
 func (om *OBSManager) GetScenes() ([]string, error) {
 	om.mu.RLock()
 	client := om.client
+	cached := om.state.scenes
+	version := om.state.objectsVersion
 	om.mu.RUnlock()
 
 	if client == nil {
 		return nil, fmt.Errorf("not connected to OBS")
 	}
+	if cached != nil {
+		return append([]string(nil), cached...), nil
+	}
 
-	resp, err := client.Scenes.GetSceneList()
+	var resp *scenes.GetSceneListResponse
+	err := obsRequest(func() (err error) {
+		resp, err = client.Scenes.GetSceneList()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -88,21 +131,36 @@ func (om *OBSManager) GetScenes() ([]string, error) {
 		sceneNames[i] = scene.SceneName
 	}
 
+	om.mu.Lock()
+	if om.client == client && om.state.objectsVersion == version {
+		om.state.scenes = sceneNames
+	}
+	om.mu.Unlock()
+
 	log.Printf("🎬 GetScenes returning %d scenes: %v", len(sceneNames), sceneNames)
-	return sceneNames, nil
+	return append([]string(nil), sceneNames...), nil
 }
 
 // GetInputs returns list of input names
 func (om *OBSManager) GetInputs() ([]string, error) {
 	om.mu.RLock()
 	client := om.client
+	cached := om.state.inputs
+	version := om.state.objectsVersion
 	om.mu.RUnlock()
 
 	if client == nil {
 		return nil, fmt.Errorf("not connected to OBS")
 	}
+	if cached != nil {
+		return append([]string(nil), cached...), nil
+	}
 
-	resp, err := client.Inputs.GetInputList(&inputs.GetInputListParams{})
+	var resp *inputs.GetInputListResponse
+	err := obsRequest(func() (err error) {
+		resp, err = client.Inputs.GetInputList(&inputs.GetInputListParams{})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -112,136 +170,618 @@ func (om *OBSManager) GetInputs() ([]string, error) {
 		inputNames[i] = input.InputName
 	}
 
+	om.mu.Lock()
+	if om.client == client && om.state.objectsVersion == version {
+		om.state.inputs = inputNames
+	}
+	om.mu.Unlock()
+
 	log.Printf("🎤 GetInputs returning %d inputs: %v", len(inputNames), inputNames)
-	return inputNames, nil
+	return append([]string(nil), inputNames...), nil
 }
 
-// ExecuteAction executes a button action
-func (om *OBSManager) ExecuteAction(action models.ButtonAction) error {
+// GetMediaStatus reads the playback state of a media input from OBS
+func (om *OBSManager) GetMediaStatus(inputName string) (status *models.MediaStatus, err error) {
 	om.mu.RLock()
 	client := om.client
 	om.mu.RUnlock()
 
 	if client == nil {
-		return fmt.Errorf("not connected to OBS")
+		return nil, fmt.Errorf("not connected to OBS")
 	}
-
-	switch action.Type {
-	case "switch_scene":
-		sceneName, ok := action.Params["scene_name"].(string)
-		if !ok {
-			return fmt.Errorf("missing scene_name parameter")
-		}
-		_, err := client.Scenes.SetCurrentProgramScene(&scenes.SetCurrentProgramSceneParams{
-			SceneName: &sceneName,
-		})
+	err = obsRequest(func() (err error) {
+		status, err = mediaStatus(client, inputName)
 		return err
+	})
+	return status, err
+}
 
-	case "start_stream":
-		_, err := client.Stream.StartStream()
-		return err
+// ExecuteAction executes a button action. The returned result is action
+// specific and may be nil (e.g. macros return per-step results).
+func (om *OBSManager) ExecuteAction(action models.ButtonAction) (result interface{}, err error) {
+	handler, err := om.actions.Validate(action)
+	if err != nil {
+		return nil, err
+	}
 
-	case "stop_stream":
-		_, err := client.Stream.StopStream()
-		return err
+	om.mu.RLock()
+	client := om.client
+	om.mu.RUnlock()
 
-	case "toggle_stream":
-		_, err := client.Stream.ToggleStream()
-		return err
+	if client == nil {
+		return nil, fmt.Errorf("not connected to OBS")
+	}
 
-	case "start_record":
-		_, err := client.Record.StartRecord()
+	err = obsRequest(func() (err error) {
+		result, err = handler.Execute(client, action.Params)
 		return err
+	})
+	return result, err
+}
 
-	case "stop_record":
-		_, err := client.Record.StopRecord()
-		return err
+// Actions returns the registry of action handlers, so callers can register
+// additional action types
+func (om *OBSManager) Actions() *ActionRegistry {
+	return om.actions
+}
 
-	case "toggle_record":
-		_, err := client.Record.ToggleRecord()
-		return err
+// ActionCatalog describes every action type that can be executed
+func (om *OBSManager) ActionCatalog() []ActionSpec {
+	return om.actions.Catalog()
+}
 
-	case "pause_record":
-		_, err := client.Record.PauseRecord()
-		return err
+// GetStatus returns current OBS status
+func (om *OBSManager) GetStatus() (map[string]interface{}, error) {
+	om.mu.RLock()
+	client := om.client
+	loaded := om.state.loaded
+	om.mu.RUnlock()
 
-	case "resume_record":
-		_, err := client.Record.ResumeRecord()
-		return err
+	if client == nil {
+		om.mu.RLock()
+		defer om.mu.RUnlock()
+		return om.statusLocked(), nil
+	}
 
-	case "toggle_input_mute":
-		inputName, ok := action.Params["input_name"].(string)
-		if !ok {
-			return fmt.Errorf("missing input_name parameter")
+	if !loaded {
+		if err := om.loadState(client); err != nil {
+			return nil, err
 		}
-		_, err := client.Inputs.ToggleInputMute(&inputs.ToggleInputMuteParams{
-			InputName: &inputName,
-		})
-		return err
+	}
+
+	om.mu.RLock()
+	defer om.mu.RUnlock()
+	return om.statusLocked(), nil
+}
 
-	case "mute_input":
-		inputName, ok := action.Params["input_name"].(string)
-		if !ok {
-			return fmt.Errorf("missing input_name parameter")
+// ButtonStateActive reports whether a toggle button's state source is on.
+// Everything reads as off while OBS is disconnected.
+func (om *OBSManager) ButtonStateActive(state models.ButtonState) (bool, error) {
+	om.mu.RLock()
+	client := om.client
+	loaded := om.state.loaded
+	om.mu.RUnlock()
+
+	if client == nil {
+		return false, nil
+	}
+	if !loaded {
+		if err := om.loadState(client); err != nil {
+			return false, err
 		}
-		muted := true
-		_, err := client.Inputs.SetInputMute(&inputs.SetInputMuteParams{
-			InputName:  &inputName,
-			InputMuted: &muted,
-		})
-		return err
+	}
+
+	params := ActionParams(state.Params)
+
+	om.mu.RLock()
+	cached := om.state
+	muted, muteKnown := om.state.inputMuted[params.String("input_name")]
+	om.mu.RUnlock()
 
-	case "unmute_input":
-		inputName, ok := action.Params["input_name"].(string)
-		if !ok {
-			return fmt.Errorf("missing input_name parameter")
+	switch state.Source {
+	case models.StateSourceStreamActive:
+		return cached.streaming, nil
+	case models.StateSourceRecordActive:
+		return cached.recording, nil
+	case models.StateSourceRecordPaused:
+		return cached.recordPaused, nil
+	case models.StateSourceVirtualcamActive:
+		return cached.virtualcam, nil
+	case models.StateSourceReplayBufferActive:
+		return cached.replayBuffer, nil
+	case models.StateSourceCurrentScene:
+		return cached.currentScene == params.String("scene_name"), nil
+	case models.StateSourceStudioMode:
+		return cached.studioMode, nil
+	case models.StateSourcePreviewScene:
+		return cached.studioMode && cached.previewScene == params.String("scene_name"), nil
+	case models.StateSourceMediaPlaying:
+		status, err := om.GetMediaStatus(params.String("input_name"))
+		if err != nil {
+			return false, err
 		}
-		muted := false
-		_, err := client.Inputs.SetInputMute(&inputs.SetInputMuteParams{
-			InputName:  &inputName,
-			InputMuted: &muted,
+		return status.State == models.MediaPlaying, nil
+	case models.StateSourceInputMuted:
+		if muteKnown {
+			return muted, nil
+		}
+		return om.loadInputMute(client, params.String("input_name"))
+	case models.StateSourceSourceVisible, models.StateSourceSourceLocked:
+		return om.loadSceneItemState(client, cached.currentScene, state)
+	default:
+		return false, fmt.Errorf("unknown state source: %s", state.Source)
+	}
+}
+
+// loadInputMute queries an input's mute state and caches it
+func (om *OBSManager) loadInputMute(client *goobs.Client, inputName string) (bool, error) {
+	var resp *inputs.GetInputMuteResponse
+	err := obsRequest(func() (err error) {
+		resp, err = client.Inputs.GetInputMute(&inputs.GetInputMuteParams{
+			InputName: &inputName,
 		})
 		return err
+	})
+	if err != nil {
+		return false, err
+	}
 
-	default:
-		return fmt.Errorf("unknown action type: %s", action.Type)
+	om.mu.Lock()
+	if om.client == client && om.state.inputMuted != nil {
+		om.state.inputMuted[inputName] = resp.InputMuted
 	}
+	om.mu.Unlock()
+
+	return resp.InputMuted, nil
 }
 
-// GetStatus returns current OBS status
-func (om *OBSManager) GetStatus() (map[string]interface{}, error) {
+// sceneSource identifies a source placed in a scene by name
+type sceneSource struct {
+	scene  string
+	source string
+}
+
+// loadSceneItemState reports whether the source a source_visible or
+// source_locked state names is visible or locked, caching the answer.
+// programScene is used when the state names no scene.
+func (om *OBSManager) loadSceneItemState(client *goobs.Client, programScene string, state models.ButtonState) (bool, error) {
+	params := ActionParams(state.Params)
+	scene := params.String("scene_name")
+	if scene == "" && params.String("target_scene") == TargetPreview {
+		err := obsRequest(func() (err error) {
+			scene, err = targetScene(client, params)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+	} else if scene == "" {
+		scene = programScene
+	}
+	key := sceneSource{scene: scene, source: params.String("source_name")}
+	locked := state.Source == models.StateSourceSourceLocked
+
 	om.mu.RLock()
-	client := om.client
+	id, idKnown := om.state.sceneItemIDs[key]
+	flags := om.state.sceneItemEnabled
+	if locked {
+		flags = om.state.sceneItemLocked
+	}
+	value, known := flags[sceneItemRef{scene, id}]
 	om.mu.RUnlock()
+	if idKnown && known {
+		return value, nil
+	}
 
-	if client == nil {
-		return map[string]interface{}{
-			"connected": false,
-		}, nil
+	var item sceneItemRef
+	err := obsRequest(func() error {
+		if !idKnown {
+			resp, err := client.SceneItems.GetSceneItemId(&sceneitems.GetSceneItemIdParams{
+				SceneName:  &key.scene,
+				SourceName: &key.source,
+			})
+			if err != nil {
+				return err
+			}
+			id = resp.SceneItemId
+		}
+		item = sceneItemRef{scene: scene, id: id}
+		if locked {
+			resp, err := client.SceneItems.GetSceneItemLocked(&sceneitems.GetSceneItemLockedParams{
+				SceneName:   &item.scene,
+				SceneItemId: &item.id,
+			})
+			if err != nil {
+				return err
+			}
+			value = resp.SceneItemLocked
+		} else {
+			resp, err := client.SceneItems.GetSceneItemEnabled(&sceneitems.GetSceneItemEnabledParams{
+				SceneName:   &item.scene,
+				SceneItemId: &item.id,
+			})
+			if err != nil {
+				return err
+			}
+			value = resp.SceneItemEnabled
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	om.mu.Lock()
+	if om.client == client && om.state.sceneItemIDs != nil {
+		om.state.sceneItemIDs[key] = id
+		if locked {
+			om.state.sceneItemLocked[item] = value
+		} else {
+			om.state.sceneItemEnabled[item] = value
+		}
 	}
+	om.mu.Unlock()
+
+	return value, nil
+}
+
+// loadState queries OBS for the state that events keep current afterwards
+func (om *OBSManager) loadState(client *goobs.Client) error {
+	return obsRequest(func() error {
+		return om.fetchState(client)
+	})
+}
 
+// fetchState sends the requests for loadState
+func (om *OBSManager) fetchState(client *goobs.Client) error {
 	// Get stream status
 	streamResp, err := client.Stream.GetStreamStatus()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Get record status
 	recordResp, err := client.Record.GetRecordStatus()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Get current scene
 	sceneResp, err := client.Scenes.GetCurrentProgramScene()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// Virtual camera may be unavailable on this machine; treat that as off
+	virtualcam := false
+	if vcamResp, err := client.Outputs.GetVirtualCamStatus(); err == nil {
+		virtualcam = vcamResp.OutputActive
+	}
+
+	// So may the replay buffer, and OBS errors until a replay is saved
+	replayBuffer := false
+	if replayResp, err := client.Outputs.GetReplayBufferStatus(); err == nil {
+		replayBuffer = replayResp.OutputActive
+	}
+	lastReplay := ""
+	if lastResp, err := client.Outputs.GetLastReplayBufferReplay(); err == nil {
+		lastReplay = lastResp.SavedReplayPath
+	}
+
+	// Studio mode and transitions are only used by some setups; read them
+	// best-effort like the outputs above
+	studioMode, previewScene := false, ""
+	if studioResp, err := client.Ui.GetStudioModeEnabled(); err == nil && studioResp.StudioModeEnabled {
+		studioMode = true
+		if previewResp, err := client.Scenes.GetCurrentPreviewScene(); err == nil {
+			previewScene = previewResp.CurrentPreviewSceneName
+		}
+	}
+	transitionName, transitionDuration := "", 0.0
+	if transitionResp, err := client.Transitions.GetCurrentSceneTransition(); err == nil {
+		transitionName, transitionDuration = transitionResp.TransitionName, transitionResp.TransitionDuration
+	}
+
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	// Ignore results for a client that was replaced while we were querying
+	if om.client != client {
+		return nil
+	}
+	om.state = obsState{
+		loaded:       true,
+		streaming:    streamResp.OutputActive,
+		recording:    recordResp.OutputActive,
+		recordPaused: recordResp.OutputPaused,
+		virtualcam:   virtualcam,
+		replayBuffer: replayBuffer,
+		lastReplay:   lastReplay,
+		currentScene: sceneResp.CurrentProgramSceneName,
+		studioMode:   studioMode,
+		previewScene: previewScene,
+		inputMuted:   make(map[string]bool),
+
+		currentTransition:  transitionName,
+		transitionDuration: transitionDuration,
+
+		sceneItemIDs:     make(map[sceneSource]int),
+		sceneItemEnabled: make(map[sceneItemRef]bool),
+		sceneItemLocked:  make(map[sceneItemRef]bool),
+	}
+	return nil
+}
+
+// statusLocked builds the status map from cached state. Caller must hold om.mu.
+func (om *OBSManager) statusLocked() map[string]interface{} {
+	status := map[string]interface{}{
+		"connected":        om.client != nil && om.connState == StateConnected,
+		"state":            om.connState,
+		"streaming":        om.state.streaming,
+		"recording":        om.state.recording,
+		"record_paused":    om.state.recordPaused,
+		"virtualcam":       om.state.virtualcam,
+		"replay_buffer":    om.state.replayBuffer,
+		"last_replay_path": om.state.lastReplay,
+		"current_scene":    om.state.currentScene,
+		"studio_mode":      om.state.studioMode,
+		"preview_scene":    om.state.previewScene,
+
+		"current_transition":     om.state.currentTransition,
+		"transition_duration_ms": om.state.transitionDuration,
+	}
+	if om.lastErr != nil {
+		status["error"] = om.lastErr.Error()
+	}
+	return status
+}
+
+// ==================== EVENTS ====================
+
+// Subscribe registers a handler for OBS events and returns a function that
+// removes it. Handlers are called from the event goroutine and must not block.
+func (om *OBSManager) Subscribe(handler func(models.OBSEvent)) func() {
+	om.listenersMu.Lock()
+	id := om.nextListenerID
+	om.nextListenerID++
+	om.listeners[id] = handler
+	om.listenersMu.Unlock()
+
+	return func() {
+		om.listenersMu.Lock()
+		delete(om.listeners, id)
+		om.listenersMu.Unlock()
+	}
+}
+
+// publish sends an event to every subscriber
+func (om *OBSManager) publish(event models.OBSEvent) {
+	om.listenersMu.RLock()
+	defer om.listenersMu.RUnlock()
+
+	for _, handler := range om.listeners {
+		handler(event)
+	}
+}
+
+// listen consumes events from an OBS client until its connection closes
+func (om *OBSManager) listen(client *goobs.Client) {
+	if err := om.loadState(client); err != nil {
+		log.Printf("⚠️  Failed to load initial OBS state: %v", err)
+	}
+
+	// Push a fresh snapshot now that state is loaded
+	om.mu.RLock()
+	status := om.statusLocked()
+	om.mu.RUnlock()
+	om.publish(models.OBSEvent{Type: models.EventStatus, Status: status})
+
+	client.Listen(func(event any) {
+		om.handleEvent(client, event)
+	})
+}
+
+// handleEvent updates cached state from an OBS event and publishes it
+func (om *OBSManager) handleEvent(client *goobs.Client, event any) {
+	var out models.OBSEvent
+
+	om.mu.Lock()
+	if om.client != client {
+		om.mu.Unlock()
+		return
+	}
+
+	switch e := event.(type) {
+	case *events.CurrentProgramSceneChanged:
+		om.state.currentScene = e.SceneName
+		out = models.OBSEvent{
+			Type: models.EventSceneChanged,
+			Data: map[string]interface{}{"scene_name": e.SceneName},
+		}
+
+	case *events.CurrentPreviewSceneChanged:
+		om.state.previewScene = e.SceneName
+		out = models.OBSEvent{
+			Type: models.EventPreviewSceneChanged,
+			Data: map[string]interface{}{"scene_name": e.SceneName},
+		}
+
+	case *events.StudioModeStateChanged:
+		om.state.studioMode = e.StudioModeEnabled
+		if !e.StudioModeEnabled {
+			om.state.previewScene = ""
+		}
+		out = models.OBSEvent{
+			Type: models.EventStudioModeChanged,
+			Data: map[string]interface{}{"enabled": e.StudioModeEnabled},
+		}
+
+	case *events.CurrentSceneTransitionChanged:
+		om.state.currentTransition = e.TransitionName
+		out = models.OBSEvent{
+			Type: models.EventTransitionChanged,
+			Data: map[string]interface{}{"transition_name": e.TransitionName, "duration_ms": om.state.transitionDuration},
+		}
+
+	case *events.CurrentSceneTransitionDurationChanged:
+		om.state.transitionDuration = e.TransitionDuration
+		out = models.OBSEvent{
+			Type: models.EventTransitionChanged,
+			Data: map[string]interface{}{"transition_name": om.state.currentTransition, "duration_ms": e.TransitionDuration},
+		}
+
+	case *events.MediaInputActionTriggered:
+		out = models.OBSEvent{
+			Type: models.EventMediaStateChanged,
+			Data: map[string]interface{}{"input_name": e.InputName, "action": mediaActionName(e.MediaAction)},
+		}
+
+	case *events.MediaInputPlaybackStarted:
+		out = models.OBSEvent{
+			Type: models.EventMediaStateChanged,
+			Data: map[string]interface{}{"input_name": e.InputName, "action": "started"},
+		}
+
+	case *events.MediaInputPlaybackEnded:
+		out = models.OBSEvent{
+			Type: models.EventMediaStateChanged,
+			Data: map[string]interface{}{"input_name": e.InputName, "action": "ended"},
+		}
+
+	case *events.StreamStateChanged:
+		om.state.streaming = e.OutputActive
+		out = models.OBSEvent{
+			Type: models.EventStreamStateChanged,
+			Data: map[string]interface{}{"active": e.OutputActive, "state": e.OutputState},
+		}
+
+	case *events.RecordStateChanged:
+		om.state.recording = e.OutputActive
+		switch e.OutputState {
+		case "OBS_WEBSOCKET_OUTPUT_PAUSED":
+			om.state.recordPaused = true
+		case "OBS_WEBSOCKET_OUTPUT_RESUMED", "OBS_WEBSOCKET_OUTPUT_STOPPED":
+			om.state.recordPaused = false
+		}
+		out = models.OBSEvent{
+			Type: models.EventRecordStateChanged,
+			Data: map[string]interface{}{"active": e.OutputActive, "state": e.OutputState, "path": e.OutputPath},
+		}
+
+	case *events.VirtualcamStateChanged:
+		om.state.virtualcam = e.OutputActive
+		out = models.OBSEvent{
+			Type: models.EventVirtualcamStateChanged,
+			Data: map[string]interface{}{"active": e.OutputActive, "state": e.OutputState},
+		}
+
+	case *events.ReplayBufferStateChanged:
+		om.state.replayBuffer = e.OutputActive
+		out = models.OBSEvent{
+			Type: models.EventReplayBufferStateChanged,
+			Data: map[string]interface{}{"active": e.OutputActive, "state": e.OutputState},
+		}
+
+	case *events.ReplayBufferSaved:
+		om.state.lastReplay = e.SavedReplayPath
+		out = models.OBSEvent{
+			Type: models.EventReplayBufferSaved,
+			Data: map[string]interface{}{"path": e.SavedReplayPath},
+		}
+
+	case *events.InputMuteStateChanged:
+		if om.state.inputMuted != nil {
+			om.state.inputMuted[e.InputName] = e.InputMuted
+		}
+		out = models.OBSEvent{
+			Type: models.EventInputMuteChanged,
+			Data: map[string]interface{}{"input_name": e.InputName, "muted": e.InputMuted},
+		}
+
+	case *events.SceneItemEnableStateChanged:
+		if om.state.sceneItemEnabled != nil {
+			om.state.sceneItemEnabled[sceneItemRef{e.SceneName, e.SceneItemId}] = e.SceneItemEnabled
+		}
+		out = models.OBSEvent{
+			Type: models.EventSourceVisibilityChanged,
+			Data: map[string]interface{}{"scene_name": e.SceneName, "scene_item_id": e.SceneItemId, "visible": e.SceneItemEnabled},
+		}
+
+	case *events.SceneItemLockStateChanged:
+		if om.state.sceneItemLocked != nil {
+			om.state.sceneItemLocked[sceneItemRef{e.SceneName, e.SceneItemId}] = e.SceneItemLocked
+		}
+		out = models.OBSEvent{
+			Type: models.EventSourceLockChanged,
+			Data: map[string]interface{}{"scene_name": e.SceneName, "scene_item_id": e.SceneItemId, "locked": e.SceneItemLocked},
+		}
+
+	case *events.SceneItemCreated, *events.SceneItemRemoved:
+		om.forgetSceneItemsLocked()
+		om.mu.Unlock()
+		return
+
+	case *events.SceneCreated, *events.SceneRemoved:
+		om.dropObjectsLocked()
+		om.mu.Unlock()
+		return
+
+	case *events.SceneNameChanged:
+		om.dropObjectsLocked()
+		if om.state.currentScene == e.OldSceneName {
+			om.state.currentScene = e.SceneName
+		}
+		if om.state.previewScene == e.OldSceneName {
+			om.state.previewScene = e.SceneName
+		}
+		out = models.OBSEvent{
+			Type: models.EventSceneRenamed,
+			Data: map[string]interface{}{"old_name": e.OldSceneName, "new_name": e.SceneName},
+		}
+
+	case *events.InputCreated, *events.InputRemoved:
+		om.dropObjectsLocked()
+		om.mu.Unlock()
+		return
+
+	case *events.InputNameChanged:
+		om.dropObjectsLocked()
+		if muted, ok := om.state.inputMuted[e.OldInputName]; ok {
+			delete(om.state.inputMuted, e.OldInputName)
+			om.state.inputMuted[e.InputName] = muted
+		}
+		out = models.OBSEvent{
+			Type: models.EventInputRenamed,
+			Data: map[string]interface{}{"old_name": e.OldInputName, "new_name": e.InputName},
+		}
+
+	default:
+		om.mu.Unlock()
+		return
 	}
 
-	return map[string]interface{}{
-		"connected":     true,
-		"streaming":     streamResp.OutputActive,
-		"recording":     recordResp.OutputActive,
-		"current_scene": sceneResp.CurrentProgramSceneName,
-	}, nil
+	out.Status = om.statusLocked()
+	om.mu.Unlock()
+
+	om.publish(out)
+}
+
+// dropObjectsLocked forgets the cached scene and input lists, and the scene
+// items found in them. Caller must hold om.mu.
+func (om *OBSManager) dropObjectsLocked() {
+	om.state.scenes = nil
+	om.state.inputs = nil
+	om.state.objectsVersion++
+	om.forgetSceneItemsLocked()
+}
+
+// forgetSceneItemsLocked empties the scene item caches, if state is loaded.
+// Caller must hold om.mu.
+func (om *OBSManager) forgetSceneItemsLocked() {
+	if om.state.sceneItemIDs == nil {
+		return
+	}
+	om.state.sceneItemIDs = make(map[sceneSource]int)
+	om.state.sceneItemEnabled = make(map[sceneItemRef]bool)
+	om.state.sceneItemLocked = make(map[sceneItemRef]bool)
 }
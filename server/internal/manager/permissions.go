@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// ActionAllowed reports whether a session on a configuration may execute an
+// action: a button placed in the configuration must perform exactly that
+// action, or its type must be in the configuration's AllowedActions
+func (cm *ConfigManager) ActionAllowed(configID string, action models.ButtonAction) (bool, error) {
+	allowed := false
+	err := cm.store.View(func() error {
+		cfg, ok := cm.configs[configID]
+		if !ok {
+			return fmt.Errorf("configuration not found: %s", configID)
+		}
+		allowed = cm.actionAllowedLocked(cfg, action, 0)
+		return nil
+	})
+	return allowed, err
+}
+
+// actionAllowedLocked checks action against cfg. An allowlisted macro could
+// wrap any action, so each of its steps must be allowed as well. Caller must
+// be inside cm.store.View.
+func (cm *ConfigManager) actionAllowedLocked(cfg *models.Configuration, action models.ButtonAction, depth int) bool {
+	for _, buttonID := range cfg.Buttons {
+		button, ok := cm.buttonManager.buttons[buttonID]
+		if ok && sameAction(button.Action, action) {
+			return true
+		}
+	}
+
+	listed := false
+	for _, actionType := range cfg.AllowedActions {
+		if actionType == action.Type {
+			listed = true
+			break
+		}
+	}
+	if !listed || action.Type != "macro" {
+		return listed
+	}
+
+	if depth >= maxMacroDepth {
+		return false
+	}
+	steps, err := parseMacroSteps(action.Params["steps"])
+	if err != nil {
+		return false
+	}
+	for _, step := range steps {
+		if !cm.actionAllowedLocked(cfg, step.Action, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// SwitchAllowed reports whether a session on a configuration may move
+// itself to another one
+func (cm *ConfigManager) SwitchAllowed(configID string) (bool, error) {
+	allowed := false
+	err := cm.store.View(func() error {
+		cfg, ok := cm.configs[configID]
+		if !ok {
+			return fmt.Errorf("configuration not found: %s", configID)
+		}
+		allowed = cfg.AllowSwitching
+		return nil
+	})
+	return allowed, err
+}
+
+// sameAction reports whether two actions have the same type and parameters.
+// Parameters are compared as JSON so numbers decoded from requests match
+// those loaded from storage.
+func sameAction(a, b models.ButtonAction) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if len(a.Params) == 0 && len(b.Params) == 0 {
+		return true
+	}
+
+	// encoding/json sorts map keys, so equal maps encode identically
+	aJSON, err := json.Marshal(a.Params)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b.Params)
+	if err != nil {
+		return false
+	}
+	return string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,243 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// profileMapping turns another application's action into one of ours
+type profileMapping struct {
+	action    string // our action type
+	offAction string // used instead when the key's on/off setting is false
+	param     string // parameter taken from the key's settings, if any
+	label     string // button name for keys without a title
+	icon      string
+	color     string
+}
+
+// profileMappings maps Stream-Pi module names and Stream Deck plugin UUIDs.
+// Folder keys are mapped from ProfileKey.Folder, not from here.
+var profileMappings = map[string]profileMapping{
+	// Stream-Pi OBS suite
+	"com.stream_pi.obssuite.setcurrentscene":    {action: "switch_scene", param: "scene_name", label: "Scene", icon: "layout", color: "#3498db"},
+	"com.stream_pi.obssuite.setscene":           {action: "switch_scene", param: "scene_name", label: "Scene", icon: "layout", color: "#3498db"},
+	"com.stream_pi.obssuite.togglemute":         {action: "toggle_input_mute", param: "input_name", label: "Mute", icon: "mic-off", color: "#e67e22"},
+	"com.stream_pi.obssuite.setmute":            {action: "mute_input", offAction: "unmute_input", param: "input_name", label: "Mute", icon: "mic-off", color: "#e67e22"},
+	"com.stream_pi.obssuite.togglestreaming":    {action: "toggle_stream", label: "Stream", icon: "video", color: "#e74c3c"},
+	"com.stream_pi.obssuite.startstopstreaming": {action: "toggle_stream", label: "Stream", icon: "video", color: "#e74c3c"},
+	"com.stream_pi.obssuite.setstreaming":       {action: "start_stream", offAction: "stop_stream", label: "Stream", icon: "video", color: "#e74c3c"},
+	"com.stream_pi.obssuite.togglerecording":    {action: "toggle_record", label: "Record", icon: "circle", color: "#e74c3c"},
+	"com.stream_pi.obssuite.startstoprecording": {action: "toggle_record", label: "Record", icon: "circle", color: "#e74c3c"},
+	"com.stream_pi.obssuite.setrecording":       {action: "start_record", offAction: "stop_record", label: "Record", icon: "circle", color: "#e74c3c"},
+	"com.stream_pi.obssuite.pauserecording":     {action: "pause_record", label: "Pause Recording", icon: "pause", color: "#95a5a6"},
+	"com.stream_pi.obssuite.resumerecording":    {action: "resume_record", label: "Resume Recording", icon: "play", color: "#95a5a6"},
+
+	// Elgato OBS Studio plugin
+	"com.elgato.obsstudio.scene":      {action: "switch_scene", param: "scene_name", label: "Scene", icon: "layout", color: "#3498db"},
+	"com.elgato.obsstudio.stream":     {action: "toggle_stream", label: "Stream", icon: "video", color: "#e74c3c"},
+	"com.elgato.obsstudio.record":     {action: "toggle_record", label: "Record", icon: "circle", color: "#e74c3c"},
+	"com.elgato.obsstudio.mixeraudio": {action: "toggle_input_mute", param: "input_name", label: "Mute", icon: "mic-off", color: "#e67e22"},
+
+	// Stream Deck navigation
+	"com.elgato.streamdeck.profile.backtoparent": {action: "page_back", label: "Back", icon: "square", color: "#95a5a6"},
+	"com.elgato.streamdeck.page.next":            {action: "page_next", label: "Next Page", icon: "square", color: "#95a5a6"},
+	"com.elgato.streamdeck.page.previous":        {action: "page_prev", label: "Previous Page", icon: "square", color: "#95a5a6"},
+}
+
+// Settings that may hold a parameter, by our parameter name. They are
+// matched without regard to case.
+var profileParamSettings = map[string][]string{
+	"scene_name": {"scene_name", "sceneName", "scene", "sceneId"},
+	"input_name": {"input_name", "inputName", "input", "source", "sourceName", "sourceId", "mixer"},
+}
+
+// Settings that may hold the on/off choice of a set action
+var profileStateSettings = []string{"state", "mute", "muted", "value", "enabled"}
+
+// ImportProfile maps a deck profile to buttons and one configuration and
+// imports them like a bundle, so opts works as for ImportBundle. Keys that
+// cannot be mapped are left empty and listed in the report.
+func (cm *ConfigManager) ImportProfile(profile *models.Profile, opts models.BundleImportOptions) (*models.ProfileImportReport, error) {
+	bundle, unmapped := ProfileBundle(profile)
+	result, err := cm.ImportBundle(bundle, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ProfileImportReport{
+		Format:   profile.Format,
+		Name:     profile.Name,
+		Unmapped: unmapped,
+		Import:   result,
+	}, nil
+}
+
+// ProfileBundle maps a profile's keys to buttons placed in one
+// configuration and returns the keys it could not map
+func ProfileBundle(profile *models.Profile) (*models.Bundle, []models.UnmappedAction) {
+	cfg := &models.Configuration{
+		ID:          uuid.New().String(),
+		Name:        profile.Name,
+		Description: fmt.Sprintf("Imported from %s profile", profileFormatLabel(profile.Format)),
+		Grid:        profile.Grid,
+		Buttons:     make(map[string]string),
+		Pages:       make([]models.Page, 0, len(profile.Pages)),
+	}
+	bundle := &models.Bundle{
+		Version:        models.BundleVersion,
+		Configurations: []*models.Configuration{cfg},
+		Buttons:        make([]*models.Button, 0),
+		Icons:          make([]string, 0),
+	}
+	unmapped := make([]models.UnmappedAction, 0)
+	buttons := make(map[string]string) // name and action -> bundle button ID
+
+	for page, profilePage := range profile.Pages {
+		name := profilePage.Name
+		if name == "" {
+			name = fmt.Sprintf("Page %d", page+1)
+		}
+		cfg.Pages = append(cfg.Pages, models.Page{Name: name})
+
+		for _, key := range profilePage.Keys {
+			if key.ActionID == "" && key.Folder == 0 {
+				continue // blank key
+			}
+			skip := func(reason string) {
+				unmapped = append(unmapped, models.UnmappedAction{
+					Page:     page,
+					Row:      key.Row,
+					Col:      key.Col,
+					Title:    key.Title,
+					ActionID: key.ActionID,
+					Reason:   reason,
+				})
+			}
+
+			if key.Row < 0 || key.Col < 0 || key.Row >= cfg.Grid.Rows || key.Col >= cfg.Grid.Cols {
+				skip("outside the grid")
+				continue
+			}
+			position := models.PositionKey(page, key.Row, key.Col)
+			if _, taken := cfg.Buttons[position]; taken {
+				skip("another key has this position")
+				continue
+			}
+
+			btn, reason := profileButton(key)
+			if reason != "" {
+				skip(reason)
+				continue
+			}
+
+			// Keys that look and act the same share a button
+			actionJSON, _ := json.Marshal(btn.Action)
+			dedupe := btn.Name + "\x00" + string(actionJSON)
+			if id, ok := buttons[dedupe]; ok {
+				cfg.Buttons[position] = id
+				continue
+			}
+			btn.ID = uuid.New().String()
+			buttons[dedupe] = btn.ID
+			bundle.Buttons = append(bundle.Buttons, btn)
+			cfg.Buttons[position] = btn.ID
+		}
+	}
+	return bundle, unmapped
+}
+
+// profileButton builds the button for one profile key, or returns why it
+// cannot be mapped
+func profileButton(key models.ProfileKey) (*models.Button, string) {
+	if key.Folder > 0 {
+		return &models.Button{
+			Name:   profileButtonName(key.Title, "Folder"),
+			Icon:   "square",
+			Color:  profileColor(key.Color, "#95a5a6"),
+			Action: models.ButtonAction{Type: "folder", Params: map[string]interface{}{"page": key.Folder + 1}},
+		}, ""
+	}
+
+	mapping, ok := profileMappings[key.ActionID]
+	if !ok {
+		return nil, "unsupported action"
+	}
+
+	action := models.ButtonAction{Type: mapping.action}
+	if mapping.param != "" {
+		value := profileSetting(key.Settings, profileParamSettings[mapping.param])
+		if value == "" {
+			return nil, fmt.Sprintf("no %s in the action settings", strings.ReplaceAll(mapping.param, "_", " "))
+		}
+		action.Params = map[string]interface{}{mapping.param: value}
+	}
+	if mapping.offAction != "" {
+		if on, err := strconv.ParseBool(profileSetting(key.Settings, profileStateSettings)); err == nil && !on {
+			action.Type = mapping.offAction
+		}
+	}
+
+	return &models.Button{
+		Name:   profileButtonName(key.Title, mapping.label),
+		Icon:   mapping.icon,
+		Color:  profileColor(key.Color, mapping.color),
+		Action: action,
+	}, ""
+}
+
+// profileSetting returns the first of names set in settings, as a string
+func profileSetting(settings map[string]interface{}, names []string) string {
+	for _, name := range names {
+		for key, value := range settings {
+			if !strings.EqualFold(key, name) {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				if v != "" {
+					return v
+				}
+			case bool:
+				return strconv.FormatBool(v)
+			}
+		}
+	}
+	return ""
+}
+
+// profileButtonName returns a key's title, or fallback for untitled keys
+func profileButtonName(title, fallback string) string {
+	if title = strings.Join(strings.Fields(title), " "); title != "" {
+		return title
+	}
+	return fallback
+}
+
+// profileColor returns a key's color if it is a #rrggbb value
+func profileColor(color, fallback string) string {
+	color = strings.TrimSpace(color)
+	if !strings.HasPrefix(color, "#") {
+		color = "#" + color
+	}
+	if len(color) == 7 {
+		if _, err := strconv.ParseUint(color[1:], 16, 32); err == nil {
+			return strings.ToLower(color)
+		}
+	}
+	return fallback
+}
+
+// profileFormatLabel names a profile format for descriptions
+func profileFormatLabel(format string) string {
+	switch format {
+	case models.ProfileFormatStreamPi:
+		return "Stream-Pi"
+	case models.ProfileFormatStreamDeck:
+		return "Stream Deck"
+	}
+	return format
+}
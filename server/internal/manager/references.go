@@ -0,0 +1,67 @@
+package manager
+
+import "github.com/robomon1/robo-stream/server/internal/models"
+
+// Action parameters that name OBS scenes, inputs and sources. A source is
+// either a scene or an input.
+var (
+	sceneParamNames  = []string{"scene_name"}
+	inputParamNames  = []string{"input_name"}
+	sourceParamNames = []string{"source_name"}
+)
+
+// objectRefs is the set of OBS scenes, inputs and sources something refers to
+type objectRefs struct {
+	Scenes  map[string]bool
+	Inputs  map[string]bool
+	Sources map[string]bool
+}
+
+// buttonRefs returns the scenes, inputs and sources a button's action, its
+// macro steps and its toggle state refer to
+func buttonRefs(btn *models.Button) objectRefs {
+	refs := objectRefs{
+		Scenes:  make(map[string]bool),
+		Inputs:  make(map[string]bool),
+		Sources: make(map[string]bool),
+	}
+	refs.addAction(btn.Action, 1)
+	if btn.State != nil {
+		refs.addParams(btn.State.Params)
+	}
+	return refs
+}
+
+// addAction adds an action's references, descending into macro steps
+func (refs objectRefs) addAction(action models.ButtonAction, depth int) {
+	refs.addParams(action.Params)
+	if action.Type != "macro" || depth > maxMacroDepth {
+		return
+	}
+	steps, err := parseMacroSteps(action.Params["steps"])
+	if err != nil {
+		return
+	}
+	for _, step := range steps {
+		refs.addAction(step.Action, depth+1)
+	}
+}
+
+// addParams adds the scenes, inputs and sources named in params
+func (refs objectRefs) addParams(params map[string]interface{}) {
+	for _, name := range sceneParamNames {
+		if scene, _ := params[name].(string); scene != "" {
+			refs.Scenes[scene] = true
+		}
+	}
+	for _, name := range inputParamNames {
+		if input, _ := params[name].(string); input != "" {
+			refs.Inputs[input] = true
+		}
+	}
+	for _, name := range sourceParamNames {
+		if source, _ := params[name].(string); source != "" {
+			refs.Sources[source] = true
+		}
+	}
+}
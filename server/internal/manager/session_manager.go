@@ -2,6 +2,7 @@ package manager
 
 import (
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,137 +12,158 @@ import (
 
 // SessionManager manages client sessions
 type SessionManager struct {
-	storage  *storage.Storage
+	store    *Store
 	sessions map[string]*models.ClientSession
 }
 
 // NewSessionManager creates a new SessionManager
-func NewSessionManager(storage *storage.Storage) *SessionManager {
+func NewSessionManager(store *Store) *SessionManager {
 	sm := &SessionManager{
-		storage:  storage,
+		store:    store,
 		sessions: make(map[string]*models.ClientSession),
 	}
-	sm.load()
+	if err := sm.load(); err != nil {
+		log.Printf("⚠️  Failed to load sessions: %v", err)
+	}
+	store.register(storage.KindSessions, sm.load)
 	return sm
 }
 
-// load reads sessions from storage
+// load reads sessions from the repository
 func (sm *SessionManager) load() error {
-	var sessions []*models.ClientSession
-	if err := sm.storage.LoadJSON("sessions.json", &sessions); err != nil {
+	sessions, err := sm.store.repo.LoadSessions()
+	if err != nil {
 		return err
 	}
+	sm.sessions = make(map[string]*models.ClientSession, len(sessions))
 	for _, sess := range sessions {
 		sm.sessions[sess.SessionID] = sess
 	}
 	return nil
 }
 
-// save writes sessions to storage
-func (sm *SessionManager) save() error {
-	sessions := make([]*models.ClientSession, 0, len(sm.sessions))
-	for _, sess := range sm.sessions {
-		sessions = append(sessions, sess)
-	}
-	return sm.storage.SaveJSON("sessions.json", sessions)
-}
-
 // RegisterOrUpdate creates a new session or updates existing one
 func (sm *SessionManager) RegisterOrUpdate(clientID, clientName, configID, ipAddress string) (*models.ClientSession, error) {
-	// Check if client already has a session
-	for _, sess := range sm.sessions {
-		if sess.ClientID == clientID {
-			// Update existing session
-			sess.ClientName = clientName
-			sess.IPAddress = ipAddress
-			sess.LastConnected = time.Now()
-			sess.LastActive = time.Now()
-			if configID != "" {
-				sess.ConfigID = configID
+	var session *models.ClientSession
+	err := sm.store.Update(func(tx *Tx) error {
+		// Check if client already has a session
+		for _, sess := range sm.sessions {
+			if sess.ClientID == clientID {
+				// Update existing session
+				sess.ClientName = clientName
+				sess.IPAddress = ipAddress
+				sess.LastConnected = time.Now()
+				sess.LastActive = time.Now()
+				if configID != "" {
+					sess.ConfigID = configID
+				}
+				tx.Put(storage.KindSessions, sess.SessionID, sess)
+				session = copySession(sess)
+				return nil
 			}
-			sm.save()
-			return sess, nil
 		}
-	}
 
-	// Create new session
-	session := &models.ClientSession{
-		SessionID:     uuid.New().String(),
-		ClientID:      clientID,
-		ClientName:    clientName,
-		ConfigID:      configID,
-		IPAddress:     ipAddress,
-		LastConnected: time.Now(),
-		LastActive:    time.Now(),
+		// Create new session
+		sess := &models.ClientSession{
+			SessionID:     uuid.New().String(),
+			ClientID:      clientID,
+			ClientName:    clientName,
+			ConfigID:      configID,
+			IPAddress:     ipAddress,
+			LastConnected: time.Now(),
+			LastActive:    time.Now(),
+		}
+		sm.sessions[sess.SessionID] = sess
+		tx.Put(storage.KindSessions, sess.SessionID, sess)
+		session = copySession(sess)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	sm.sessions[session.SessionID] = session
-	sm.save()
 	return session, nil
 }
 
 // Get retrieves a session by session ID
 func (sm *SessionManager) Get(sessionID string) (*models.ClientSession, error) {
-	sess, ok := sm.sessions[sessionID]
-	if !ok {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
-	}
-	return sess, nil
+	var session *models.ClientSession
+	err := sm.store.View(func() error {
+		sess, ok := sm.sessions[sessionID]
+		if !ok {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+		session = copySession(sess)
+		return nil
+	})
+	return session, err
 }
 
 // GetByClientID retrieves a session by client ID
 func (sm *SessionManager) GetByClientID(clientID string) (*models.ClientSession, error) {
-	for _, sess := range sm.sessions {
-		if sess.ClientID == clientID {
-			return sess, nil
+	var session *models.ClientSession
+	err := sm.store.View(func() error {
+		for _, sess := range sm.sessions {
+			if sess.ClientID == clientID {
+				session = copySession(sess)
+				return nil
+			}
 		}
-	}
-	return nil, fmt.Errorf("session not found for client: %s", clientID)
+		return fmt.Errorf("session not found for client: %s", clientID)
+	})
+	return session, err
 }
 
 // List returns all sessions
 func (sm *SessionManager) List() []*models.ClientSession {
-	sessions := make([]*models.ClientSession, 0, len(sm.sessions))
-	for _, sess := range sm.sessions {
-		sessions = append(sessions, sess)
-	}
+	sessions := make([]*models.ClientSession, 0)
+	sm.store.View(func() error {
+		for _, sess := range sm.sessions {
+			sessions = append(sessions, copySession(sess))
+		}
+		return nil
+	})
 	return sessions
 }
 
-// UpdateConfig updates the configuration for a session
-func (sm *SessionManager) UpdateConfig(sessionID, configID string) error {
-	sess, ok := sm.sessions[sessionID]
-	if !ok {
-		return fmt.Errorf("session not found: %s", sessionID)
-	}
-	sess.ConfigID = configID
-	sess.LastActive = time.Now()
-	return sm.save()
-}
-
 // UpdateActivity updates the last activity time for a session
 func (sm *SessionManager) UpdateActivity(sessionID string) error {
-	sess, ok := sm.sessions[sessionID]
-	if !ok {
-		return fmt.Errorf("session not found: %s", sessionID)
-	}
-	sess.LastActive = time.Now()
-	return sm.save()
+	return sm.store.Update(func(tx *Tx) error {
+		sess, ok := sm.sessions[sessionID]
+		if !ok {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+
+		tx.Put(storage.KindSessions, sessionID, sess)
+		sess.LastActive = time.Now()
+		return nil
+	})
 }
 
 // Delete removes a session
 func (sm *SessionManager) Delete(sessionID string) error {
-	delete(sm.sessions, sessionID)
-	return sm.save()
+	return sm.store.Update(func(tx *Tx) error {
+		tx.Delete(storage.KindSessions, sessionID)
+		delete(sm.sessions, sessionID)
+		return nil
+	})
 }
 
 // CleanupInactive removes sessions inactive for more than the specified duration
 func (sm *SessionManager) CleanupInactive(duration time.Duration) error {
-	cutoff := time.Now().Add(-duration)
-	for sessionID, sess := range sm.sessions {
-		if sess.LastActive.Before(cutoff) {
-			delete(sm.sessions, sessionID)
+	return sm.store.Update(func(tx *Tx) error {
+		cutoff := time.Now().Add(-duration)
+		for sessionID, sess := range sm.sessions {
+			if sess.LastActive.Before(cutoff) {
+				tx.Delete(storage.KindSessions, sessionID)
+				delete(sm.sessions, sessionID)
+			}
 		}
-	}
-	return sm.save()
+		return nil
+	})
+}
+
+// copySession returns a copy of a session
+func copySession(sess *models.ClientSession) *models.ClientSession {
+	out := *sess
+	return &out
 }
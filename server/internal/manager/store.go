@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+// Store guards the state of the button, configuration and session managers
+// with one lock, so operations that touch several of them see and leave a
+// consistent picture, and persists everything a transaction changed together.
+type Store struct {
+	repo    storage.Repository
+	mu      sync.RWMutex
+	reloads map[storage.Kind]func() error
+}
+
+// Tx records the records a Store.Update changes
+type Tx struct {
+	changes map[changeKey]interface{}
+}
+
+// changeKey identifies a record within a transaction
+type changeKey struct {
+	kind storage.Kind
+	id   string
+}
+
+// deleted marks a record a transaction removes
+type deleted struct{}
+
+// NewStore creates a Store saving through repo
+func NewStore(repo storage.Repository) *Store {
+	return &Store{
+		repo:    repo,
+		reloads: make(map[storage.Kind]func() error),
+	}
+}
+
+// register declares that a manager owns the records of kind. reload
+// replaces its in-memory state with what the repository holds.
+func (s *Store) register(kind storage.Kind, reload func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloads[kind] = reload
+}
+
+// View runs fn with shared access to manager state
+func (s *Store) View(fn func() error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn()
+}
+
+// Update runs fn with exclusive access to manager state, then writes every
+// record fn put or deleted in one repository Apply. If fn or the write
+// fails, the touched kinds are reloaded so memory never runs ahead of what
+// was persisted. fn must Put or Delete a record before it can return an
+// error after changing it.
+func (s *Store) Update(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Tx{changes: make(map[changeKey]interface{})}
+	err := fn(tx)
+	if err == nil && len(tx.changes) > 0 {
+		err = s.repo.Apply(tx.list())
+	}
+
+	if err != nil {
+		s.rollback(tx)
+	}
+	return err
+}
+
+// rollback reloads every kind tx touched
+func (s *Store) rollback(tx *Tx) {
+	kinds := make(map[storage.Kind]bool)
+	for key := range tx.changes {
+		kinds[key.kind] = true
+	}
+	for kind := range kinds {
+		if reload, ok := s.reloads[kind]; ok {
+			if err := reload(); err != nil {
+				log.Printf("⚠️  Failed to reload %s after a failed change: %v", kind, err)
+			}
+		}
+	}
+}
+
+// Put records that the transaction saves value as the record id. value is
+// encoded when the transaction commits, so later changes to it are included.
+func (tx *Tx) Put(kind storage.Kind, id string, value interface{}) {
+	tx.changes[changeKey{kind, id}] = value
+}
+
+// Delete records that the transaction removes the record id
+func (tx *Tx) Delete(kind storage.Kind, id string) {
+	tx.changes[changeKey{kind, id}] = deleted{}
+}
+
+// list returns the changes in a stable order
+func (tx *Tx) list() []storage.Change {
+	changes := make([]storage.Change, 0, len(tx.changes))
+	for key, value := range tx.changes {
+		if _, ok := value.(deleted); ok {
+			value = nil
+		}
+		changes = append(changes, storage.Change{Kind: key.kind, ID: key.id, Value: value})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].ID < changes[j].ID
+	})
+	return changes
+}
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// ButtonUsage reports where a button is placed and how often it is pressed
+func (cm *ConfigManager) ButtonUsage(id string) (*models.ButtonUsage, error) {
+	var usage *models.ButtonUsage
+	err := cm.store.View(func() error {
+		btn, ok := cm.buttonManager.buttons[id]
+		if !ok {
+			return fmt.Errorf("button not found: %s", id)
+		}
+		usage = cm.usageLocked(btn, cm.placementsLocked())
+		return nil
+	})
+	return usage, err
+}
+
+// Usage reports the usage of every button, sorted by name. With unusedOnly
+// it lists only buttons that are neither placed nor ever pressed.
+func (cm *ConfigManager) Usage(unusedOnly bool) []*models.ButtonUsage {
+	usages := make([]*models.ButtonUsage, 0)
+	cm.store.View(func() error {
+		placements := cm.placementsLocked()
+		for _, btn := range cm.buttonManager.buttons {
+			usage := cm.usageLocked(btn, placements)
+			if !unusedOnly || usage.Unused() {
+				usages = append(usages, usage)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(usages, func(i, j int) bool {
+		a, b := strings.ToLower(usages[i].Name), strings.ToLower(usages[j].Name)
+		if a != b {
+			return a < b
+		}
+		return usages[i].ID < usages[j].ID
+	})
+	return usages
+}
+
+// usageLocked builds a button's usage from the configurations placing it
+// and its press counts. Caller must hold the store lock.
+func (cm *ConfigManager) usageLocked(btn *models.Button, placements map[string][]models.Dependent) *models.ButtonUsage {
+	usage := &models.ButtonUsage{
+		ID:             btn.ID,
+		Name:           btn.Name,
+		Configurations: placements[btn.ID],
+	}
+	if usage.Configurations == nil {
+		usage.Configurations = make([]models.Dependent, 0)
+	}
+	if stat, ok := cm.buttonManager.stats[btn.ID]; ok {
+		usage.PressCount = stat.PressCount
+		if stat.LastPressedAt != nil {
+			last := *stat.LastPressedAt
+			usage.LastPressedAt = &last
+		}
+	}
+	return usage
+}
+
+// PressedButton works out which library button a session pressed to run an
+// action. position is the key of the pressed cell, as sent in resolved
+// configurations; without one the button is found by its action, as long as
+// only one button in the configuration performs it. It returns "" when the
+// press can't be attributed.
+func (cm *ConfigManager) PressedButton(configID, position string, action models.ButtonAction) string {
+	buttonID := ""
+	cm.store.View(func() error {
+		cfg, ok := cm.configs[configID]
+		if !ok {
+			return nil
+		}
+
+		if position != "" {
+			if page, row, col, err := models.ParsePosition(position); err == nil {
+				if id, ok := cfg.Buttons[models.PositionKey(page, row, col)]; ok {
+					if btn, ok := cm.buttonManager.buttons[id]; ok && sameAction(btn.Action, action) {
+						buttonID = id
+					}
+				}
+			}
+			return nil
+		}
+
+		for _, id := range cfg.Buttons {
+			btn, ok := cm.buttonManager.buttons[id]
+			if !ok || !sameAction(btn.Action, action) {
+				continue
+			}
+			if buttonID != "" && buttonID != id {
+				buttonID = "" // ambiguous
+				return nil
+			}
+			buttonID = id
+		}
+		return nil
+	})
+	return buttonID
+}
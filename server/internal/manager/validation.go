@@ -0,0 +1,287 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+	"github.com/robomon1/robo-stream/server/internal/storage"
+)
+
+// OBSObjectLister lists the scenes and inputs that exist in OBS
+type OBSObjectLister interface {
+	GetScenes() ([]string, error)
+	GetInputs() ([]string, error)
+}
+
+// obsObjects is a snapshot of the scene and input names in OBS
+type obsObjects struct {
+	scenes map[string]bool
+	inputs map[string]bool
+}
+
+// listObjects takes a snapshot of the objects lister knows about
+func listObjects(lister OBSObjectLister) (*obsObjects, error) {
+	scenes, err := lister.GetScenes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenes: %w", err)
+	}
+	inputs, err := lister.GetInputs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inputs: %w", err)
+	}
+
+	objects := &obsObjects{
+		scenes: make(map[string]bool, len(scenes)),
+		inputs: make(map[string]bool, len(inputs)),
+	}
+	for _, name := range scenes {
+		objects.scenes[name] = true
+	}
+	for _, name := range inputs {
+		objects.inputs[name] = true
+	}
+	return objects, nil
+}
+
+// check returns an issue for every reference to an object OBS doesn't have,
+// sorted by kind and name
+func (objects *obsObjects) check(refs objectRefs) []models.ButtonIssue {
+	issues := make([]models.ButtonIssue, 0)
+	for name := range refs.Scenes {
+		if !objects.scenes[name] {
+			issues = append(issues, models.ButtonIssue{
+				Kind:    models.ObjectScene,
+				Name:    name,
+				Message: fmt.Sprintf("scene %q does not exist in OBS", name),
+			})
+		}
+	}
+	for name := range refs.Inputs {
+		if !objects.inputs[name] {
+			issues = append(issues, models.ButtonIssue{
+				Kind:    models.ObjectInput,
+				Name:    name,
+				Message: fmt.Sprintf("input %q does not exist in OBS", name),
+			})
+		}
+	}
+	for name := range refs.Sources {
+		if !objects.scenes[name] && !objects.inputs[name] {
+			issues = append(issues, models.ButtonIssue{
+				Kind:    models.ObjectSource,
+				Name:    name,
+				Message: fmt.Sprintf("source %q does not exist in OBS", name),
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		return issues[i].Name < issues[j].Name
+	})
+	return issues
+}
+
+// SetObjectLister sets where buttons' scene and input references are
+// checked. Resolve flags broken buttons only once it is set.
+func (cm *ConfigManager) SetObjectLister(lister OBSObjectLister) {
+	cm.objectLister = lister
+}
+
+// ValidateButtons checks every library button's scene, input and source
+// references against OBS. It fails when OBS can't be asked.
+func (cm *ConfigManager) ValidateButtons() (*models.ValidationReport, error) {
+	if cm.objectLister == nil {
+		return nil, fmt.Errorf("no OBS connection to validate against")
+	}
+	objects, err := listObjects(cm.objectLister)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ValidationReport{Broken: make([]models.ButtonValidation, 0)}
+	cm.store.View(func() error {
+		for _, btn := range cm.buttonManager.buttons {
+			report.Checked++
+			if issues := objects.check(buttonRefs(btn)); len(issues) > 0 {
+				report.Broken = append(report.Broken, models.ButtonValidation{
+					ID:     btn.ID,
+					Name:   btn.Name,
+					Issues: issues,
+				})
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(report.Broken, func(i, j int) bool {
+		a, b := strings.ToLower(report.Broken[i].Name), strings.ToLower(report.Broken[j].Name)
+		if a != b {
+			return a < b
+		}
+		return report.Broken[i].ID < report.Broken[j].ID
+	})
+	return report, nil
+}
+
+// flagBroken marks the resolved buttons whose references OBS doesn't have.
+// refs holds each button's references by position. Nothing is flagged when
+// OBS can't be asked, since every button would look broken.
+func (cm *ConfigManager) flagBroken(resolved *models.ResolvedConfiguration, refs map[string]objectRefs) {
+	if cm.objectLister == nil {
+		return
+	}
+	objects, err := listObjects(cm.objectLister)
+	if err != nil {
+		return
+	}
+
+	for p := range resolved.Pages {
+		for i := range resolved.Pages[p].Buttons {
+			btn := &resolved.Pages[p].Buttons[i]
+			if issues := objects.check(refs[btn.ID]); len(issues) > 0 {
+				btn.Broken = true
+				btn.Issues = issues
+			}
+		}
+	}
+}
+
+// RenameScene rewrites references to a scene renamed in OBS, in every
+// button's action, macro steps and toggle state. It returns how many
+// buttons changed.
+func (bm *ButtonManager) RenameScene(oldName, newName string) (int, error) {
+	return bm.renameReferences(append(sceneParamNames, sourceParamNames...), oldName, newName)
+}
+
+// RenameInput rewrites references to an input renamed in OBS, like
+// RenameScene
+func (bm *ButtonManager) RenameInput(oldName, newName string) (int, error) {
+	return bm.renameReferences(append(inputParamNames, sourceParamNames...), oldName, newName)
+}
+
+// renameReferences replaces oldName with newName in the named params of
+// every button, saving the changed buttons together
+func (bm *ButtonManager) renameReferences(paramNames []string, oldName, newName string) (int, error) {
+	if oldName == "" || newName == "" || oldName == newName {
+		return 0, nil
+	}
+
+	changed := make([]string, 0)
+	err := bm.store.Update(func(tx *Tx) error {
+		for id, btn := range bm.buttons {
+			out := copyButton(btn)
+			actionParams, actionChanged := renameParams(out.Action.Params, paramNames, oldName, newName)
+			stateChanged := false
+			if out.State != nil {
+				out.State.Params, stateChanged = renameParams(out.State.Params, paramNames, oldName, newName)
+			}
+			if !actionChanged && !stateChanged {
+				continue
+			}
+
+			out.Action.Params = actionParams
+			out.UpdatedAt = time.Now()
+			bm.buttons[id] = out
+			tx.Put(storage.KindButtons, id, out)
+			changed = append(changed, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if bm.onUpdate != nil {
+		for _, id := range changed {
+			bm.onUpdate(id)
+		}
+	}
+	return len(changed), nil
+}
+
+// renameParams returns params with oldName replaced by newName in the named
+// params, descending into macro steps. Changed maps and lists are copied, so
+// params itself is never modified.
+func renameParams(params map[string]interface{}, paramNames []string, oldName, newName string) (map[string]interface{}, bool) {
+	var out map[string]interface{}
+	set := func(key string, value interface{}) {
+		if out == nil {
+			out = copyParams(params)
+		}
+		out[key] = value
+	}
+
+	for _, name := range paramNames {
+		if value, _ := params[name].(string); value == oldName {
+			set(name, newName)
+		}
+	}
+
+	if steps, ok := params["steps"].([]interface{}); ok {
+		var newSteps []interface{}
+		for i, raw := range steps {
+			step, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			action, ok := step["action"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			stepParams, _ := action["params"].(map[string]interface{})
+			renamed, changed := renameParams(stepParams, paramNames, oldName, newName)
+			if !changed {
+				continue
+			}
+
+			if newSteps == nil {
+				newSteps = append([]interface{}(nil), steps...)
+			}
+			newAction := copyParams(action)
+			newAction["params"] = renamed
+			newStep := copyParams(step)
+			newStep["action"] = newAction
+			newSteps[i] = newStep
+		}
+		if newSteps != nil {
+			set("steps", newSteps)
+		}
+	}
+
+	if out == nil {
+		return params, false
+	}
+	return out, true
+}
+
+// FollowRenames keeps button references current as scenes and inputs are
+// renamed in OBS. It returns a function that stops following.
+func (bm *ButtonManager) FollowRenames(om *OBSManager) func() {
+	return om.Subscribe(func(event models.OBSEvent) {
+		var rename func(oldName, newName string) (int, error)
+		switch event.Type {
+		case models.EventSceneRenamed:
+			rename = bm.RenameScene
+		case models.EventInputRenamed:
+			rename = bm.RenameInput
+		default:
+			return
+		}
+
+		oldName, _ := event.Data["old_name"].(string)
+		newName, _ := event.Data["new_name"].(string)
+		count, err := rename(oldName, newName)
+		if err != nil {
+			log.Printf("⚠️  Failed to follow rename of %s to %s: %v", oldName, newName, err)
+		} else if count > 0 {
+			log.Printf("✏️  Renamed %s to %s in %d buttons", oldName, newName, count)
+		}
+	})
+}
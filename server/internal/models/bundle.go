@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// BundleVersion is the bundle format version written by this server
+const BundleVersion = 1
+
+// Bundle is a set of configurations and the buttons they place, for moving
+// a deck between servers
+type Bundle struct {
+	Version        int              `json:"version"`
+	ExportedAt     time.Time        `json:"exported_at"`
+	Configurations []*Configuration `json:"configurations"`
+	Buttons        []*Button        `json:"buttons"`
+	Icons          []string         `json:"icons"` // icon names the buttons use
+}
+
+// ConflictMode says what an import does with a record that already exists
+type ConflictMode string
+
+const (
+	ConflictSkip      ConflictMode = "skip"      // keep the existing record and use it (default)
+	ConflictOverwrite ConflictMode = "overwrite" // replace the existing record's contents
+	ConflictDuplicate ConflictMode = "duplicate" // import a copy with a new ID
+)
+
+// BundleImportOptions controls how a bundle is imported
+type BundleImportOptions struct {
+	Conflict ConflictMode `json:"conflict,omitempty"`
+	DryRun   bool         `json:"dry_run,omitempty"`
+}
+
+// Results of importing one bundle record
+const (
+	ImportCreated     = "created"
+	ImportOverwritten = "overwritten"
+	ImportSkipped     = "skipped"
+	ImportDuplicated  = "duplicated"
+)
+
+// BundleImportItem is what an import did, or would do, with one record
+type BundleImportItem struct {
+	SourceID   string `json:"source_id"` // ID in the bundle
+	ID         string `json:"id"`        // ID in this library
+	Name       string `json:"name"`
+	Result     string `json:"result"`
+	ConflictID string `json:"conflict_id,omitempty"` // existing record it matched
+}
+
+// BundleImportReport describes an import. On a dry run nothing is saved.
+type BundleImportReport struct {
+	DryRun         bool               `json:"dry_run"`
+	Conflict       ConflictMode       `json:"conflict"`
+	Configurations []BundleImportItem `json:"configurations"`
+	Buttons        []BundleImportItem `json:"buttons"`
+	Warnings       []string           `json:"warnings"`
+}
@@ -10,8 +10,14 @@ type Button struct {
 	Icon        string       `json:"icon"`
 	Color       string       `json:"color"`
 	Action      ButtonAction `json:"action"`
+	State       *ButtonState `json:"state,omitempty"` // makes the button a toggle
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// Category and Tags organize the library for searching; they don't
+	// change what the button does
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
 // ButtonAction defines what the button does
@@ -19,3 +25,48 @@ type ButtonAction struct {
 	Type   string                 `json:"type"`
 	Params map[string]interface{} `json:"params,omitempty"`
 }
+
+// Button state sources a toggle button can follow
+const (
+	StateSourceStreamActive       = "stream_active"
+	StateSourceRecordActive       = "record_active"
+	StateSourceRecordPaused       = "record_paused"
+	StateSourceInputMuted         = "input_muted"   // params: input_name
+	StateSourceCurrentScene       = "current_scene" // params: scene_name
+	StateSourceVirtualcamActive   = "virtualcam_active"
+	StateSourceReplayBufferActive = "replay_buffer_active"
+	StateSourceStudioMode         = "studio_mode"
+	StateSourcePreviewScene       = "preview_scene" // params: scene_name
+	StateSourceMediaPlaying       = "media_playing" // params: input_name
+
+	// Params: source_name, and scene_name or target_scene as the source
+	// actions take them
+	StateSourceSourceVisible = "source_visible"
+	StateSourceSourceLocked  = "source_locked"
+)
+
+// ButtonState ties a button's appearance to OBS state. While the source is
+// on, the On* fields replace the button's text, icon and color; empty fields
+// keep the normal appearance.
+type ButtonState struct {
+	Source  string                 `json:"source"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	OnText  string                 `json:"on_text,omitempty"`
+	OnIcon  string                 `json:"on_icon,omitempty"`
+	OnColor string                 `json:"on_color,omitempty"`
+}
+
+// MacroStep is one action of a macro, run after an optional delay
+type MacroStep struct {
+	Action  ButtonAction `json:"action"`
+	DelayMs int          `json:"delay_ms,omitempty"`
+}
+
+// MacroStepResult reports the outcome of one macro step
+type MacroStepResult struct {
+	Step   int         `json:"step"`
+	Type   string      `json:"type"`
+	Status string      `json:"status"` // ok, failed or skipped
+	Error  string      `json:"error,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// Sort orders for button searches
+const (
+	ButtonSortName     = "name" // default
+	ButtonSortCategory = "category"
+	ButtonSortAction   = "action"
+	ButtonSortCreated  = "created"
+	ButtonSortUpdated  = "updated"
+)
+
+// ButtonQuery filters, sorts and pages the button library. Empty fields
+// match every button.
+type ButtonQuery struct {
+	Text       string   `json:"text,omitempty"` // in the name or description, ignoring case
+	ActionType string   `json:"action_type,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Tags       []string `json:"tags,omitempty"`  // buttons must carry all of them
+	Scene      string   `json:"scene,omitempty"` // scene the button refers to, also in macro steps and toggle states
+	Input      string   `json:"input,omitempty"` // input the button refers to, likewise
+	Sort       string   `json:"sort,omitempty"`
+	Desc       bool     `json:"desc,omitempty"`
+	Offset     int      `json:"offset,omitempty"`
+	Limit      int      `json:"limit,omitempty"` // 0 returns every match
+}
+
+// ButtonSearchResult is one page of buttons matching a ButtonQuery
+type ButtonSearchResult struct {
+	Buttons []*Button `json:"buttons"`
+	Total   int       `json:"total"` // matches before paging
+	Offset  int       `json:"offset"`
+	Limit   int       `json:"limit"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ButtonStats counts the presses of a library button
+type ButtonStats struct {
+	ButtonID      string     `json:"button_id"`
+	PressCount    int        `json:"press_count"`
+	LastPressedAt *time.Time `json:"last_pressed_at,omitempty"`
+}
+
+// ButtonUsage reports where a library button is placed and how often it is
+// pressed, so unused buttons can be found
+type ButtonUsage struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	Configurations []Dependent `json:"configurations"` // configurations placing it
+	PressCount     int         `json:"press_count"`
+	LastPressedAt  *time.Time  `json:"last_pressed_at,omitempty"`
+}
+
+// Unused reports whether the button is neither placed nor ever pressed
+func (u *ButtonUsage) Unused() bool {
+	return len(u.Configurations) == 0 && u.PressCount == 0
+}
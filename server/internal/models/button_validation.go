@@ -0,0 +1,29 @@
+package models
+
+// Kinds of OBS object a button can refer to
+const (
+	ObjectScene  = "scene"
+	ObjectInput  = "input"
+	ObjectSource = "source" // a scene or an input
+)
+
+// ButtonIssue is a reference from a button to an OBS object that doesn't
+// exist, so pressing the button would fail
+type ButtonIssue struct {
+	Kind    string `json:"kind"` // scene, input or source
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// ButtonValidation lists the broken references of one library button
+type ButtonValidation struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Issues []ButtonIssue `json:"issues"`
+}
+
+// ValidationReport is the result of checking the library against OBS
+type ValidationReport struct {
+	Checked int                `json:"checked"` // buttons checked
+	Broken  []ButtonValidation `json:"broken"`  // buttons with issues, sorted by name
+}
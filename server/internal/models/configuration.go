@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Configuration represents a button layout for a specific role/client
 type Configuration struct {
@@ -8,10 +13,53 @@ type Configuration struct {
 	Name        string            `json:"name"`
 	Description string            `json:"description"`
 	Grid        GridConfig        `json:"grid"`
-	Buttons     map[string]string `json:"buttons"` // position (btn-0-0) -> button ID
+	Buttons     map[string]string `json:"buttons"` // position (btn-<page>-<row>-<col>) -> button ID
+	Pages       []Page            `json:"pages,omitempty"`
 	IsDefault   bool              `json:"is_default"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
+
+	// AllowedActions lists action types sessions on this configuration may
+	// execute even when no button in it performs them
+	AllowedActions []string `json:"allowed_actions,omitempty"`
+
+	// AllowSwitching lets sessions on this configuration move themselves to
+	// another one. Otherwise only the operator can reassign them.
+	AllowSwitching bool `json:"allow_switching,omitempty"`
+}
+
+// Page is one screen of buttons in a configuration. Its index in
+// Configuration.Pages is the page number used in position keys.
+type Page struct {
+	Name string `json:"name"`
+}
+
+// PositionKey builds the Buttons key for a grid cell
+func PositionKey(page, row, col int) string {
+	return fmt.Sprintf("btn-%d-%d-%d", page, row, col)
+}
+
+// ParsePosition parses a Buttons key. Keys without a page (btn-<row>-<col>,
+// written before pages existed) are on page 0.
+func ParsePosition(key string) (page, row, col int, err error) {
+	parts := strings.Split(key, "-")
+	if parts[0] != "btn" || (len(parts) != 3 && len(parts) != 4) {
+		return 0, 0, 0, fmt.Errorf("invalid position: %s", key)
+	}
+
+	nums := make([]int, 0, 3)
+	for _, part := range parts[1:] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, 0, 0, fmt.Errorf("invalid position: %s", key)
+		}
+		nums = append(nums, n)
+	}
+
+	if len(nums) == 2 {
+		return 0, nums[0], nums[1], nil
+	}
+	return nums[0], nums[1], nums[2], nil
 }
 
 // GridConfig defines the button grid size
@@ -22,19 +70,45 @@ type GridConfig struct {
 
 // ResolvedConfiguration is what gets sent to clients with full button details
 type ResolvedConfiguration struct {
-	ID      string            `json:"id"`
-	Name    string            `json:"name"`
-	Grid    GridConfig        `json:"grid"`
-	Buttons []ResolvedButton  `json:"buttons"`
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Grid    GridConfig       `json:"grid"`
+	Buttons []ResolvedButton `json:"buttons"` // first page, for clients without page support
+	Pages   []ResolvedPage   `json:"pages"`
+}
+
+// ResolvedPage is one page of a resolved configuration
+type ResolvedPage struct {
+	Index   int              `json:"index"`
+	Name    string           `json:"name"`
+	Buttons []ResolvedButton `json:"buttons"`
 }
 
 // ResolvedButton is a button with position information for the client
 type ResolvedButton struct {
 	ID     string       `json:"id"`
+	Page   int          `json:"page"`
 	Row    int          `json:"row"`
 	Col    int          `json:"col"`
 	Text   string       `json:"text"`
 	Icon   string       `json:"icon"`
 	Color  string       `json:"color"`
 	Action ButtonAction `json:"action"`
+	Toggle bool         `json:"toggle,omitempty"` // appearance follows OBS state
+	Active bool         `json:"active,omitempty"`
+
+	// Broken is set when the button refers to scenes or inputs OBS doesn't
+	// have; Issues says which
+	Broken bool          `json:"broken,omitempty"`
+	Issues []ButtonIssue `json:"issues,omitempty"`
+}
+
+// ButtonStateUpdate is the live appearance of a toggle button, sent with
+// status so clients can re-render it without their own state logic
+type ButtonStateUpdate struct {
+	ID     string `json:"id"` // position, as in ResolvedButton
+	Active bool   `json:"active"`
+	Text   string `json:"text"`
+	Icon   string `json:"icon"`
+	Color  string `json:"color"`
 }
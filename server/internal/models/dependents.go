@@ -0,0 +1,45 @@
+package models
+
+// DeleteMode says what happens to the things that refer to a deleted button
+// or configuration
+type DeleteMode string
+
+const (
+	DeleteRestrict DeleteMode = "restrict" // refuse while anything refers to it (default)
+	DeleteCascade  DeleteMode = "cascade"  // drop the references: unplace the button, end the sessions
+	DeleteReassign DeleteMode = "reassign" // point the references at ReplaceWith
+)
+
+// DeleteOptions controls how a delete treats dependents
+type DeleteOptions struct {
+	Mode DeleteMode `json:"mode,omitempty"`
+	// ReplaceWith is the button or configuration that takes over in reassign
+	// mode. When deleting the default configuration it also becomes the new
+	// default; without it sessions are reassigned to the default.
+	ReplaceWith string `json:"replace_with,omitempty"`
+}
+
+// Dependent is a configuration or session that refers to an item
+type Dependent struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Positions []string `json:"positions,omitempty"` // where a button is placed
+}
+
+// DeleteReport lists what refers to a button or configuration and, after a
+// delete, what was done about it
+type DeleteReport struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	IsDefault      bool        `json:"is_default,omitempty"`
+	Configurations []Dependent `json:"configurations"` // configurations placing the button
+	Sessions       []Dependent `json:"sessions"`       // sessions using the configuration
+	Deleted        bool        `json:"deleted"`
+	ReplacedWith   string      `json:"replaced_with,omitempty"`
+	NewDefault     string      `json:"new_default,omitempty"`
+}
+
+// HasDependents reports whether anything refers to the item
+func (r *DeleteReport) HasDependents() bool {
+	return len(r.Configurations) > 0 || len(r.Sessions) > 0
+}
@@ -0,0 +1,42 @@
+package models
+
+// Outcomes of checking a button's action without running it
+const (
+	CheckOK            = "ok"
+	CheckMissingScene  = "missing_scene"
+	CheckMissingInput  = "missing_input"
+	CheckMissingSource = "missing_source"
+	CheckUnknownAction = "unknown_action"
+	CheckInvalidParams = "invalid_params"
+)
+
+// ActionCheck is the outcome of a dry run of one action
+type ActionCheck struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// OK reports whether the action would run
+func (c ActionCheck) OK() bool {
+	return c.Status == CheckOK
+}
+
+// ButtonCheck is the dry run of one button of a configuration
+type ButtonCheck struct {
+	ID     string `json:"id"` // position, as in ResolvedButton
+	Page   int    `json:"page"`
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Text   string `json:"text"`
+	Action string `json:"action"`
+	ActionCheck
+}
+
+// ConfigurationTest reports a dry run of every button of a configuration
+type ConfigurationTest struct {
+	ConfigID string        `json:"config_id"`
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`     // every button passed
+	Failed   int           `json:"failed"` // buttons that did not
+	Buttons  []ButtonCheck `json:"buttons"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Playback states of a media input
+const (
+	MediaPlaying   = "playing"
+	MediaPaused    = "paused"
+	MediaStopped   = "stopped"
+	MediaEnded     = "ended"
+	MediaOpening   = "opening"
+	MediaBuffering = "buffering"
+	MediaError     = "error"
+	MediaNone      = "none"
+)
+
+// MediaStatus is the playback state of a media input, such as a video
+// source. Cursor and duration are only known while playing or paused.
+type MediaStatus struct {
+	InputName  string  `json:"input_name"`
+	State      string  `json:"state"`
+	CursorMs   float64 `json:"cursor_ms"`
+	DurationMs float64 `json:"duration_ms"`
+	Time       string  `json:"time"` // "02:13 / 05:00", or "" while not playing or paused
+}
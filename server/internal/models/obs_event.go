@@ -0,0 +1,45 @@
+package models
+
+// Event types pushed to clients over the event stream
+const (
+	EventStatus                   = "status"
+	EventSceneChanged             = "scene_changed"
+	EventStreamStateChanged       = "stream_state_changed"
+	EventRecordStateChanged       = "record_state_changed"
+	EventInputMuteChanged         = "input_mute_changed"
+	EventConnectionChanged        = "connection_changed"
+	EventVirtualcamStateChanged   = "virtualcam_state_changed"
+	EventReplayBufferStateChanged = "replay_buffer_state_changed"
+	EventReplayBufferSaved        = "replay_buffer_saved" // data: path
+
+	// EventStudioModeChanged carries enabled, EventPreviewSceneChanged
+	// scene_name, and EventTransitionChanged transition_name and duration_ms
+	EventStudioModeChanged   = "studio_mode_changed"
+	EventPreviewSceneChanged = "preview_scene_changed"
+	EventTransitionChanged   = "transition_changed"
+
+	// EventMediaStateChanged carries input_name and action: play, pause,
+	// stop, restart, next or previous when one is triggered, and started or
+	// ended as playback starts and ends
+	EventMediaStateChanged = "media_state_changed"
+
+	// EventSourceVisibilityChanged and EventSourceLockChanged carry
+	// scene_name, scene_item_id and visible or locked
+	EventSourceVisibilityChanged = "source_visibility_changed"
+	EventSourceLockChanged       = "source_lock_changed"
+
+	// EventSceneRenamed and EventInputRenamed carry old_name and new_name
+	EventSceneRenamed = "scene_renamed"
+	EventInputRenamed = "input_renamed"
+
+	// EventConfigChanged tells a session its configuration was reassigned or
+	// edited; data carries config_id and reason
+	EventConfigChanged = "config_changed"
+)
+
+// OBSEvent is a state change pushed to clients over the event stream
+type OBSEvent struct {
+	Type   string                 `json:"type"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Status map[string]interface{} `json:"status,omitempty"` // full status snapshot after the change
+}
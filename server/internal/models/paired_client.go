@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PairedClient is a client device that has been paired with the server and
+// holds an API token. Only a hash of the token is kept.
+type PairedClient struct {
+	ID         string    `json:"id"`
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	IPAddress  string    `json:"ip_address"`
+	TokenHash  string    `json:"token_hash,omitempty"`
+	PairedAt   time.Time `json:"paired_at"`
+}
+
+// PairingCode is a short-lived, single-use code the server shows so a
+// client can be paired
+type PairingCode struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// Deck profile formats the importer reads
+const (
+	ProfileFormatStreamPi   = "stream-pi"   // Stream-Pi client profile, XML or JSON
+	ProfileFormatStreamDeck = "stream-deck" // Elgato .streamDeckProfile archive
+)
+
+// Profile is a deck profile read from another application, before its
+// actions are mapped to ours
+type Profile struct {
+	Format string
+	Name   string
+	Grid   GridConfig
+	Pages  []ProfilePage // the first is the top level; folders open the others
+}
+
+// ProfilePage is one screen of a profile
+type ProfilePage struct {
+	Name string
+	Keys []ProfileKey
+}
+
+// ProfileKey is one key of a profile page as the other application stores it
+type ProfileKey struct {
+	Row      int
+	Col      int
+	Title    string
+	Color    string
+	ActionID string                 // plugin UUID or module name
+	Settings map[string]interface{} // action settings
+	Folder   int                    // page a folder key opens; 0 if not a folder
+}
+
+// UnmappedAction is a profile key the importer could not turn into a button
+type UnmappedAction struct {
+	Page     int    `json:"page"`
+	Row      int    `json:"row"`
+	Col      int    `json:"col"`
+	Title    string `json:"title"`
+	ActionID string `json:"action_id"`
+	Reason   string `json:"reason"`
+}
+
+// ProfileImportReport describes a profile import: what the profile held,
+// what could not be mapped and what the bundle import did with the rest
+type ProfileImportReport struct {
+	Format   string              `json:"format"`
+	Name     string              `json:"name"`
+	Unmapped []UnmappedAction    `json:"unmapped"`
+	Import   *BundleImportReport `json:"import"`
+}
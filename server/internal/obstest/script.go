@@ -0,0 +1,386 @@
+package obstest
+
+import "github.com/andreykaipov/goobs/api/typedefs"
+
+// AddScene creates a scene holding sources, in order, as OBS does when a
+// scene is added in its UI. The first scene added becomes the current scene.
+func (s *Server) AddScene(name string, sources ...string) {
+	s.update(func(st *state) []event {
+		sc := &scene{name: name, uuid: newUUID()}
+		for _, source := range sources {
+			sc.items = append(sc.items, &SceneItem{
+				ID:        st.nextItemID,
+				Source:    source,
+				Enabled:   true,
+				Transform: typedefs.SceneItemTransform{ScaleX: 1, ScaleY: 1, BoundsType: "OBS_BOUNDS_NONE"},
+			})
+			st.nextItemID++
+		}
+		st.scenes = append(st.scenes, sc)
+
+		events := []event{{"SceneCreated", map[string]interface{}{
+			"sceneName": name,
+			"sceneUuid": sc.uuid,
+			"isGroup":   false,
+		}}}
+		if st.currentScene == "" {
+			events = append(events, st.setCurrentScene(name)...)
+		}
+		return events
+	})
+}
+
+// RemoveScene deletes a scene and every item that shows it in another scene
+func (s *Server) RemoveScene(name string) {
+	s.update(func(st *state) []event {
+		sc, err := st.findScene(name)
+		if err != nil {
+			return nil
+		}
+		kept := st.scenes[:0]
+		for _, other := range st.scenes {
+			if other != sc {
+				kept = append(kept, other)
+			}
+		}
+		st.scenes = kept
+		st.removeItems(name)
+
+		events := []event{{"SceneRemoved", map[string]interface{}{
+			"sceneName": name,
+			"sceneUuid": sc.uuid,
+			"isGroup":   false,
+		}}}
+		if st.currentScene == name {
+			st.currentScene = ""
+			if len(st.scenes) > 0 {
+				events = append(events, st.setCurrentScene(st.scenes[0].name)...)
+			}
+		}
+		if st.studioMode && st.previewScene == name {
+			events = append(events, st.setPreviewScene(st.currentScene)...)
+		}
+		return events
+	})
+}
+
+// RenameScene renames a scene, as when it is renamed in OBS
+func (s *Server) RenameScene(oldName, newName string) {
+	s.update(func(st *state) []event {
+		sc, err := st.findScene(oldName)
+		if err != nil {
+			return nil
+		}
+		sc.name = newName
+		st.renameItems(oldName, newName)
+		if st.currentScene == oldName {
+			st.currentScene = newName
+		}
+		if st.previewScene == oldName {
+			st.previewScene = newName
+		}
+		return []event{{"SceneNameChanged", map[string]interface{}{
+			"sceneUuid":    sc.uuid,
+			"oldSceneName": oldName,
+			"sceneName":    newName,
+		}}}
+	})
+}
+
+// AddInput creates an unmuted input of kind, such as "wasapi_input_capture"
+func (s *Server) AddInput(name, kind string) {
+	s.update(func(st *state) []event {
+		in := &input{name: name, kind: kind, uuid: newUUID(), volumeMul: 1}
+		st.inputs = append(st.inputs, in)
+		return []event{{"InputCreated", map[string]interface{}{
+			"inputName":            name,
+			"inputUuid":            in.uuid,
+			"inputKind":            kind,
+			"unversionedInputKind": kind,
+			"inputSettings":        map[string]interface{}{},
+			"defaultInputSettings": map[string]interface{}{},
+		}}}
+	})
+}
+
+// AddMediaInput creates a stopped media source whose media lasts durationMs
+func (s *Server) AddMediaInput(name string, durationMs float64) {
+	s.AddInput(name, "ffmpeg_source")
+	s.update(func(st *state) []event {
+		if in, err := st.findInput(name); err == nil {
+			in.media = &media{state: MediaStopped, duration: durationMs}
+		}
+		return nil
+	})
+}
+
+// RemoveInput deletes an input and its items in every scene
+func (s *Server) RemoveInput(name string) {
+	s.update(func(st *state) []event {
+		in, err := st.findInput(name)
+		if err != nil {
+			return nil
+		}
+		kept := st.inputs[:0]
+		for _, other := range st.inputs {
+			if other != in {
+				kept = append(kept, other)
+			}
+		}
+		st.inputs = kept
+		st.removeItems(name)
+		return []event{{"InputRemoved", map[string]interface{}{
+			"inputName": name,
+			"inputUuid": in.uuid,
+		}}}
+	})
+}
+
+// RenameInput renames an input, as when it is renamed in OBS
+func (s *Server) RenameInput(oldName, newName string) {
+	s.update(func(st *state) []event {
+		in, err := st.findInput(oldName)
+		if err != nil {
+			return nil
+		}
+		in.name = newName
+		st.renameItems(oldName, newName)
+		return []event{{"InputNameChanged", map[string]interface{}{
+			"inputUuid":    in.uuid,
+			"oldInputName": oldName,
+			"inputName":    newName,
+		}}}
+	})
+}
+
+// SetCurrentScene switches the program scene, as when it is clicked in OBS
+func (s *Server) SetCurrentScene(name string) {
+	s.update(func(st *state) []event {
+		if _, err := st.findScene(name); err != nil {
+			return nil
+		}
+		return st.setCurrentScene(name)
+	})
+}
+
+// EndMedia plays a media input to its end, as when its media runs out
+func (s *Server) EndMedia(name string) {
+	s.update(func(st *state) []event {
+		in, err := st.findInput(name)
+		if err != nil || in.media == nil {
+			return nil
+		}
+		in.media.state, in.media.cursor = MediaEnded, 0
+		return []event{{"MediaInputPlaybackEnded", map[string]interface{}{
+			"inputName": in.name,
+			"inputUuid": in.uuid,
+		}}}
+	})
+}
+
+// SetStudioMode turns studio mode on or off, as when it is done from OBS
+func (s *Server) SetStudioMode(enabled bool) {
+	s.update(func(st *state) []event { return st.setStudioMode(enabled) })
+}
+
+// SetInputMuted mutes or unmutes an input
+func (s *Server) SetInputMuted(name string, muted bool) {
+	s.update(func(st *state) []event {
+		in, err := st.findInput(name)
+		if err != nil {
+			return nil
+		}
+		return st.setInputMuted(in, muted)
+	})
+}
+
+// SetInputVolume sets an input's volume multiplier, 1 being 0 dB
+func (s *Server) SetInputVolume(name string, mul float64) {
+	s.update(func(st *state) []event {
+		in, err := st.findInput(name)
+		if err != nil {
+			return nil
+		}
+		return st.setInputVolume(in, mul)
+	})
+}
+
+// SetStreaming starts or stops the stream, as when it is done from OBS
+func (s *Server) SetStreaming(active bool) {
+	s.update(func(st *state) []event {
+		if st.streaming == active {
+			return nil
+		}
+		return st.setStreaming(active)
+	})
+}
+
+// SetRecording starts or stops recording, as when it is done from OBS
+func (s *Server) SetRecording(active bool) {
+	s.update(func(st *state) []event {
+		if st.recording == active {
+			return nil
+		}
+		return st.setRecording(active)
+	})
+}
+
+// CurrentScene returns the program scene
+func (s *Server) CurrentScene() (name string) {
+	s.view(func(st *state) { name = st.currentScene })
+	return name
+}
+
+// StudioMode reports whether studio mode is on
+func (s *Server) StudioMode() (enabled bool) {
+	s.view(func(st *state) { enabled = st.studioMode })
+	return enabled
+}
+
+// PreviewScene returns the studio mode preview scene, or "" outside studio
+// mode
+func (s *Server) PreviewScene() (name string) {
+	s.view(func(st *state) { name = st.previewScene })
+	return name
+}
+
+// Transition returns the current transition and its duration in ms
+func (s *Server) Transition() (name string, durationMs float64) {
+	s.view(func(st *state) { name, durationMs = st.currentTransition, st.transitionDuration })
+	return name, durationMs
+}
+
+// LastTransition returns the transition that was current when the program
+// scene last changed
+func (s *Server) LastTransition() (name string) {
+	s.view(func(st *state) { name = st.lastTransition })
+	return name
+}
+
+// Scenes returns every scene name, in order
+func (s *Server) Scenes() (names []string) {
+	s.view(func(st *state) {
+		for _, sc := range st.scenes {
+			names = append(names, sc.name)
+		}
+	})
+	return names
+}
+
+// Inputs returns every input name, in order
+func (s *Server) Inputs() (names []string) {
+	s.view(func(st *state) {
+		for _, in := range st.inputs {
+			names = append(names, in.name)
+		}
+	})
+	return names
+}
+
+// InputMuted reports whether an input is muted, and whether it exists
+func (s *Server) InputMuted(name string) (muted, ok bool) {
+	s.view(func(st *state) {
+		if in, err := st.findInput(name); err == nil {
+			muted, ok = in.muted, true
+		}
+	})
+	return muted, ok
+}
+
+// InputVolume returns an input's volume multiplier, and whether it exists
+func (s *Server) InputVolume(name string) (mul float64, ok bool) {
+	s.view(func(st *state) {
+		if in, err := st.findInput(name); err == nil {
+			mul, ok = in.volumeMul, true
+		}
+	})
+	return mul, ok
+}
+
+// Media returns a media input's state and cursor in ms, and whether it is
+// a media input
+func (s *Server) Media(name string) (mediaState string, cursorMs float64, ok bool) {
+	s.view(func(st *state) {
+		if in, err := st.findInput(name); err == nil && in.media != nil {
+			mediaState, cursorMs, ok = in.media.state, in.media.cursor, true
+		}
+	})
+	return mediaState, cursorMs, ok
+}
+
+// Streaming reports whether the stream is running
+func (s *Server) Streaming() (active bool) {
+	s.view(func(st *state) { active = st.streaming })
+	return active
+}
+
+// Recording reports whether recording is running
+func (s *Server) Recording() (active bool) {
+	s.view(func(st *state) { active = st.recording })
+	return active
+}
+
+// RecordPaused reports whether recording is paused
+func (s *Server) RecordPaused() (paused bool) {
+	s.view(func(st *state) { paused = st.recordPaused })
+	return paused
+}
+
+// VirtualCam reports whether the virtual camera is running
+func (s *Server) VirtualCam() (active bool) {
+	s.view(func(st *state) { active = st.virtualcam })
+	return active
+}
+
+// ReplayBuffer reports whether the replay buffer is running
+func (s *Server) ReplayBuffer() (active bool) {
+	s.view(func(st *state) { active = st.replayBuffer })
+	return active
+}
+
+// LastReplay returns the path of the last saved replay, or "" if none was
+func (s *Server) LastReplay() (path string) {
+	s.view(func(st *state) { path = st.lastReplay })
+	return path
+}
+
+// SceneItem returns a copy of the item showing source in a scene
+func (s *Server) SceneItem(sceneName, source string) (item SceneItem, ok bool) {
+	s.view(func(st *state) {
+		sc, err := st.findScene(sceneName)
+		if err != nil {
+			return
+		}
+		for _, candidate := range sc.items {
+			if candidate.Source == source {
+				item, ok = *candidate, true
+				return
+			}
+		}
+	})
+	return item, ok
+}
+
+// removeItems deletes every scene item showing source
+func (st *state) removeItems(source string) {
+	for _, sc := range st.scenes {
+		kept := sc.items[:0]
+		for _, item := range sc.items {
+			if item.Source != source {
+				kept = append(kept, item)
+			}
+		}
+		sc.items = kept
+	}
+}
+
+// renameItems points scene items showing oldName at newName
+func (st *state) renameItems(oldName, newName string) {
+	for _, sc := range st.scenes {
+		for _, item := range sc.items {
+			if item.Source == oldName {
+				item.Source = newName
+			}
+		}
+	}
+}
@@ -0,0 +1,457 @@
+// Package obstest provides an in-process obs-websocket 5 server for tests.
+//
+// Server speaks the handshake, authentication and the subset of requests and
+// events robo-stream uses: scenes, inputs, stream, record, virtual camera and
+// scene items. Its state is scripted through Go methods, which emit the same
+// events OBS would, and requests can be made to fail on demand.
+package obstest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreykaipov/goobs/api/closecodes"
+	"github.com/andreykaipov/goobs/api/events/subscriptions"
+	"github.com/gorilla/websocket"
+)
+
+// Request status codes, as in the obs-websocket protocol
+const (
+	StatusSuccess              = 100
+	StatusUnknownRequestType   = 204
+	StatusMissingRequestField  = 300
+	StatusInvalidRequestField  = 400
+	StatusOutOfRange           = 402
+	StatusOutputRunning        = 500
+	StatusOutputNotRunning     = 501
+	StatusOutputPaused         = 502
+	StatusOutputNotPaused      = 503
+	StatusStudioModeNotActive  = 506
+	StatusResourceNotFound     = 600
+	StatusResourceExists       = 601
+	StatusInvalidResourceType  = 602
+	StatusInvalidResourceState = 604
+)
+
+// Protocol opcodes
+const (
+	opHello           = 0
+	opIdentify        = 1
+	opIdentified      = 2
+	opEvent           = 5
+	opRequest         = 6
+	opRequestResponse = 7
+)
+
+// rpcVersion is the only obs-websocket RPC version spoken
+const rpcVersion = 1
+
+// Server is a fake OBS. Create it with NewServer and point clients at Host.
+type Server struct {
+	// Host is the host:port to connect to, as OBSManager.Connect expects
+	Host string
+
+	http     *httptest.Server
+	password string
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	state    state
+	failures map[string]RequestError
+	delays   map[string]time.Duration
+	requests []string
+	conns    map[*conn]bool
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithPassword makes clients authenticate with password
+func WithPassword(password string) Option {
+	return func(s *Server) { s.password = password }
+}
+
+// RequestError is the status a failed request responds with
+type RequestError struct {
+	Code    int
+	Comment string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Comment)
+}
+
+// conn is one identified client connection
+type conn struct {
+	ws            *websocket.Conn
+	mu            sync.Mutex // serializes writes
+	subscriptions int
+}
+
+// message is the envelope of every protocol message
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+// event is an OBS event waiting to be sent to subscribers
+type event struct {
+	Type string
+	Data map[string]interface{}
+}
+
+// NewServer starts a fake OBS with no scenes or inputs. Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		state:    newState(),
+		failures: make(map[string]RequestError),
+		delays:   make(map[string]time.Duration),
+		conns:    make(map[*conn]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.http = httptest.NewServer(http.HandlerFunc(s.serveWS))
+	s.Host = strings.TrimPrefix(s.http.URL, "http://")
+	return s
+}
+
+// Close disconnects every client and stops the server
+func (s *Server) Close() {
+	s.DropConnections()
+	s.http.Close()
+}
+
+// DropConnections closes every client connection, as OBS does when it quits
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.conns = make(map[*conn]bool)
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.mu.Lock()
+		c.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "OBS is shutting down"),
+			time.Now().Add(time.Second))
+		c.mu.Unlock()
+		c.ws.Close()
+	}
+}
+
+// Connections returns how many clients are identified
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// FailRequest makes every request of requestType fail with code and comment
+// until ClearFailures
+func (s *Server) FailRequest(requestType string, code int, comment string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[requestType] = RequestError{Code: code, Comment: comment}
+}
+
+// DelayRequest makes every request of requestType wait before responding
+// until ClearFailures
+func (s *Server) DelayRequest(requestType string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[requestType] = delay
+}
+
+// ClearFailures undoes FailRequest and DelayRequest
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[string]RequestError)
+	s.delays = make(map[string]time.Duration)
+}
+
+// Requests returns the type of every request received, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Received reports how many requests of requestType were received
+func (s *Server) Received(requestType string) int {
+	count := 0
+	for _, r := range s.Requests() {
+		if r == requestType {
+			count++
+		}
+	}
+	return count
+}
+
+// Emit sends an arbitrary event to every subscribed client
+func (s *Server) Emit(eventType string, data map[string]interface{}) {
+	s.broadcast([]event{{Type: eventType, Data: data}})
+}
+
+// serveWS runs the handshake and then answers requests until the client
+// goes away
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws}
+	defer ws.Close()
+
+	if !s.handshake(c) {
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+	}()
+
+	for {
+		var msg message
+		if err := ws.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Op != opRequest {
+			continue
+		}
+
+		var req struct {
+			Type string          `json:"requestType"`
+			ID   string          `json:"requestId"`
+			Data json.RawMessage `json:"requestData"`
+		}
+		if err := json.Unmarshal(msg.D, &req); err != nil {
+			continue
+		}
+		go s.respond(c, req.Type, req.ID, req.Data)
+	}
+}
+
+// handshake sends Hello, checks the client's Identify and confirms it
+func (s *Server) handshake(c *conn) bool {
+	hello := map[string]interface{}{
+		"obsWebSocketVersion": "5.5.0",
+		"rpcVersion":          rpcVersion,
+	}
+	salt, challenge := randomString(), randomString()
+	if s.password != "" {
+		hello["authentication"] = map[string]string{"challenge": challenge, "salt": salt}
+	}
+	if err := c.write(opHello, hello); err != nil {
+		return false
+	}
+
+	var msg message
+	if err := c.ws.ReadJSON(&msg); err != nil || msg.Op != opIdentify {
+		c.close(closecodes.NotIdentified, "expected Identify")
+		return false
+	}
+	var identify struct {
+		RPCVersion         int    `json:"rpcVersion"`
+		Authentication     string `json:"authentication"`
+		EventSubscriptions *int   `json:"eventSubscriptions"`
+	}
+	if err := json.Unmarshal(msg.D, &identify); err != nil {
+		c.close(closecodes.MessageDecodeError, err.Error())
+		return false
+	}
+	if identify.RPCVersion != rpcVersion {
+		c.close(closecodes.UnsupportedRpcVersion, "unsupported RPC version")
+		return false
+	}
+	if s.password != "" && identify.Authentication != authResponse(s.password, salt, challenge) {
+		c.close(closecodes.AuthenticationFailed, "Authentication failed.")
+		return false
+	}
+
+	c.subscriptions = subscriptions.All
+	if identify.EventSubscriptions != nil {
+		c.subscriptions = *identify.EventSubscriptions
+	}
+	return c.write(opIdentified, map[string]int{"negotiatedRpcVersion": rpcVersion}) == nil
+}
+
+// respond runs one request and writes its response, then sends the events
+// it caused
+func (s *Server) respond(c *conn, requestType, id string, data json.RawMessage) {
+	s.mu.Lock()
+	s.requests = append(s.requests, requestType)
+	delay := s.delays[requestType]
+	failure, failing := s.failures[requestType]
+	s.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	var (
+		result interface{}
+		events []event
+		err    *RequestError
+	)
+	if failing {
+		err = &failure
+	} else {
+		var params requestParams
+		if len(data) > 0 {
+			if decodeErr := json.Unmarshal(data, &params); decodeErr != nil {
+				err = &RequestError{Code: 400, Comment: decodeErr.Error()}
+			}
+		}
+		if err == nil {
+			s.mu.Lock()
+			result, events, err = s.state.handle(requestType, params)
+			s.mu.Unlock()
+		}
+	}
+
+	status := map[string]interface{}{"result": err == nil, "code": StatusSuccess}
+	if err != nil {
+		status["code"] = err.Code
+		status["comment"] = err.Comment
+	}
+	response := map[string]interface{}{
+		"requestType":   requestType,
+		"requestId":     id,
+		"requestStatus": status,
+	}
+	if result != nil {
+		response["responseData"] = result
+	}
+	c.write(opRequestResponse, response)
+
+	s.broadcast(events)
+}
+
+// broadcast sends events to every client subscribed to their category
+func (s *Server) broadcast(events []event) {
+	if len(events) == 0 {
+		return
+	}
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, e := range events {
+		// Events we don't know the category of go to everyone
+		category, known := eventCategories[e.Type]
+		if !known {
+			category = subscriptions.General
+		}
+		data := e.Data
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		for _, c := range conns {
+			if known && c.subscriptions&category == 0 {
+				continue
+			}
+			c.write(opEvent, map[string]interface{}{
+				"eventType":   e.Type,
+				"eventIntent": category,
+				"eventData":   data,
+			})
+		}
+	}
+}
+
+// update runs fn on the state and sends the events it returns
+func (s *Server) update(fn func(st *state) []event) {
+	s.mu.Lock()
+	events := fn(&s.state)
+	s.mu.Unlock()
+	s.broadcast(events)
+}
+
+// view runs fn on the state
+func (s *Server) view(fn func(st *state)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+}
+
+// write sends one protocol message
+func (c *conn) write(op int, d interface{}) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteJSON(message{Op: op, D: raw})
+}
+
+// close ends the connection with a close code
+func (c *conn) close(code int, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+// authResponse computes what a client with password answers a challenge with
+func authResponse(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+// randomString returns a random base64 string for salts and challenges
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// eventCategories maps each event the server sends to its subscription
+var eventCategories = map[string]int{
+	"CurrentProgramSceneChanged":            subscriptions.Scenes,
+	"SceneCreated":                          subscriptions.Scenes,
+	"SceneRemoved":                          subscriptions.Scenes,
+	"SceneNameChanged":                      subscriptions.Scenes,
+	"SceneListChanged":                      subscriptions.Scenes,
+	"CurrentPreviewSceneChanged":            subscriptions.Scenes,
+	"StudioModeStateChanged":                subscriptions.Ui,
+	"CurrentSceneTransitionChanged":         subscriptions.Transitions,
+	"CurrentSceneTransitionDurationChanged": subscriptions.Transitions,
+	"InputCreated":                          subscriptions.Inputs,
+	"InputRemoved":                          subscriptions.Inputs,
+	"InputNameChanged":                      subscriptions.Inputs,
+	"InputMuteStateChanged":                 subscriptions.Inputs,
+	"InputVolumeChanged":                    subscriptions.Inputs,
+	"MediaInputActionTriggered":             subscriptions.MediaInputs,
+	"MediaInputPlaybackStarted":             subscriptions.MediaInputs,
+	"MediaInputPlaybackEnded":               subscriptions.MediaInputs,
+	"StreamStateChanged":                    subscriptions.Outputs,
+	"RecordStateChanged":                    subscriptions.Outputs,
+	"VirtualcamStateChanged":                subscriptions.Outputs,
+	"ReplayBufferStateChanged":              subscriptions.Outputs,
+	"ReplayBufferSaved":                     subscriptions.Outputs,
+	"SceneItemEnableStateChanged":           subscriptions.SceneItems,
+	"SceneItemLockStateChanged":             subscriptions.SceneItems,
+	"SceneItemTransformChanged":             subscriptions.SceneItemTransformChanged,
+}
@@ -0,0 +1,845 @@
+package obstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/google/uuid"
+)
+
+// Output states sent with StreamStateChanged, RecordStateChanged,
+// VirtualcamStateChanged and ReplayBufferStateChanged
+const (
+	OutputStarted = "OBS_WEBSOCKET_OUTPUT_STARTED"
+	OutputStopped = "OBS_WEBSOCKET_OUTPUT_STOPPED"
+	OutputPaused  = "OBS_WEBSOCKET_OUTPUT_PAUSED"
+	OutputResumed = "OBS_WEBSOCKET_OUTPUT_RESUMED"
+)
+
+// RecordingPath is where stopped recordings are reported to be saved
+const RecordingPath = "/tmp/obstest-recording.mkv"
+
+// replayPathFormat names saved replays by how many were saved before
+const replayPathFormat = "/tmp/obstest-replay-%d.mkv"
+
+// Transitions the fake starts with. Fade is current, at 300 ms; Cut has a
+// fixed length.
+const (
+	TransitionFade = "Fade"
+	TransitionCut  = "Cut"
+)
+
+// Media input states, as GetMediaInputStatus reports them
+const (
+	MediaPlaying = "OBS_MEDIA_STATE_PLAYING"
+	MediaPaused  = "OBS_MEDIA_STATE_PAUSED"
+	MediaStopped = "OBS_MEDIA_STATE_STOPPED"
+	MediaEnded   = "OBS_MEDIA_STATE_ENDED"
+)
+
+// SceneItem is a source placed in a scene
+type SceneItem struct {
+	ID        int
+	Source    string
+	Enabled   bool
+	Locked    bool
+	Transform typedefs.SceneItemTransform
+}
+
+// state is what the fake OBS holds. It is guarded by Server.mu.
+type state struct {
+	scenes       []*scene
+	inputs       []*input
+	currentScene string
+	studioMode   bool
+	previewScene string
+	streaming    bool
+	recording    bool
+	recordPaused bool
+	virtualcam   bool
+	replayBuffer bool
+	lastReplay   string
+	replaysSaved int
+	nextItemID   int
+
+	transitions        []*transition
+	currentTransition  string
+	transitionDuration float64
+	lastTransition     string // current when the program scene last changed
+}
+
+type scene struct {
+	name  string
+	uuid  string
+	items []*SceneItem
+}
+
+type transition struct {
+	name  string
+	kind  string
+	uuid  string
+	fixed bool
+}
+
+type input struct {
+	name      string
+	kind      string
+	uuid      string
+	muted     bool
+	volumeMul float64
+	media     *media // nil unless the input plays media
+}
+
+// media is the playback of a media input. Time doesn't pass in the fake;
+// the cursor only moves when it is set.
+type media struct {
+	state    string
+	cursor   float64 // ms
+	duration float64 // ms
+}
+
+// requestParams holds the request fields any supported request takes
+type requestParams struct {
+	SceneName          string                 `json:"sceneName"`
+	InputName          string                 `json:"inputName"`
+	SourceName         string                 `json:"sourceName"`
+	InputMuted         *bool                  `json:"inputMuted"`
+	InputVolumeMul     *float64               `json:"inputVolumeMul"`
+	InputVolumeDb      *float64               `json:"inputVolumeDb"`
+	SceneItemID        *int                   `json:"sceneItemId"`
+	SceneItemEnabled   *bool                  `json:"sceneItemEnabled"`
+	SceneItemLocked    *bool                  `json:"sceneItemLocked"`
+	SceneItemTransform map[string]interface{} `json:"sceneItemTransform"`
+	StudioModeEnabled  *bool                  `json:"studioModeEnabled"`
+	TransitionName     string                 `json:"transitionName"`
+	TransitionDuration *float64               `json:"transitionDuration"`
+	MediaAction        string                 `json:"mediaAction"`
+	MediaCursor        *float64               `json:"mediaCursor"`
+	MediaCursorOffset  *float64               `json:"mediaCursorOffset"`
+}
+
+func newState() state {
+	return state{
+		nextItemID: 1,
+		transitions: []*transition{
+			{name: TransitionFade, kind: "fade_transition", uuid: newUUID()},
+			{name: TransitionCut, kind: "cut_transition", uuid: newUUID(), fixed: true},
+		},
+		currentTransition:  TransitionFade,
+		transitionDuration: 300,
+	}
+}
+
+// handle runs one request, returning its response data and the events it
+// causes
+func (st *state) handle(requestType string, p requestParams) (interface{}, []event, *RequestError) {
+	switch requestType {
+	case "GetVersion":
+		return map[string]interface{}{
+			"obsVersion":            "30.0.0",
+			"obsWebSocketVersion":   "5.5.0",
+			"rpcVersion":            rpcVersion,
+			"availableRequests":     []string{},
+			"supportedImageFormats": []string{},
+			"platform":              "obstest",
+			"platformDescription":   "in-process fake",
+		}, nil, nil
+
+	// Scenes
+	case "GetSceneList":
+		scenes := make([]map[string]interface{}, len(st.scenes))
+		for i, sc := range st.scenes {
+			scenes[i] = map[string]interface{}{"sceneName": sc.name, "sceneUuid": sc.uuid, "sceneIndex": i}
+		}
+		return map[string]interface{}{
+			"currentProgramSceneName": st.currentScene,
+			"currentProgramSceneUuid": st.sceneUUID(st.currentScene),
+			"currentPreviewSceneName": st.previewScene,
+			"scenes":                  scenes,
+		}, nil, nil
+	case "GetCurrentProgramScene":
+		return map[string]interface{}{
+			"currentProgramSceneName": st.currentScene,
+			"currentProgramSceneUuid": st.sceneUUID(st.currentScene),
+			"sceneName":               st.currentScene,
+			"sceneUuid":               st.sceneUUID(st.currentScene),
+		}, nil, nil
+	case "SetCurrentProgramScene":
+		if _, err := st.findScene(p.SceneName); err != nil {
+			return nil, nil, err
+		}
+		return nil, st.setCurrentScene(p.SceneName), nil
+
+	// Studio mode and transitions
+	case "GetStudioModeEnabled":
+		return map[string]interface{}{"studioModeEnabled": st.studioMode}, nil, nil
+	case "SetStudioModeEnabled":
+		if p.StudioModeEnabled == nil {
+			return nil, nil, missingField("studioModeEnabled")
+		}
+		return nil, st.setStudioMode(*p.StudioModeEnabled), nil
+	case "GetCurrentPreviewScene":
+		if !st.studioMode {
+			return nil, nil, studioModeNotActive()
+		}
+		return map[string]interface{}{
+			"currentPreviewSceneName": st.previewScene,
+			"currentPreviewSceneUuid": st.sceneUUID(st.previewScene),
+			"sceneName":               st.previewScene,
+			"sceneUuid":               st.sceneUUID(st.previewScene),
+		}, nil, nil
+	case "SetCurrentPreviewScene":
+		if !st.studioMode {
+			return nil, nil, studioModeNotActive()
+		}
+		if _, err := st.findScene(p.SceneName); err != nil {
+			return nil, nil, err
+		}
+		return nil, st.setPreviewScene(p.SceneName), nil
+	case "TriggerStudioModeTransition":
+		if !st.studioMode {
+			return nil, nil, studioModeNotActive()
+		}
+		// OBS swaps the scenes, leaving the old program in preview
+		program, preview := st.currentScene, st.previewScene
+		events := st.setCurrentScene(preview)
+		return nil, append(events, st.setPreviewScene(program)...), nil
+	case "GetSceneTransitionList":
+		list := make([]map[string]interface{}, len(st.transitions))
+		for i, tr := range st.transitions {
+			list[i] = map[string]interface{}{
+				"transitionName":         tr.name,
+				"transitionKind":         tr.kind,
+				"transitionUuid":         tr.uuid,
+				"transitionFixed":        tr.fixed,
+				"transitionConfigurable": !tr.fixed,
+			}
+		}
+		current := st.findTransition(st.currentTransition)
+		return map[string]interface{}{
+			"currentSceneTransitionName": current.name,
+			"currentSceneTransitionKind": current.kind,
+			"currentSceneTransitionUuid": current.uuid,
+			"transitions":                list,
+		}, nil, nil
+	case "GetCurrentSceneTransition":
+		tr := st.findTransition(st.currentTransition)
+		data := map[string]interface{}{
+			"transitionName":         tr.name,
+			"transitionKind":         tr.kind,
+			"transitionUuid":         tr.uuid,
+			"transitionFixed":        tr.fixed,
+			"transitionConfigurable": !tr.fixed,
+			"transitionDuration":     nil,
+			"transitionSettings":     map[string]interface{}{},
+		}
+		if !tr.fixed {
+			data["transitionDuration"] = st.transitionDuration
+		}
+		return data, nil, nil
+	case "SetCurrentSceneTransition":
+		if p.TransitionName == "" {
+			return nil, nil, missingField("transitionName")
+		}
+		tr := st.findTransition(p.TransitionName)
+		if tr == nil {
+			return nil, nil, notFound("transition", p.TransitionName)
+		}
+		if st.currentTransition == tr.name {
+			return nil, nil, nil
+		}
+		st.currentTransition = tr.name
+		return nil, []event{{"CurrentSceneTransitionChanged", map[string]interface{}{
+			"transitionName": tr.name,
+			"transitionUuid": tr.uuid,
+		}}}, nil
+	case "SetCurrentSceneTransitionDuration":
+		if p.TransitionDuration == nil {
+			return nil, nil, missingField("transitionDuration")
+		}
+		if *p.TransitionDuration < 50 || *p.TransitionDuration > 20000 {
+			return nil, nil, outOfRange("transitionDuration")
+		}
+		if st.transitionDuration == *p.TransitionDuration {
+			return nil, nil, nil
+		}
+		st.transitionDuration = *p.TransitionDuration
+		return nil, []event{{"CurrentSceneTransitionDurationChanged", map[string]interface{}{
+			"transitionDuration": st.transitionDuration,
+		}}}, nil
+
+	// Inputs
+	case "GetInputList":
+		inputs := make([]map[string]interface{}, len(st.inputs))
+		for i, in := range st.inputs {
+			inputs[i] = map[string]interface{}{
+				"inputName":            in.name,
+				"inputUuid":            in.uuid,
+				"inputKind":            in.kind,
+				"unversionedInputKind": in.kind,
+			}
+		}
+		return map[string]interface{}{"inputs": inputs}, nil, nil
+	case "GetInputMute":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]interface{}{"inputMuted": in.muted}, nil, nil
+	case "SetInputMute":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.InputMuted == nil {
+			return nil, nil, missingField("inputMuted")
+		}
+		return nil, st.setInputMuted(in, *p.InputMuted), nil
+	case "ToggleInputMute":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		events := st.setInputMuted(in, !in.muted)
+		return map[string]interface{}{"inputMuted": in.muted}, events, nil
+	case "GetInputVolume":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]interface{}{"inputVolumeMul": in.volumeMul, "inputVolumeDb": mulToDb(in.volumeMul)}, nil, nil
+	case "SetInputVolume":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		var mul float64
+		switch {
+		case p.InputVolumeMul != nil:
+			if *p.InputVolumeMul < 0 || *p.InputVolumeMul > 20 {
+				return nil, nil, outOfRange("inputVolumeMul")
+			}
+			mul = *p.InputVolumeMul
+		case p.InputVolumeDb != nil:
+			if *p.InputVolumeDb < -100 || *p.InputVolumeDb > 26 {
+				return nil, nil, outOfRange("inputVolumeDb")
+			}
+			mul = math.Pow(10, *p.InputVolumeDb/20)
+		default:
+			return nil, nil, missingField("inputVolumeMul")
+		}
+		return nil, st.setInputVolume(in, mul), nil
+
+	// Media inputs
+	case "GetMediaInputStatus", "TriggerMediaInputAction", "SetMediaInputCursor", "OffsetMediaInputCursor":
+		in, err := st.findInput(p.InputName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if in.media == nil {
+			return nil, nil, &RequestError{Code: StatusInvalidResourceType, Comment: "The specified input is not a media input."}
+		}
+		return st.handleMedia(requestType, in, p)
+
+	// Stream
+	case "GetStreamStatus":
+		return map[string]interface{}{
+			"outputActive":        st.streaming,
+			"outputReconnecting":  false,
+			"outputTimecode":      "00:00:00.000",
+			"outputDuration":      0,
+			"outputCongestion":    0,
+			"outputBytes":         0,
+			"outputSkippedFrames": 0,
+			"outputTotalFrames":   0,
+		}, nil, nil
+	case "StartStream":
+		if st.streaming {
+			return nil, nil, &RequestError{Code: StatusOutputRunning, Comment: "The stream output is already running."}
+		}
+		return nil, st.setStreaming(true), nil
+	case "StopStream":
+		if !st.streaming {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The stream output is not running."}
+		}
+		return nil, st.setStreaming(false), nil
+	case "ToggleStream":
+		events := st.setStreaming(!st.streaming)
+		return map[string]interface{}{"outputActive": st.streaming}, events, nil
+
+	// Record
+	case "GetRecordStatus":
+		return map[string]interface{}{
+			"outputActive":   st.recording,
+			"outputPaused":   st.recordPaused,
+			"outputTimecode": "00:00:00.000",
+			"outputDuration": 0,
+			"outputBytes":    0,
+		}, nil, nil
+	case "StartRecord":
+		if st.recording {
+			return nil, nil, &RequestError{Code: StatusOutputRunning, Comment: "The record output is already running."}
+		}
+		return nil, st.setRecording(true), nil
+	case "StopRecord":
+		if !st.recording {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The record output is not running."}
+		}
+		return map[string]interface{}{"outputPath": RecordingPath}, st.setRecording(false), nil
+	case "ToggleRecord":
+		events := st.setRecording(!st.recording)
+		return map[string]interface{}{"outputActive": st.recording}, events, nil
+	case "PauseRecord", "ResumeRecord", "ToggleRecordPause":
+		if !st.recording {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The record output is not running."}
+		}
+		pause := !st.recordPaused
+		if requestType == "PauseRecord" {
+			if st.recordPaused {
+				return nil, nil, &RequestError{Code: StatusOutputPaused, Comment: "The record output is already paused."}
+			}
+			pause = true
+		} else if requestType == "ResumeRecord" {
+			if !st.recordPaused {
+				return nil, nil, &RequestError{Code: StatusOutputNotPaused, Comment: "The record output is not paused."}
+			}
+			pause = false
+		}
+		return nil, st.setRecordPaused(pause), nil
+
+	// Virtual camera
+	case "GetVirtualCamStatus":
+		return map[string]interface{}{"outputActive": st.virtualcam}, nil, nil
+	case "StartVirtualCam":
+		if st.virtualcam {
+			return nil, nil, &RequestError{Code: StatusOutputRunning, Comment: "The virtualcam output is already running."}
+		}
+		return nil, st.setVirtualcam(true), nil
+	case "StopVirtualCam":
+		if !st.virtualcam {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The virtualcam output is not running."}
+		}
+		return nil, st.setVirtualcam(false), nil
+	case "ToggleVirtualCam":
+		events := st.setVirtualcam(!st.virtualcam)
+		return map[string]interface{}{"outputActive": st.virtualcam}, events, nil
+
+	// Replay buffer
+	case "GetReplayBufferStatus":
+		return map[string]interface{}{"outputActive": st.replayBuffer}, nil, nil
+	case "StartReplayBuffer":
+		if st.replayBuffer {
+			return nil, nil, &RequestError{Code: StatusOutputRunning, Comment: "The replay buffer output is already running."}
+		}
+		return nil, st.setReplayBuffer(true), nil
+	case "StopReplayBuffer":
+		if !st.replayBuffer {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The replay buffer output is not running."}
+		}
+		return nil, st.setReplayBuffer(false), nil
+	case "ToggleReplayBuffer":
+		events := st.setReplayBuffer(!st.replayBuffer)
+		return map[string]interface{}{"outputActive": st.replayBuffer}, events, nil
+	case "SaveReplayBuffer":
+		if !st.replayBuffer {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The replay buffer output is not running."}
+		}
+		st.replaysSaved++
+		st.lastReplay = fmt.Sprintf(replayPathFormat, st.replaysSaved)
+		return nil, []event{{"ReplayBufferSaved", map[string]interface{}{"savedReplayPath": st.lastReplay}}}, nil
+	case "GetLastReplayBufferReplay":
+		if !st.replayBuffer {
+			return nil, nil, &RequestError{Code: StatusOutputNotRunning, Comment: "The replay buffer output is not running."}
+		}
+		return map[string]interface{}{"savedReplayPath": st.lastReplay}, nil, nil
+
+	// Scene items
+	case "GetSceneItemList":
+		sc, err := st.findScene(p.SceneName)
+		if err != nil {
+			return nil, nil, err
+		}
+		items := make([]map[string]interface{}, len(sc.items))
+		for i, item := range sc.items {
+			items[i] = st.sceneItemData(item, i)
+		}
+		return map[string]interface{}{"sceneItems": items}, nil, nil
+	case "GetSceneItemId":
+		sc, err := st.findScene(p.SceneName)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range sc.items {
+			if item.Source == p.SourceName {
+				return map[string]interface{}{"sceneItemId": item.ID}, nil, nil
+			}
+		}
+		return nil, nil, notFound("scene item", p.SourceName)
+	case "GetSceneItemEnabled", "SetSceneItemEnabled",
+		"GetSceneItemLocked", "SetSceneItemLocked",
+		"GetSceneItemTransform", "SetSceneItemTransform":
+		return st.handleSceneItem(requestType, p)
+
+	default:
+		return nil, nil, &RequestError{Code: StatusUnknownRequestType, Comment: "Your request type is not valid."}
+	}
+}
+
+// handleSceneItem runs the requests that read or change one scene item
+func (st *state) handleSceneItem(requestType string, p requestParams) (interface{}, []event, *RequestError) {
+	sc, err := st.findScene(p.SceneName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.SceneItemID == nil {
+		return nil, nil, missingField("sceneItemId")
+	}
+	var item *SceneItem
+	for _, candidate := range sc.items {
+		if candidate.ID == *p.SceneItemID {
+			item = candidate
+		}
+	}
+	if item == nil {
+		return nil, nil, notFound("scene item", fmt.Sprint(*p.SceneItemID))
+	}
+
+	base := map[string]interface{}{"sceneName": sc.name, "sceneUuid": sc.uuid, "sceneItemId": item.ID}
+	with := func(key string, value interface{}) map[string]interface{} {
+		out := map[string]interface{}{key: value}
+		for k, v := range base {
+			out[k] = v
+		}
+		return out
+	}
+
+	switch requestType {
+	case "GetSceneItemEnabled":
+		return map[string]interface{}{"sceneItemEnabled": item.Enabled}, nil, nil
+	case "SetSceneItemEnabled":
+		if p.SceneItemEnabled == nil {
+			return nil, nil, missingField("sceneItemEnabled")
+		}
+		item.Enabled = *p.SceneItemEnabled
+		return nil, []event{{"SceneItemEnableStateChanged", with("sceneItemEnabled", item.Enabled)}}, nil
+	case "GetSceneItemLocked":
+		return map[string]interface{}{"sceneItemLocked": item.Locked}, nil, nil
+	case "SetSceneItemLocked":
+		if p.SceneItemLocked == nil {
+			return nil, nil, missingField("sceneItemLocked")
+		}
+		item.Locked = *p.SceneItemLocked
+		return nil, []event{{"SceneItemLockStateChanged", with("sceneItemLocked", item.Locked)}}, nil
+	case "GetSceneItemTransform":
+		return map[string]interface{}{"sceneItemTransform": item.Transform}, nil, nil
+	default: // SetSceneItemTransform
+		if p.SceneItemTransform == nil {
+			return nil, nil, missingField("sceneItemTransform")
+		}
+		if err := mergeTransform(&item.Transform, p.SceneItemTransform); err != nil {
+			return nil, nil, &RequestError{Code: 400, Comment: err.Error()}
+		}
+		return nil, []event{{"SceneItemTransformChanged", with("sceneItemTransform", item.Transform)}}, nil
+	}
+}
+
+// mergeTransform sets the fields of t named in changes
+func mergeTransform(t *typedefs.SceneItemTransform, changes map[string]interface{}) error {
+	current, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(current, &fields); err != nil {
+		return err
+	}
+	for key, value := range changes {
+		fields[key] = value
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(merged, t)
+}
+
+func (st *state) sceneItemData(item *SceneItem, index int) map[string]interface{} {
+	sourceType, kind, sourceUUID := "OBS_SOURCE_TYPE_INPUT", "", ""
+	if in, err := st.findInput(item.Source); err == nil {
+		kind, sourceUUID = in.kind, in.uuid
+	} else if sc, err := st.findScene(item.Source); err == nil {
+		sourceType, sourceUUID = "OBS_SOURCE_TYPE_SCENE", sc.uuid
+	}
+	return map[string]interface{}{
+		"sceneItemId":        item.ID,
+		"sceneItemIndex":     index,
+		"sourceName":         item.Source,
+		"sourceUuid":         sourceUUID,
+		"sourceType":         sourceType,
+		"inputKind":          kind,
+		"isGroup":            false,
+		"sceneItemEnabled":   item.Enabled,
+		"sceneItemLocked":    item.Locked,
+		"sceneItemTransform": item.Transform,
+		"sceneItemBlendMode": "OBS_BLEND_NORMAL",
+	}
+}
+
+func (st *state) findScene(name string) (*scene, *RequestError) {
+	if name == "" {
+		return nil, missingField("sceneName")
+	}
+	for _, sc := range st.scenes {
+		if sc.name == name {
+			return sc, nil
+		}
+	}
+	return nil, notFound("scene", name)
+}
+
+func (st *state) findInput(name string) (*input, *RequestError) {
+	if name == "" {
+		return nil, missingField("inputName")
+	}
+	for _, in := range st.inputs {
+		if in.name == name {
+			return in, nil
+		}
+	}
+	return nil, notFound("input", name)
+}
+
+func (st *state) sceneUUID(name string) string {
+	if sc, err := st.findScene(name); err == nil {
+		return sc.uuid
+	}
+	return ""
+}
+
+func (st *state) setCurrentScene(name string) []event {
+	if st.currentScene == name {
+		return nil
+	}
+	st.currentScene = name
+	st.lastTransition = st.currentTransition
+	return []event{{"CurrentProgramSceneChanged", map[string]interface{}{
+		"sceneName": name,
+		"sceneUuid": st.sceneUUID(name),
+	}}}
+}
+
+// handleMedia runs a request on a media input
+func (st *state) handleMedia(requestType string, in *input, p requestParams) (interface{}, []event, *RequestError) {
+	m := in.media
+	timed := m.state == MediaPlaying || m.state == MediaPaused
+
+	switch requestType {
+	case "GetMediaInputStatus":
+		data := map[string]interface{}{"mediaState": m.state, "mediaCursor": nil, "mediaDuration": nil}
+		if timed {
+			data["mediaCursor"], data["mediaDuration"] = m.cursor, m.duration
+		}
+		return data, nil, nil
+
+	case "TriggerMediaInputAction":
+		var events []event
+		switch p.MediaAction {
+		case "":
+			return nil, nil, missingField("mediaAction")
+		case "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PLAY":
+			if m.state == MediaPaused {
+				m.state = MediaPlaying
+				break
+			}
+			events = st.startMedia(in)
+		case "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_RESTART":
+			events = st.startMedia(in)
+		case "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PAUSE":
+			if m.state == MediaPlaying {
+				m.state = MediaPaused
+			}
+		case "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_STOP":
+			m.state, m.cursor = MediaStopped, 0
+		case "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_NEXT", "OBS_WEBSOCKET_MEDIA_INPUT_ACTION_PREVIOUS":
+		default:
+			return nil, nil, &RequestError{Code: StatusInvalidRequestField, Comment: "You have specified an invalid media input action."}
+		}
+		events = append([]event{{"MediaInputActionTriggered", map[string]interface{}{
+			"inputName":   in.name,
+			"inputUuid":   in.uuid,
+			"mediaAction": p.MediaAction,
+		}}}, events...)
+		return nil, events, nil
+
+	case "SetMediaInputCursor", "OffsetMediaInputCursor":
+		if !timed {
+			return nil, nil, &RequestError{Code: StatusInvalidResourceState, Comment: "The media input must be playing or paused in order to set the cursor position."}
+		}
+		if requestType == "SetMediaInputCursor" {
+			if p.MediaCursor == nil {
+				return nil, nil, missingField("mediaCursor")
+			}
+			if *p.MediaCursor < 0 {
+				return nil, nil, outOfRange("mediaCursor")
+			}
+			m.cursor = *p.MediaCursor
+		} else {
+			if p.MediaCursorOffset == nil {
+				return nil, nil, missingField("mediaCursorOffset")
+			}
+			m.cursor += *p.MediaCursorOffset
+		}
+		m.cursor = math.Max(0, math.Min(m.cursor, m.duration))
+		return nil, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// startMedia plays a media input from the start
+func (st *state) startMedia(in *input) []event {
+	in.media.state, in.media.cursor = MediaPlaying, 0
+	return []event{{"MediaInputPlaybackStarted", map[string]interface{}{
+		"inputName": in.name,
+		"inputUuid": in.uuid,
+	}}}
+}
+
+func (st *state) setPreviewScene(name string) []event {
+	if st.previewScene == name {
+		return nil
+	}
+	st.previewScene = name
+	return []event{{"CurrentPreviewSceneChanged", map[string]interface{}{
+		"sceneName": name,
+		"sceneUuid": st.sceneUUID(name),
+	}}}
+}
+
+// setStudioMode turns studio mode on or off. As in OBS, the preview starts
+// out showing the program scene.
+func (st *state) setStudioMode(enabled bool) []event {
+	if st.studioMode == enabled {
+		return nil
+	}
+	st.studioMode = enabled
+	events := []event{{"StudioModeStateChanged", map[string]interface{}{"studioModeEnabled": enabled}}}
+	if !enabled {
+		st.previewScene = ""
+		return events
+	}
+	return append(events, st.setPreviewScene(st.currentScene)...)
+}
+
+func (st *state) findTransition(name string) *transition {
+	for _, tr := range st.transitions {
+		if tr.name == name {
+			return tr
+		}
+	}
+	return nil
+}
+
+func (st *state) setInputMuted(in *input, muted bool) []event {
+	if in.muted == muted {
+		return nil
+	}
+	in.muted = muted
+	return []event{{"InputMuteStateChanged", map[string]interface{}{
+		"inputName":  in.name,
+		"inputUuid":  in.uuid,
+		"inputMuted": muted,
+	}}}
+}
+
+func (st *state) setInputVolume(in *input, mul float64) []event {
+	if in.volumeMul == mul {
+		return nil
+	}
+	in.volumeMul = mul
+	return []event{{"InputVolumeChanged", map[string]interface{}{
+		"inputName":      in.name,
+		"inputUuid":      in.uuid,
+		"inputVolumeMul": mul,
+		"inputVolumeDb":  mulToDb(mul),
+	}}}
+}
+
+// mulToDb converts a volume multiplier to dB, reporting silence as -100
+// since JSON has no infinity
+func mulToDb(mul float64) float64 {
+	if mul <= 0 {
+		return -100
+	}
+	return math.Max(20*math.Log10(mul), -100)
+}
+
+func (st *state) setStreaming(active bool) []event {
+	st.streaming = active
+	return []event{{"StreamStateChanged", outputEvent(active, "")}}
+}
+
+func (st *state) setRecording(active bool) []event {
+	st.recording = active
+	st.recordPaused = false
+	path := ""
+	if !active {
+		path = RecordingPath
+	}
+	return []event{{"RecordStateChanged", outputEvent(active, path)}}
+}
+
+func (st *state) setRecordPaused(paused bool) []event {
+	st.recordPaused = paused
+	outputState := OutputResumed
+	if paused {
+		outputState = OutputPaused
+	}
+	return []event{{"RecordStateChanged", map[string]interface{}{
+		"outputActive": true,
+		"outputState":  outputState,
+	}}}
+}
+
+func (st *state) setVirtualcam(active bool) []event {
+	st.virtualcam = active
+	return []event{{"VirtualcamStateChanged", outputEvent(active, "")}}
+}
+
+func (st *state) setReplayBuffer(active bool) []event {
+	st.replayBuffer = active
+	return []event{{"ReplayBufferStateChanged", outputEvent(active, "")}}
+}
+
+// outputEvent is the data of an output started or stopped event
+func outputEvent(active bool, path string) map[string]interface{} {
+	data := map[string]interface{}{"outputActive": active, "outputState": OutputStopped}
+	if active {
+		data["outputState"] = OutputStarted
+	}
+	if path != "" {
+		data["outputPath"] = path
+	}
+	return data
+}
+
+func studioModeNotActive() *RequestError {
+	return &RequestError{Code: StatusStudioModeNotActive, Comment: "Studio mode is not active."}
+}
+
+func missingField(field string) *RequestError {
+	return &RequestError{Code: StatusMissingRequestField, Comment: fmt.Sprintf("Your request is missing the `%s` field.", field)}
+}
+
+func outOfRange(field string) *RequestError {
+	return &RequestError{Code: StatusOutOfRange, Comment: fmt.Sprintf("The field `%s` is out of range.", field)}
+}
+
+func notFound(kind, name string) *RequestError {
+	return &RequestError{Code: StatusResourceNotFound, Comment: fmt.Sprintf("No %s was found by the name of `%s`.", kind, name)}
+}
+
+func newUUID() string {
+	return uuid.New().String()
+}
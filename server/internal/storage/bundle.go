@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// Files inside a bundle archive
+const (
+	bundleManifestFile       = "manifest.json"
+	bundleConfigurationsFile = "configurations.json"
+	bundleButtonsFile        = "buttons.json"
+)
+
+// bundleFormat identifies a robo-stream bundle in its manifest
+const bundleFormat = "robo-stream-bundle"
+
+// Largest file read from a bundle or profile archive
+const maxBundleFileSize = 16 << 20
+
+// bundleManifest describes a bundle archive
+type bundleManifest struct {
+	Format         string    `json:"format"`
+	Version        int       `json:"version"`
+	ExportedAt     time.Time `json:"exported_at"`
+	Configurations int       `json:"configurations"`
+	Buttons        int       `json:"buttons"`
+	Icons          []string  `json:"icons"`
+}
+
+// WriteBundle writes a bundle as a zip archive holding a manifest and one
+// JSON file per collection
+func WriteBundle(w io.Writer, b *models.Bundle) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{bundleManifestFile, bundleManifest{
+			Format:         bundleFormat,
+			Version:        b.Version,
+			ExportedAt:     b.ExportedAt,
+			Configurations: len(b.Configurations),
+			Buttons:        len(b.Buttons),
+			Icons:          b.Icons,
+		}},
+		{bundleConfigurationsFile, b.Configurations},
+		{bundleButtonsFile, b.Buttons},
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// ReadBundle reads a bundle archive written by WriteBundle. Bundles from a
+// newer format version are refused.
+func ReadBundle(data []byte) (*models.Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle archive: %w", err)
+	}
+
+	var manifest bundleManifest
+	if err := readBundleFile(zr, bundleManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != bundleFormat {
+		return nil, fmt.Errorf("not a bundle archive: unknown format %q", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > models.BundleVersion {
+		return nil, fmt.Errorf("bundle has version %d, supported version is %d", manifest.Version, models.BundleVersion)
+	}
+
+	b := &models.Bundle{
+		Version:    manifest.Version,
+		ExportedAt: manifest.ExportedAt,
+		Icons:      manifest.Icons,
+	}
+	if err := readBundleFile(zr, bundleConfigurationsFile, &b.Configurations); err != nil {
+		return nil, err
+	}
+	if err := readBundleFile(zr, bundleButtonsFile, &b.Buttons); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// readBundleFile decodes one file of a bundle archive into v
+func readBundleFile(zr *zip.Reader, name string, v interface{}) error {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return readZipJSON(f, v)
+		}
+	}
+	return fmt.Errorf("bundle is missing %s", name)
+}
+
+// readZipJSON decodes a JSON file inside a zip archive into v
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxBundleFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if len(data) > maxBundleFileSize {
+		return fmt.Errorf("%s is too large", f.Name)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+	return nil
+}
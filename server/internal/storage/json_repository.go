@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// jsonFiles maps each collection to the file it is saved in
+var jsonFiles = map[Kind]string{
+	KindButtons:        "buttons.json",
+	KindConfigurations: "configs.json",
+	KindSessions:       "sessions.json",
+	KindClients:        "clients.json",
+	KindButtonStats:    "button_stats.json",
+}
+
+// jsonIDFields maps each collection to the field holding a record's ID
+var jsonIDFields = map[Kind]string{
+	KindButtons:        "id",
+	KindConfigurations: "id",
+	KindSessions:       "session_id",
+	KindClients:        "id",
+	KindButtonStats:    "button_id",
+}
+
+// JSONRepository keeps each collection in one JSON file. Every change to a
+// collection rewrites its whole file.
+type JSONRepository struct {
+	storage *Storage
+	mu      sync.Mutex
+	records map[Kind]map[string]json.RawMessage // what is on disk
+}
+
+// NewJSONRepository reads every collection from storage
+func NewJSONRepository(s *Storage) (*JSONRepository, error) {
+	r := &JSONRepository{
+		storage: s,
+		records: make(map[Kind]map[string]json.RawMessage),
+	}
+	for _, kind := range Kinds {
+		records, err := r.read(kind)
+		if err != nil {
+			return nil, err
+		}
+		r.records[kind] = records
+	}
+	return r, nil
+}
+
+// read loads a collection file into records keyed by ID
+func (r *JSONRepository) read(kind Kind) (map[string]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := r.storage.LoadJSON(jsonFiles[kind], &items); err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]json.RawMessage, len(items))
+	for _, item := range items {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(item, &fields); err != nil {
+			return nil, fmt.Errorf("invalid record in %s: %w", jsonFiles[kind], err)
+		}
+		id, _ := fields[jsonIDFields[kind]].(string)
+		records[id] = item
+	}
+	return records, nil
+}
+
+// LoadButtons returns the button library
+func (r *JSONRepository) LoadButtons() ([]*models.Button, error) {
+	var buttons []*models.Button
+	return buttons, r.load(KindButtons, &buttons)
+}
+
+// LoadConfigurations returns every configuration
+func (r *JSONRepository) LoadConfigurations() ([]*models.Configuration, error) {
+	var configs []*models.Configuration
+	return configs, r.load(KindConfigurations, &configs)
+}
+
+// LoadSessions returns every client session
+func (r *JSONRepository) LoadSessions() ([]*models.ClientSession, error) {
+	var sessions []*models.ClientSession
+	return sessions, r.load(KindSessions, &sessions)
+}
+
+// LoadClients returns every paired client
+func (r *JSONRepository) LoadClients() ([]*models.PairedClient, error) {
+	var clients []*models.PairedClient
+	return clients, r.load(KindClients, &clients)
+}
+
+// LoadButtonStats returns every button's press counts
+func (r *JSONRepository) LoadButtonStats() ([]*models.ButtonStats, error) {
+	var stats []*models.ButtonStats
+	return stats, r.load(KindButtonStats, &stats)
+}
+
+// load decodes a collection into v, a pointer to a slice
+func (r *JSONRepository) load(kind Kind, v interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return decodeRecords(sortedRecords(r.records[kind]), v)
+}
+
+// Apply rewrites the file of every collection the changes touch, all in one
+// storage write
+func (r *JSONRepository) Apply(changes []Change) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Work on copies so a failed save leaves the cache matching the disk
+	updated := make(map[Kind]map[string]json.RawMessage)
+	for _, change := range changes {
+		records, ok := updated[change.Kind]
+		if !ok {
+			current, known := r.records[change.Kind]
+			if !known {
+				return fmt.Errorf("unknown record kind: %s", change.Kind)
+			}
+			records = make(map[string]json.RawMessage, len(current))
+			for id, data := range current {
+				records[id] = data
+			}
+			updated[change.Kind] = records
+		}
+
+		if change.Value == nil {
+			delete(records, change.ID)
+			continue
+		}
+		data, err := json.Marshal(change.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s %s: %w", change.Kind, change.ID, err)
+		}
+		records[change.ID] = data
+	}
+
+	files := make(map[string]interface{}, len(updated))
+	for kind, records := range updated {
+		files[jsonFiles[kind]] = sortedRecords(records)
+	}
+	if err := r.storage.SaveJSONFiles(files); err != nil {
+		return err
+	}
+
+	for kind, records := range updated {
+		r.records[kind] = records
+	}
+	return nil
+}
+
+// Close does nothing; every change is already on disk
+func (r *JSONRepository) Close() error {
+	return nil
+}
+
+// sortedRecords returns records ordered by ID, so files and query results
+// are stable
+func sortedRecords(records map[string]json.RawMessage) []json.RawMessage {
+	ids := make([]string, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := make([]json.RawMessage, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, records[id])
+	}
+	return out
+}
+
+// decodeRecords decodes a list of JSON records into v, a pointer to a slice
+func decodeRecords(records []json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
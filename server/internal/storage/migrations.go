@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Migration upgrades one file from Version-1 to Version. Migrations work on
+// raw JSON rather than the models package so they keep describing the format
+// as it was, however the models change later.
+type Migration struct {
+	File        string // file name, or "" for every file
+	Version     int    // schema version this migration produces
+	Description string
+	Migrate     func(data json.RawMessage) (json.RawMessage, error)
+}
+
+// migrations lists every schema change, oldest first
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "wrap data in a schema_version envelope",
+		Migrate:     func(data json.RawMessage) (json.RawMessage, error) { return data, nil },
+	},
+	{
+		File:        "configs.json",
+		Version:     2,
+		Description: "convert btn-<row>-<col> positions to btn-<page>-<row>-<col> and add pages",
+		Migrate:     migrateConfigPages,
+	},
+}
+
+// SchemaVersion returns the schema version files named filename are written in
+func SchemaVersion(filename string) int {
+	version := 0
+	for _, m := range migrations {
+		if (m.File == "" || m.File == filename) && m.Version > version {
+			version = m.Version
+		}
+	}
+	return version
+}
+
+// migrate upgrades data from version to the current schema version, logging
+// each step that was applied
+func migrate(filename string, version int, data json.RawMessage) (json.RawMessage, error) {
+	from := version
+	applied := make([]string, 0)
+
+	for _, m := range migrations {
+		if m.Version <= version || (m.File != "" && m.File != filename) {
+			continue
+		}
+
+		migrated, err := m.Migrate(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s to schema version %d (%s): %w", filename, m.Version, m.Description, err)
+		}
+		data = migrated
+		version = m.Version
+		applied = append(applied, fmt.Sprintf("v%d: %s", m.Version, m.Description))
+	}
+
+	log.Printf("📦 Migrated %s from schema v%d to v%d", filename, from, version)
+	for _, step := range applied {
+		log.Printf("📦   %s", step)
+	}
+	return data, nil
+}
+
+// migrateConfigPages rewrites the position keys of every configuration
+func migrateConfigPages(data json.RawMessage) (json.RawMessage, error) {
+	var configs []map[string]interface{}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, err
+	}
+
+	for _, cfg := range configs {
+		buttons, _ := cfg["buttons"].(map[string]interface{})
+		rewritten := make(map[string]interface{}, len(buttons))
+		for position, buttonID := range buttons {
+			if parts := strings.Split(position, "-"); len(parts) == 3 {
+				position = fmt.Sprintf("btn-0-%s-%s", parts[1], parts[2])
+			}
+			rewritten[position] = buttonID
+		}
+		cfg["buttons"] = rewritten
+
+		if _, ok := cfg["pages"]; !ok {
+			cfg["pages"] = []map[string]interface{}{{"name": "Page 1"}}
+		}
+	}
+
+	return json.Marshal(configs)
+}
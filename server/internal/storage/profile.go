@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// Stream Deck actions the reader handles itself
+const streamDeckOpenChild = "com.elgato.streamdeck.profile.openchild"
+
+// Key grids of Stream Deck models, by the device model in a profile manifest
+var streamDeckGrids = map[string]models.GridConfig{
+	"20GAA9901": {Rows: 3, Cols: 5}, // Stream Deck
+	"20GBA9901": {Rows: 3, Cols: 5}, // Stream Deck MK.2
+	"20GAI9901": {Rows: 2, Cols: 3}, // Stream Deck Mini
+	"20GAT9901": {Rows: 4, Cols: 8}, // Stream Deck XL
+	"20GBD9901": {Rows: 2, Cols: 4}, // Stream Deck +
+}
+
+// ReadProfile reads a Stream-Pi profile (XML or JSON) or an Elgato
+// .streamDeckProfile archive. The format is detected from the contents;
+// name is the uploaded file name, used when the profile has none.
+func ReadProfile(name string, data []byte) (*models.Profile, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	var (
+		profile *models.Profile
+		err     error
+	)
+	switch {
+	case bytes.HasPrefix(data, []byte("PK")):
+		profile, err = readStreamDeckProfile(data)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		profile, err = readStreamPiXML(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		profile, err = readStreamPiJSON(trimmed)
+	default:
+		return nil, fmt.Errorf("unrecognized profile format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+	if profile.Name == "" || profile.Name == "." {
+		profile.Name = "Imported Profile"
+	}
+	fitGrid(profile)
+	return profile, nil
+}
+
+// streamPiAction is a Stream-Pi action in either file format
+type streamPiAction struct {
+	ID       string
+	Parent   string // folder action ID, or "root"
+	Type     string // NORMAL, FOLDER, COMBINE or GAUGE
+	Module   string
+	Title    string
+	Color    string
+	Row      int
+	Col      int
+	Settings map[string]interface{}
+}
+
+// streamPiXMLProfile is a Stream-Pi client profile file
+type streamPiXMLProfile struct {
+	Name    string              `xml:"name"`
+	Rows    int                 `xml:"rows"`
+	Cols    int                 `xml:"cols"`
+	Actions []streamPiXMLAction `xml:"actions>action"`
+}
+
+type streamPiXMLAction struct {
+	ID         string `xml:"id"`
+	Parent     string `xml:"parent"`
+	Type       string `xml:"action-type"`
+	ModuleName string `xml:"module-name"`
+	Text       string `xml:"display>text>display-text"`
+	Color      string `xml:"display>background>colour-hex"`
+	Row        int    `xml:"display>location>row"`
+	Col        int    `xml:"display>location>col"`
+	Properties []struct {
+		Name  string `xml:"name"`
+		Value string `xml:"value"`
+	} `xml:"properties>property"`
+}
+
+// readStreamPiXML reads a Stream-Pi client profile XML file
+func readStreamPiXML(data []byte) (*models.Profile, error) {
+	var file streamPiXMLProfile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid Stream-Pi profile: %w", err)
+	}
+
+	actions := make([]streamPiAction, 0, len(file.Actions))
+	for _, a := range file.Actions {
+		settings := make(map[string]interface{}, len(a.Properties))
+		for _, prop := range a.Properties {
+			settings[prop.Name] = prop.Value
+		}
+		actions = append(actions, streamPiAction{
+			ID:       a.ID,
+			Parent:   a.Parent,
+			Type:     a.Type,
+			Module:   a.ModuleName,
+			Title:    a.Text,
+			Color:    a.Color,
+			Row:      a.Row,
+			Col:      a.Col,
+			Settings: settings,
+		})
+	}
+	return streamPiProfile(file.Name, file.Rows, file.Cols, actions), nil
+}
+
+// streamPiJSONProfile is a Stream-Pi profile as JSON, shaped like the
+// profile payload of the Stream-Pi protocol
+type streamPiJSONProfile struct {
+	Name    string `json:"name"`
+	Rows    int    `json:"rows"`
+	Cols    int    `json:"cols"`
+	Actions []struct {
+		ID              string                 `json:"id"`
+		Parent          string                 `json:"parent"`
+		Type            string                 `json:"type"`
+		Name            string                 `json:"name"`
+		Row             int                    `json:"row"`
+		Col             int                    `json:"col"`
+		DisplayText     string                 `json:"displayText"`
+		BackgroundColor string                 `json:"backgroundColor"`
+		Properties      map[string]interface{} `json:"properties"`
+		PluginID        string                 `json:"pluginId"`
+	} `json:"actions"`
+}
+
+// readStreamPiJSON reads a Stream-Pi profile JSON file
+func readStreamPiJSON(data []byte) (*models.Profile, error) {
+	var file streamPiJSONProfile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid Stream-Pi profile: %w", err)
+	}
+	if file.Actions == nil {
+		return nil, fmt.Errorf("not a Stream-Pi profile: no actions")
+	}
+
+	actions := make([]streamPiAction, 0, len(file.Actions))
+	for _, a := range file.Actions {
+		title := a.DisplayText
+		if title == "" {
+			title = a.Name
+		}
+		actions = append(actions, streamPiAction{
+			ID:       a.ID,
+			Parent:   a.Parent,
+			Type:     a.Type,
+			Module:   a.PluginID,
+			Title:    title,
+			Color:    a.BackgroundColor,
+			Row:      a.Row,
+			Col:      a.Col,
+			Settings: a.Properties,
+		})
+	}
+	return streamPiProfile(file.Name, file.Rows, file.Cols, actions), nil
+}
+
+// streamPiProfile lays out Stream-Pi actions as profile pages. Top-level
+// actions go on the first page and each folder gets a page of its own.
+func streamPiProfile(name string, rows, cols int, actions []streamPiAction) *models.Profile {
+	profile := &models.Profile{
+		Format: models.ProfileFormatStreamPi,
+		Name:   name,
+		Grid:   models.GridConfig{Rows: rows, Cols: cols},
+		Pages:  []models.ProfilePage{{}},
+	}
+
+	folders := make(map[string]int) // folder action ID -> page
+	for _, a := range actions {
+		if strings.EqualFold(a.Type, "FOLDER") && a.ID != "" {
+			folders[a.ID] = len(profile.Pages)
+			profile.Pages = append(profile.Pages, models.ProfilePage{Name: a.Title})
+		}
+	}
+
+	for _, a := range actions {
+		page := 0
+		if p, ok := folders[a.Parent]; ok {
+			page = p
+		}
+		key := models.ProfileKey{
+			Row:      a.Row,
+			Col:      a.Col,
+			Title:    a.Title,
+			Color:    a.Color,
+			ActionID: a.Module,
+			Settings: a.Settings,
+		}
+		if p, ok := folders[a.ID]; ok {
+			key.Folder = p
+		}
+		profile.Pages[page].Keys = append(profile.Pages[page].Keys, key)
+	}
+	return profile
+}
+
+// streamDeckManifest is the manifest.json of a Stream Deck profile or of
+// one of its folders or pages
+type streamDeckManifest struct {
+	Name   string `json:"Name"`
+	Device struct {
+		Model string `json:"Model"`
+	} `json:"Device"`
+	Actions     map[string]streamDeckAction `json:"Actions"`
+	Controllers []struct {
+		Type    string                      `json:"Type"`
+		Actions map[string]streamDeckAction `json:"Actions"`
+	} `json:"Controllers"`
+	Pages struct {
+		Pages []string `json:"Pages"`
+	} `json:"Pages"`
+}
+
+type streamDeckAction struct {
+	Name     string                 `json:"Name"`
+	UUID     string                 `json:"UUID"`
+	Settings map[string]interface{} `json:"Settings"`
+	State    int                    `json:"State"`
+	States   []struct {
+		Title string `json:"Title"`
+	} `json:"States"`
+}
+
+// keys returns the manifest's key actions by "col,row" position
+func (m *streamDeckManifest) keys() map[string]streamDeckAction {
+	keys := make(map[string]streamDeckAction, len(m.Actions))
+	for position, action := range m.Actions {
+		keys[position] = action
+	}
+	for _, controller := range m.Controllers {
+		if controller.Type != "Keypad" {
+			continue
+		}
+		for position, action := range controller.Actions {
+			keys[position] = action
+		}
+	}
+	return keys
+}
+
+// readStreamDeckProfile reads a .streamDeckProfile archive. The top-level
+// manifest is the least nested one; folders and pages are manifests in
+// directories named by their profile UUID.
+func readStreamDeckProfile(data []byte) (*models.Profile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a Stream Deck profile: %w", err)
+	}
+
+	manifests := make(map[string]*streamDeckManifest) // lowercase directory UUID -> manifest
+	root, rootDepth := "", -1
+	for _, f := range zr.File {
+		if path.Base(f.Name) != "manifest.json" {
+			continue
+		}
+		var m streamDeckManifest
+		if err := readZipJSON(f, &m); err != nil {
+			return nil, err
+		}
+		id := streamDeckID(path.Base(path.Dir(f.Name)))
+		manifests[id] = &m
+		if depth := strings.Count(f.Name, "/"); rootDepth < 0 || depth < rootDepth {
+			root, rootDepth = id, depth
+		}
+	}
+	if rootDepth < 0 {
+		return nil, fmt.Errorf("not a Stream Deck profile: no manifest.json")
+	}
+
+	top := manifests[root]
+	profile := &models.Profile{
+		Format: models.ProfileFormatStreamDeck,
+		Name:   top.Name,
+		Grid:   streamDeckGrids[top.Device.Model],
+	}
+
+	// Newer profiles keep their keys in pages; older ones in the top manifest
+	pageIDs := make([]string, 0, len(top.Pages.Pages)+1)
+	if len(top.keys()) > 0 || len(top.Pages.Pages) == 0 {
+		pageIDs = append(pageIDs, root)
+	}
+	for _, id := range top.Pages.Pages {
+		if _, ok := manifests[streamDeckID(id)]; ok {
+			pageIDs = append(pageIDs, streamDeckID(id))
+		}
+	}
+	pages := make(map[string]int, len(pageIDs))
+	for i, id := range pageIDs {
+		pages[id] = i
+		profile.Pages = append(profile.Pages, models.ProfilePage{})
+	}
+
+	// Folders add pages as they are found, so this walks them too
+	for i := 0; i < len(pageIDs); i++ {
+		keys := manifests[pageIDs[i]].keys()
+		for _, position := range sortedStreamDeckPositions(keys) {
+			action := keys[position]
+			col, row, ok := parseStreamDeckPosition(position)
+			if !ok {
+				continue
+			}
+
+			key := models.ProfileKey{
+				Row:      row,
+				Col:      col,
+				Title:    action.title(),
+				ActionID: action.UUID,
+				Settings: action.Settings,
+			}
+			if action.UUID == streamDeckOpenChild {
+				child, _ := action.Settings["ProfileUUID"].(string)
+				child = streamDeckID(child)
+				if _, ok := manifests[child]; ok && child != "" {
+					if _, seen := pages[child]; !seen {
+						pages[child] = len(pageIDs)
+						pageIDs = append(pageIDs, child)
+						profile.Pages = append(profile.Pages, models.ProfilePage{Name: key.Title})
+					}
+					key.Folder = pages[child]
+				}
+			}
+			profile.Pages[i].Keys = append(profile.Pages[i].Keys, key)
+		}
+	}
+	return profile, nil
+}
+
+// title returns the text shown on the key in its current state
+func (a streamDeckAction) title() string {
+	title := ""
+	if a.State >= 0 && a.State < len(a.States) {
+		title = a.States[a.State].Title
+	}
+	if strings.TrimSpace(title) == "" {
+		title = a.Name
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// streamDeckID normalizes a profile UUID or its directory name
+func streamDeckID(name string) string {
+	name = strings.ToLower(name)
+	return strings.TrimSuffix(name, ".sdprofile")
+}
+
+// parseStreamDeckPosition parses a "col,row" key position
+func parseStreamDeckPosition(position string) (col, row int, ok bool) {
+	parts := strings.Split(position, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || col < 0 {
+		return 0, 0, false
+	}
+	row, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || row < 0 {
+		return 0, 0, false
+	}
+	return col, row, true
+}
+
+// sortedStreamDeckPositions returns positions in row, then column order
+func sortedStreamDeckPositions(keys map[string]streamDeckAction) []string {
+	positions := make([]string, 0, len(keys))
+	for position := range keys {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		ci, ri, _ := parseStreamDeckPosition(positions[i])
+		cj, rj, _ := parseStreamDeckPosition(positions[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if ci != cj {
+			return ci < cj
+		}
+		return positions[i] < positions[j]
+	})
+	return positions
+}
+
+// Largest grid side fitGrid grows a profile to
+const maxProfileGridSide = 16
+
+// fitGrid grows a profile's grid to hold every key, up to
+// maxProfileGridSide; keys beyond that stay outside the grid
+func fitGrid(profile *models.Profile) {
+	for _, page := range profile.Pages {
+		for _, key := range page.Keys {
+			if key.Row >= profile.Grid.Rows && key.Row < maxProfileGridSide {
+				profile.Grid.Rows = key.Row + 1
+			}
+			if key.Col >= profile.Grid.Cols && key.Col < maxProfileGridSide {
+				profile.Grid.Cols = key.Col + 1
+			}
+		}
+	}
+	if profile.Grid.Rows < 1 {
+		profile.Grid.Rows = 1
+	}
+	if profile.Grid.Cols < 1 {
+		profile.Grid.Cols = 1
+	}
+}
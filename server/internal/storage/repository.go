@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// Storage backends selectable at startup
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Kind names a collection of records
+type Kind string
+
+const (
+	KindButtons        Kind = "buttons"
+	KindConfigurations Kind = "configurations"
+	KindSessions       Kind = "sessions"
+	KindClients        Kind = "clients"
+	KindButtonStats    Kind = "button_stats"
+)
+
+// Kinds lists every collection, in the order they are imported
+var Kinds = []Kind{KindButtons, KindConfigurations, KindSessions, KindClients, KindButtonStats}
+
+// Change writes one record. A nil Value deletes it.
+type Change struct {
+	Kind  Kind
+	ID    string
+	Value interface{}
+}
+
+// ButtonRepository loads the button library
+type ButtonRepository interface {
+	LoadButtons() ([]*models.Button, error)
+}
+
+// ConfigurationRepository loads configurations
+type ConfigurationRepository interface {
+	LoadConfigurations() ([]*models.Configuration, error)
+}
+
+// SessionRepository loads client sessions
+type SessionRepository interface {
+	LoadSessions() ([]*models.ClientSession, error)
+}
+
+// ClientRepository loads paired clients
+type ClientRepository interface {
+	LoadClients() ([]*models.PairedClient, error)
+}
+
+// ButtonStatsRepository loads button press counts
+type ButtonStatsRepository interface {
+	LoadButtonStats() ([]*models.ButtonStats, error)
+}
+
+// Repository is where manager state is kept. Apply writes every change or
+// none of them.
+type Repository interface {
+	ButtonRepository
+	ConfigurationRepository
+	SessionRepository
+	ClientRepository
+	ButtonStatsRepository
+	Apply(changes []Change) error
+	Close() error
+}
+
+// OpenRepository opens the named backend in the storage data directory. The
+// first time the SQLite backend is opened it imports the existing JSON files.
+func OpenRepository(backend string, s *Storage) (Repository, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewJSONRepository(s)
+	case BackendSQLite:
+		repo, err := OpenSQLite(filepath.Join(s.GetDataDir(), sqliteFile))
+		if err != nil {
+			return nil, err
+		}
+		if err := importOnce(s, repo); err != nil {
+			repo.Close()
+			return nil, err
+		}
+		return repo, nil
+	}
+	return nil, fmt.Errorf("unknown storage backend: %s", backend)
+}
+
+// ImportReport counts the records an import copied
+type ImportReport struct {
+	Buttons        int `json:"buttons"`
+	Configurations int `json:"configurations"`
+	Sessions       int `json:"sessions"`
+	Clients        int `json:"clients"`
+	ButtonStats    int `json:"button_stats"`
+}
+
+// Import copies every record from src to dst in one Apply
+func Import(src, dst Repository) (*ImportReport, error) {
+	buttons, err := src.LoadButtons()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read buttons: %w", err)
+	}
+	configs, err := src.LoadConfigurations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configurations: %w", err)
+	}
+	sessions, err := src.LoadSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	clients, err := src.LoadClients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read paired clients: %w", err)
+	}
+	stats, err := src.LoadButtonStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read button stats: %w", err)
+	}
+
+	changes := make([]Change, 0, len(buttons)+len(configs)+len(sessions)+len(clients)+len(stats))
+	for _, btn := range buttons {
+		changes = append(changes, Change{Kind: KindButtons, ID: btn.ID, Value: btn})
+	}
+	for _, cfg := range configs {
+		changes = append(changes, Change{Kind: KindConfigurations, ID: cfg.ID, Value: cfg})
+	}
+	for _, sess := range sessions {
+		changes = append(changes, Change{Kind: KindSessions, ID: sess.SessionID, Value: sess})
+	}
+	for _, client := range clients {
+		changes = append(changes, Change{Kind: KindClients, ID: client.ID, Value: client})
+	}
+	for _, stat := range stats {
+		changes = append(changes, Change{Kind: KindButtonStats, ID: stat.ButtonID, Value: stat})
+	}
+	if err := dst.Apply(changes); err != nil {
+		return nil, err
+	}
+
+	return &ImportReport{
+		Buttons:        len(buttons),
+		Configurations: len(configs),
+		Sessions:       len(sessions),
+		Clients:        len(clients),
+		ButtonStats:    len(stats),
+	}, nil
+}
+
+// importOnce copies the JSON files into a new SQLite database. It runs only
+// while the database has never been imported into and is empty, and leaves
+// the JSON files in place.
+func importOnce(s *Storage, repo *SQLiteRepository) error {
+	imported, err := repo.imported()
+	if err != nil || imported {
+		return err
+	}
+
+	hasJSON := false
+	for _, kind := range Kinds {
+		if _, err := os.Stat(filepath.Join(s.GetDataDir(), jsonFiles[kind])); err == nil {
+			hasJSON = true
+		}
+	}
+	empty, err := repo.empty()
+	if err != nil {
+		return err
+	}
+	if !hasJSON || !empty {
+		return repo.markImported()
+	}
+
+	src, err := NewJSONRepository(s)
+	if err != nil {
+		return fmt.Errorf("failed to read JSON files for import: %w", err)
+	}
+	report, err := Import(src, repo)
+	if err != nil {
+		return fmt.Errorf("failed to import JSON files: %w", err)
+	}
+	log.Printf("📦 Imported %d buttons, %d configurations, %d sessions and %d paired clients from JSON into %s",
+		report.Buttons, report.Configurations, report.Sessions, report.Clients, sqliteFile)
+	return repo.markImported()
+}
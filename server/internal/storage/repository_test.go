@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+// openBackends returns a fresh repository of every backend
+func openBackends(t *testing.T) map[string]Repository {
+	t.Helper()
+
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	jsonRepo, err := NewJSONRepository(s)
+	if err != nil {
+		t.Fatalf("NewJSONRepository: %v", err)
+	}
+	sqliteRepo, err := OpenSQLite(filepath.Join(t.TempDir(), sqliteFile))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { sqliteRepo.Close() })
+
+	return map[string]Repository{BackendJSON: jsonRepo, BackendSQLite: sqliteRepo}
+}
+
+func TestRepositoryApply(t *testing.T) {
+	for name, repo := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			err := repo.Apply([]Change{
+				{Kind: KindButtons, ID: "b1", Value: &models.Button{ID: "b1", Name: "One"}},
+				{Kind: KindButtons, ID: "b2", Value: &models.Button{ID: "b2", Name: "Two"}},
+				{Kind: KindSessions, ID: "s1", Value: &models.ClientSession{SessionID: "s1", ClientID: "c1"}},
+			})
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+
+			err = repo.Apply([]Change{
+				{Kind: KindButtons, ID: "b1", Value: &models.Button{ID: "b1", Name: "Renamed"}},
+				{Kind: KindButtons, ID: "b2"},
+			})
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+
+			buttons, err := repo.LoadButtons()
+			if err != nil {
+				t.Fatalf("LoadButtons: %v", err)
+			}
+			if len(buttons) != 1 || buttons[0].Name != "Renamed" {
+				t.Errorf("buttons = %+v, want only b1 renamed", buttons)
+			}
+			sessions, err := repo.LoadSessions()
+			if err != nil {
+				t.Fatalf("LoadSessions: %v", err)
+			}
+			if len(sessions) != 1 || sessions[0].ClientID != "c1" {
+				t.Errorf("sessions = %+v, want s1", sessions)
+			}
+		})
+	}
+}
+
+func TestRepositoryApplyIsAtomic(t *testing.T) {
+	for name, repo := range openBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			err := repo.Apply([]Change{
+				{Kind: KindButtons, ID: "b1", Value: &models.Button{ID: "b1"}},
+				{Kind: KindSessions, ID: "s1", Value: func() {}}, // Cannot be encoded
+			})
+			if err == nil {
+				t.Fatal("expected Apply to fail")
+			}
+
+			buttons, err := repo.LoadButtons()
+			if err != nil {
+				t.Fatalf("LoadButtons: %v", err)
+			}
+			if len(buttons) != 0 {
+				t.Errorf("buttons = %+v, want none after a failed Apply", buttons)
+			}
+		})
+	}
+}
+
+func TestOpenRepositoryImportsJSONOnce(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	jsonRepo, err := NewJSONRepository(s)
+	if err != nil {
+		t.Fatalf("NewJSONRepository: %v", err)
+	}
+	err = jsonRepo.Apply([]Change{
+		{Kind: KindButtons, ID: "b1", Value: &models.Button{ID: "b1", Name: "One"}},
+		{Kind: KindConfigurations, ID: "c1", Value: &models.Configuration{ID: "c1", IsDefault: true}},
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	repo, err := OpenRepository(BackendSQLite, s)
+	if err != nil {
+		t.Fatalf("OpenRepository: %v", err)
+	}
+	configs, err := repo.LoadConfigurations()
+	if err != nil {
+		t.Fatalf("LoadConfigurations: %v", err)
+	}
+	if len(configs) != 1 || !configs[0].IsDefault {
+		t.Fatalf("configurations = %+v, want the imported default", configs)
+	}
+
+	// Deleting everything must not bring the JSON data back on the next open
+	if err := repo.Apply([]Change{{Kind: KindButtons, ID: "b1"}, {Kind: KindConfigurations, ID: "c1"}}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	repo.Close()
+
+	repo, err = OpenRepository(BackendSQLite, s)
+	if err != nil {
+		t.Fatalf("OpenRepository: %v", err)
+	}
+	defer repo.Close()
+	buttons, err := repo.LoadButtons()
+	if err != nil {
+		t.Fatalf("LoadButtons: %v", err)
+	}
+	if len(buttons) != 0 {
+		t.Errorf("buttons = %+v, want none after reopening", buttons)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.GetDataDir(), "buttons.json")); err != nil {
+		t.Errorf("JSON files should be left in place: %v", err)
+	}
+}
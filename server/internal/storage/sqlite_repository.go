@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+	_ "modernc.org/sqlite" // Pure-Go driver, registers "sqlite"
+)
+
+// Database file used by the SQLite backend
+const sqliteFile = "robo-stream.db"
+
+// sqliteTables maps each collection to its table
+var sqliteTables = map[Kind]string{
+	KindButtons:        "buttons",
+	KindConfigurations: "configurations",
+	KindSessions:       "sessions",
+	KindClients:        "clients",
+	KindButtonStats:    "button_stats",
+}
+
+// sqliteSchema lists the statements that bring the database to each schema
+// version, oldest first. The version reached is kept in PRAGMA user_version.
+var sqliteSchema = [][]string{
+	{
+		`CREATE TABLE buttons (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+		`CREATE TABLE configurations (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+		`CREATE TABLE sessions (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+		`CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+	},
+	{
+		`CREATE TABLE clients (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+	},
+	{
+		`CREATE TABLE button_stats (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+	},
+}
+
+// SQLiteRepository keeps each record in its own row, so a change only
+// writes the rows it touches. Records are stored as JSON so the tables do
+// not need to change whenever the models do.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates a database and brings its schema up to date
+func OpenSQLite(path string) (*SQLiteRepository, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // SQLite allows one writer; queue here rather than in busy retries
+
+	r := &SQLiteRepository{db: db}
+	if err := r.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// migrate applies every schema step the database has not seen
+func (r *SQLiteRepository) migrate() error {
+	var version int
+	if err := r.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteSchema) {
+		return fmt.Errorf("database has schema version %d, newer than supported version %d", version, len(sqliteSchema))
+	}
+
+	for v := version; v < len(sqliteSchema); v++ {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range sqliteSchema[v] {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("schema v%d: %w", v+1, err)
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadButtons returns the button library
+func (r *SQLiteRepository) LoadButtons() ([]*models.Button, error) {
+	var buttons []*models.Button
+	return buttons, r.load(KindButtons, &buttons)
+}
+
+// LoadConfigurations returns every configuration
+func (r *SQLiteRepository) LoadConfigurations() ([]*models.Configuration, error) {
+	var configs []*models.Configuration
+	return configs, r.load(KindConfigurations, &configs)
+}
+
+// LoadSessions returns every client session
+func (r *SQLiteRepository) LoadSessions() ([]*models.ClientSession, error) {
+	var sessions []*models.ClientSession
+	return sessions, r.load(KindSessions, &sessions)
+}
+
+// LoadClients returns every paired client
+func (r *SQLiteRepository) LoadClients() ([]*models.PairedClient, error) {
+	var clients []*models.PairedClient
+	return clients, r.load(KindClients, &clients)
+}
+
+// LoadButtonStats returns every button's press counts
+func (r *SQLiteRepository) LoadButtonStats() ([]*models.ButtonStats, error) {
+	var stats []*models.ButtonStats
+	return stats, r.load(KindButtonStats, &stats)
+}
+
+// load decodes every row of a collection into v, a pointer to a slice
+func (r *SQLiteRepository) load(kind Kind, v interface{}) error {
+	rows, err := r.db.Query(fmt.Sprintf(`SELECT data FROM %s ORDER BY id`, sqliteTables[kind]))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	records := make([]json.RawMessage, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		records = append(records, json.RawMessage(data))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return decodeRecords(records, v)
+}
+
+// Apply writes the changed rows in one SQL transaction
+func (r *SQLiteRepository) Apply(changes []Change) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	for _, change := range changes {
+		table, ok := sqliteTables[change.Kind]
+		if !ok {
+			return fmt.Errorf("unknown record kind: %s", change.Kind)
+		}
+
+		if change.Value == nil {
+			if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, table), change.ID); err != nil {
+				return fmt.Errorf("failed to delete %s %s: %w", change.Kind, change.ID, err)
+			}
+			continue
+		}
+
+		data, err := json.Marshal(change.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s %s: %w", change.Kind, change.ID, err)
+		}
+		stmt := fmt.Sprintf(`INSERT INTO %s (id, data) VALUES (?, ?)
+			ON CONFLICT(id) DO UPDATE SET data = excluded.data`, table)
+		if _, err := tx.Exec(stmt, change.ID, string(data)); err != nil {
+			return fmt.Errorf("failed to save %s %s: %w", change.Kind, change.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// empty reports whether the database holds no records
+func (r *SQLiteRepository) empty() (bool, error) {
+	for _, table := range sqliteTables {
+		var count int
+		if err := r.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&count); err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// imported reports whether the JSON import has already been considered
+func (r *SQLiteRepository) imported() (bool, error) {
+	var value string
+	err := r.db.QueryRow(`SELECT value FROM meta WHERE key = 'json_imported_at'`).Scan(&value)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// markImported records that the JSON import must not run again
+func (r *SQLiteRepository) markImported() error {
+	_, err := r.db.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('json_imported_at', ?)`,
+		time.Now().Format(time.RFC3339))
+	return err
+}
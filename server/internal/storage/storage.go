@@ -2,11 +2,22 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
 )
 
+// Number of previous versions kept as <file>.bak.1 (newest) to <file>.bak.N
+const backupCount = 5
+
+// envelope wraps every saved file with the schema version it was written in
+type envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
 // Storage handles persistent data storage using JSON files
 type Storage struct {
 	dataDir string
@@ -21,42 +32,276 @@ func New(dataDir string) (*Storage, error) {
 	return &Storage{dataDir: dataDir}, nil
 }
 
-// LoadJSON loads data from a JSON file
+// LoadJSON loads data from a JSON file. Files from older versions are
+// migrated and written back. If the file is missing its data, truncated or
+// corrupt, the newest readable backup is used instead.
 func (s *Storage) LoadJSON(filename string, v interface{}) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	path := filepath.Join(s.dataDir, filename)
-	data, err := os.ReadFile(path)
+	data, version, err := readVersioned(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) && !s.hasBackups(filename) {
 			return nil // File doesn't exist yet, return empty
 		}
-		return err
+
+		log.Printf("⚠️  %s is unreadable (%v), trying backups", filename, err)
+		var backup string
+		data, version, backup, err = s.readBackup(filename)
+		if err != nil {
+			return fmt.Errorf("failed to load %s and no usable backup: %w", filename, err)
+		}
+		log.Printf("♻️  Loaded %s from backup %s", filename, filepath.Base(backup))
+	}
+
+	current := SchemaVersion(filename)
+	if version > current {
+		return fmt.Errorf("%s has schema version %d, newer than supported version %d", filename, version, current)
 	}
 
-	if len(data) == 0 {
-		return nil // Empty file
+	if version < current {
+		data, err = migrate(filename, version, data)
+		if err != nil {
+			return err
+		}
+		if err := s.write(filename, current, data); err != nil {
+			log.Printf("⚠️  Failed to save migrated %s: %v", filename, err)
+		}
 	}
 
 	return json.Unmarshal(data, v)
 }
 
-// SaveJSON saves data to a JSON file
+// SaveJSON saves data to a JSON file. The file is replaced atomically and
+// the previous version is kept as a backup.
 func (s *Storage) SaveJSON(filename string, v interface{}) error {
+	return s.SaveJSONFiles(map[string]interface{}{filename: v})
+}
+
+// SaveJSONFiles saves several files as one change. Every file is encoded and
+// staged to a synced temp file before any of them replaces its original, so
+// an encoding error or full disk leaves all of them untouched.
+func (s *Storage) SaveJSONFiles(files map[string]interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return err
+	staged := make(map[string]string, len(files))
+	defer func() {
+		for _, tmpPath := range staged {
+			os.Remove(tmpPath) // No-op once renamed
+		}
+	}()
+
+	for filename, v := range files {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", filename, err)
+		}
+		tmpPath, err := s.stage(filename, SchemaVersion(filename), data)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		staged[filename] = tmpPath
 	}
 
-	path := filepath.Join(s.dataDir, filename)
-	return os.WriteFile(path, data, 0644)
+	for filename, tmpPath := range staged {
+		if err := s.replace(filename, tmpPath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", filename, err)
+		}
+	}
+	syncDir(s.dataDir)
+	return nil
 }
 
 // GetDataDir returns the data directory path
 func (s *Storage) GetDataDir() string {
 	return s.dataDir
 }
+
+// write saves data in a schema envelope, replacing filename atomically.
+// Caller must hold s.mu.
+func (s *Storage) write(filename string, version int, data json.RawMessage) error {
+	tmpPath, err := s.stage(filename, version, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if err := s.replace(filename, tmpPath); err != nil {
+		return err
+	}
+	syncDir(s.dataDir)
+	return nil
+}
+
+// stage writes data in a schema envelope to a synced temp file next to
+// filename and returns its path
+func (s *Storage) stage(filename string, version int, data json.RawMessage) (string, error) {
+	out, err := json.MarshalIndent(envelope{SchemaVersion: version, Data: data}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(s.dataDir, filename+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// replace backs up filename and renames the staged temp file over it, so
+// readers only ever see a complete file
+func (s *Storage) replace(filename, tmpPath string) error {
+	if err := s.rotateBackups(filename); err != nil {
+		log.Printf("⚠️  Failed to back up %s: %v", filename, err)
+	}
+	return os.Rename(tmpPath, filepath.Join(s.dataDir, filename))
+}
+
+// rotateBackups shifts <file>.bak.N down by one and copies the current file
+// to <file>.bak.1. The current file stays in place until it is replaced. A
+// current file that doesn't parse is not backed up, so it can't push a good
+// backup out of the rotation.
+func (s *Storage) rotateBackups(filename string) error {
+	path := filepath.Join(s.dataDir, filename)
+	if _, _, err := readVersioned(path); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️  Not backing up unreadable %s: %v", filename, err)
+		}
+		return nil // Nothing usable to back up
+	}
+
+	os.Remove(s.backupPath(filename, backupCount))
+	for i := backupCount - 1; i >= 1; i-- {
+		from := s.backupPath(filename, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, s.backupPath(filename, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return copyFile(path, s.backupPath(filename, 1))
+}
+
+// readBackup returns the newest backup that can be read
+func (s *Storage) readBackup(filename string) (json.RawMessage, int, string, error) {
+	lastErr := fmt.Errorf("no backups")
+	for i := 1; i <= backupCount; i++ {
+		path := s.backupPath(filename, i)
+		data, version, err := readVersioned(path)
+		if err == nil {
+			return data, version, path, nil
+		}
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️  Backup %s is unreadable: %v", filepath.Base(path), err)
+			lastErr = err
+		}
+	}
+	return nil, 0, "", lastErr
+}
+
+// hasBackups reports whether any backup of filename exists
+func (s *Storage) hasBackups(filename string) bool {
+	for i := 1; i <= backupCount; i++ {
+		if _, err := os.Stat(s.backupPath(filename, i)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// backupPath returns the path of the n-th newest backup of filename
+func (s *Storage) backupPath(filename string, n int) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("%s.bak.%d", filename, n))
+}
+
+// readVersioned reads a file and returns its data and schema version. Files
+// written before the envelope existed are version 0 and are all data.
+func readVersioned(path string) (json.RawMessage, int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(raw) == 0 {
+		return nil, 0, fmt.Errorf("file is empty")
+	}
+	if !json.Valid(raw) {
+		return nil, 0, fmt.Errorf("file is not valid JSON")
+	}
+
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(raw, &probe) == nil {
+		if _, ok := probe["schema_version"]; ok {
+			var env envelope
+			if err := json.Unmarshal(raw, &env); err != nil {
+				return nil, 0, err
+			}
+			if len(env.Data) == 0 {
+				return nil, 0, fmt.Errorf("file has no data")
+			}
+			return env.Data, env.SchemaVersion, nil
+		}
+	}
+
+	return raw, 0, nil
+}
+
+// copyFile copies src to dst through a synced temp file
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// syncDir flushes directory entries so a rename survives a crash. Not every
+// platform supports this, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/robomon1/robo-stream/server/internal/models"
+)
+
+func newStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+// readEnvelope returns the schema version and compacted data saved in path
+func readEnvelope(t *testing.T, path string) (int, string) {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", filepath.Base(path), err)
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatalf("%s is not an envelope: %v", filepath.Base(path), err)
+	}
+	var data bytes.Buffer
+	if err := json.Compact(&data, env.Data); err != nil {
+		t.Fatalf("%s has invalid data: %v", filepath.Base(path), err)
+	}
+	return env.SchemaVersion, data.String()
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("write %s: %v", filepath.Base(path), err)
+	}
+}
+
+func TestSaveJSONLeavesNoTempFiles(t *testing.T) {
+	s := newStorage(t)
+
+	if err := s.SaveJSON("buttons.json", []string{"one"}); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	if err := s.SaveJSONFiles(map[string]interface{}{
+		"buttons.json":  []string{"two"},
+		"sessions.json": []string{"s1"},
+	}); err != nil {
+		t.Fatalf("SaveJSONFiles: %v", err)
+	}
+	if err := s.SaveJSONFiles(map[string]interface{}{
+		"buttons.json":  []string{"three"},
+		"sessions.json": func() {}, // Cannot be encoded
+	}); err == nil {
+		t.Fatal("expected SaveJSONFiles to fail")
+	}
+
+	entries, err := os.ReadDir(s.GetDataDir())
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+
+	// The failed save must not have replaced or backed up anything
+	version, data := readEnvelope(t, filepath.Join(s.GetDataDir(), "buttons.json"))
+	if version != SchemaVersion("buttons.json") || data != `["two"]` {
+		t.Errorf("buttons.json = v%d %s, want the second save", version, data)
+	}
+	if _, data := readEnvelope(t, s.backupPath("buttons.json", 1)); data != `["one"]` {
+		t.Errorf("buttons.json.bak.1 = %s, want the first save", data)
+	}
+}
+
+func TestSaveJSONRotatesBackups(t *testing.T) {
+	s := newStorage(t)
+
+	for i := 0; i < backupCount+2; i++ {
+		if err := s.SaveJSON("buttons.json", i); err != nil {
+			t.Fatalf("SaveJSON %d: %v", i, err)
+		}
+	}
+
+	// Save i leaves saves i-1 to i-backupCount in the backups, newest first
+	last := backupCount + 1
+	if _, data := readEnvelope(t, filepath.Join(s.GetDataDir(), "buttons.json")); data != strconv.Itoa(last) {
+		t.Errorf("buttons.json = %s, want %d", data, last)
+	}
+	for n := 1; n <= backupCount; n++ {
+		want := strconv.Itoa(last - n)
+		if _, data := readEnvelope(t, s.backupPath("buttons.json", n)); data != want {
+			t.Errorf("buttons.json.bak.%d = %s, want %s", n, data, want)
+		}
+	}
+	if _, err := os.Stat(s.backupPath("buttons.json", backupCount+1)); !os.IsNotExist(err) {
+		t.Errorf("more than %d backups kept", backupCount)
+	}
+}
+
+func TestSaveJSONSkipsBackupOfUnreadableFile(t *testing.T) {
+	s := newStorage(t)
+	path := filepath.Join(s.GetDataDir(), "buttons.json")
+
+	if err := s.SaveJSON("buttons.json", "good"); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	if err := s.SaveJSON("buttons.json", "newer"); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	writeFile(t, path, `{"schema_version": 1, "da`) // Truncated
+
+	if err := s.SaveJSON("buttons.json", "fixed"); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	if _, data := readEnvelope(t, s.backupPath("buttons.json", 1)); data != `"good"` {
+		t.Errorf("buttons.json.bak.1 = %s, want the last good save", data)
+	}
+	if _, err := os.Stat(s.backupPath("buttons.json", 2)); !os.IsNotExist(err) {
+		t.Error("the truncated file was rotated into the backups")
+	}
+}
+
+func TestLoadJSONFallsBackToBackup(t *testing.T) {
+	s := newStorage(t)
+	path := filepath.Join(s.GetDataDir(), "buttons.json")
+
+	var missing []string
+	if err := s.LoadJSON("buttons.json", &missing); err != nil || missing != nil {
+		t.Fatalf("LoadJSON of a missing file = %v (%v), want nothing", missing, err)
+	}
+
+	if err := s.SaveJSON("buttons.json", []string{"one"}); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	if err := s.SaveJSON("buttons.json", []string{"two"}); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+
+	for name, contents := range map[string]string{
+		"empty":   "",
+		"corrupt": `["tw`,
+		"no data": `{"schema_version": 1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeFile(t, path, contents)
+			var loaded []string
+			if err := s.LoadJSON("buttons.json", &loaded); err != nil {
+				t.Fatalf("LoadJSON: %v", err)
+			}
+			if len(loaded) != 1 || loaded[0] != "one" {
+				t.Errorf("loaded %v, want the backup", loaded)
+			}
+		})
+	}
+
+	os.Remove(path)
+	var loaded []string
+	if err := s.LoadJSON("buttons.json", &loaded); err != nil || len(loaded) != 1 {
+		t.Errorf("LoadJSON of a deleted file with backups = %v (%v), want the backup", loaded, err)
+	}
+
+	writeFile(t, path, `["tw`)
+	for n := 1; n <= backupCount; n++ {
+		os.Remove(s.backupPath("buttons.json", n))
+	}
+	if err := s.LoadJSON("buttons.json", &loaded); err == nil {
+		t.Error("LoadJSON of a corrupt file without backups succeeded")
+	}
+}
+
+func TestLoadJSONRejectsNewerSchema(t *testing.T) {
+	s := newStorage(t)
+	writeFile(t, filepath.Join(s.GetDataDir(), "buttons.json"), `{"schema_version": 99, "data": []}`)
+
+	var loaded []string
+	if err := s.LoadJSON("buttons.json", &loaded); err == nil {
+		t.Error("LoadJSON of a newer schema succeeded")
+	}
+}
+
+func TestLoadJSONMigratesUnversionedFile(t *testing.T) {
+	s := newStorage(t)
+	path := filepath.Join(s.GetDataDir(), "buttons.json")
+	writeFile(t, path, `[{"id": "b1", "name": "One"}]`)
+
+	var buttons []models.Button
+	if err := s.LoadJSON("buttons.json", &buttons); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if len(buttons) != 1 || buttons[0].Name != "One" {
+		t.Errorf("buttons = %+v", buttons)
+	}
+
+	version, _ := readEnvelope(t, path)
+	if version != SchemaVersion("buttons.json") {
+		t.Errorf("migrated file has schema version %d, want %d", version, SchemaVersion("buttons.json"))
+	}
+	if _, err := os.Stat(s.backupPath("buttons.json", 1)); err != nil {
+		t.Errorf("the unversioned file was not backed up before migrating: %v", err)
+	}
+}
+
+func TestLoadJSONMigratesConfigPages(t *testing.T) {
+	for name, contents := range map[string]string{
+		"v0": `[{"id": "c1", "buttons": {"btn-1-2": "b1"}}]`,
+		"v1": `{"schema_version": 1, "data": [{"id": "c1", "buttons": {"btn-1-2": "b1"}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newStorage(t)
+			path := filepath.Join(s.GetDataDir(), "configs.json")
+			writeFile(t, path, contents)
+
+			var configs []models.Configuration
+			if err := s.LoadJSON("configs.json", &configs); err != nil {
+				t.Fatalf("LoadJSON: %v", err)
+			}
+			if len(configs) != 1 {
+				t.Fatalf("configurations = %+v", configs)
+			}
+			if buttons := configs[0].Buttons; len(buttons) != 1 || buttons["btn-0-1-2"] != "b1" {
+				t.Errorf("buttons = %v, want btn-0-1-2 on the first page", buttons)
+			}
+			if pages := configs[0].Pages; len(pages) != 1 || pages[0].Name != "Page 1" {
+				t.Errorf("pages = %+v, want one page", pages)
+			}
+
+			if version, _ := readEnvelope(t, path); version != 2 {
+				t.Errorf("migrated file has schema version %d, want 2", version)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigPagesKeepsPagedConfigs(t *testing.T) {
+	data, err := migrateConfigPages(json.RawMessage(`[{"buttons": {"btn-1-0-0": "b1"}, "pages": [{"name": "A"}, {"name": "B"}]}]`))
+	if err != nil {
+		t.Fatalf("migrateConfigPages: %v", err)
+	}
+
+	var configs []models.Configuration
+	if err := json.Unmarshal(data, &configs); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if configs[0].Buttons["btn-1-0-0"] != "b1" || len(configs[0].Pages) != 2 {
+		t.Errorf("configuration = %+v, want it unchanged", configs[0])
+	}
+}